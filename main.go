@@ -7,7 +7,7 @@ import (
 	"github.com/kemalersin/hobaa/pkg/resources"
 )
 
-//go:embed resources/default.ico resources/rcedit.exe resources/icons/ico/* sites.json
+//go:embed resources/default.ico resources/icons/ico/* sites.json
 var embeddedFiles embed.FS
 
 func main() {
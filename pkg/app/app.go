@@ -8,45 +8,66 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
+	"time"
 
 	"github.com/kemalersin/hobaa/pkg/config"
-	"github.com/kemalersin/hobaa/pkg/dpi"
+	"github.com/kemalersin/hobaa/pkg/geometry"
+	"github.com/kemalersin/hobaa/pkg/instance"
+	"github.com/kemalersin/hobaa/pkg/pagetitle"
+	"github.com/kemalersin/hobaa/pkg/platform"
 	"github.com/kemalersin/hobaa/pkg/resources"
+	"github.com/kemalersin/hobaa/pkg/scope"
 	"github.com/kemalersin/hobaa/pkg/utils"
 	"github.com/kemalersin/hobaa/pkg/webview"
-	"github.com/kemalersin/hobaa/pkg/winapi"
 )
 
 // App represents the main application
 type App struct {
+	platform    platform.Platform
 	webView     *webview.WebView
 	appDataDir  string
 	iconsDir    string
 	webViewDir  string
 	execName    string
+	siteName    string
+	profileName string
 	execDir     string
 	execPath    string
-	siteConfig  *config.SiteConfig
-	currentSite *config.Site
+	siteStore   *config.Store
+	currentSite *config.Site // Snapshot of the current site, refreshed by updateSites
+	scope       *scope.Matcher
+	instance    *instance.Instance
 	forceMode   bool
 	iconChanged bool
-	hwnd        syscall.Handle
+	hwnd        uintptr
 	changeIcon  bool
 	targetExe   string
+	urlArgs     []string
+	routeURL    string
+	link        string
 	iconPath    string
+	title       string // Title of the site, used as window title unless a title template is set
+	windowIcon  string
+	badgeCount  int // Count drawn over the window icon
+
+	titleUpdates *pagetitle.Throttle // Window title changes from the page, nil without a title template
+
+	registerProtocol   bool
+	unregisterProtocol bool
 }
 
 // New creates a new application instance
 func New() *App {
-	// Enable high DPI support
-	dpi.SetProcessDpiAwareness()
-
 	// Create app instance
-	app := &App{}
+	app := &App{
+		platform: platform.Default(),
+	}
+
+	// Enable high DPI support
+	app.platform.SetDpiAwareness()
 
 	// Parse command line flags
-	app.parseFlags()
+	app.parseFlags(os.Args[1:])
 
 	// Initialize app data directories
 	app.initAppData()
@@ -60,6 +81,24 @@ func New() *App {
 		return app
 	}
 
+	// If in router mode, open the URL in its site app and exit
+	if app.routeURL != "" {
+		app.routeAndExit()
+		return app
+	}
+
+	// If a hobaa:// link is given, carry out its action and exit
+	if app.link != "" {
+		app.handleLinkAndExit()
+		return app
+	}
+
+	// If in protocol registration mode, update the hobaa:// handler and exit
+	if app.registerProtocol || app.unregisterProtocol {
+		app.registerProtocolAndExit()
+		return app
+	}
+
 	// Load site configuration
 	app.loadSiteConfig()
 
@@ -70,21 +109,33 @@ func New() *App {
 }
 
 // parseFlags parses command line flags
-func (a *App) parseFlags() {
+func (a *App) parseFlags(args []string) {
 	// Define flags
-	forceFlag := flag.Bool("force", false, "Force application to start")
-	changeIconFlag := flag.Bool("change-icon", false, "Change icon of target executable")
-	targetExeFlag := flag.String("target-exe", "", "Target executable to change icon")
-	iconPathFlag := flag.String("icon-path", "", "Path to icon file")
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	forceFlag := flags.Bool("force", false, "Force application to start")
+	changeIconFlag := flags.Bool("change-icon", false, "Change icon of target executable")
+	targetExeFlag := flags.String("target-exe", "", "Target executable to change icon")
+	iconPathFlag := flags.String("icon-path", "", "Path to icon file")
+	routeFlag := flags.String("route", "", "Open a URL in the site app that owns it")
+	linkFlag := flags.String("link", "", "Open a hobaa:// link")
+	registerProtocolFlag := flags.Bool("register-protocol", false, "Register this executable as the handler of hobaa:// links")
+	unregisterProtocolFlag := flags.Bool("unregister-protocol", false, "Remove the hobaa:// link handler")
 
 	// Parse flags
-	flag.Parse()
+	flags.Parse(args)
 
 	// Set force mode
 	a.forceMode = *forceFlag
 	a.changeIcon = *changeIconFlag
 	a.targetExe = *targetExeFlag
 	a.iconPath = *iconPathFlag
+	a.routeURL = *routeFlag
+	a.link = *linkFlag
+	a.registerProtocol = *registerProtocolFlag
+	a.unregisterProtocol = *unregisterProtocolFlag
+
+	// Positional arguments are URLs to open, e.g. github.exe https://github.com/org/repo
+	a.urlArgs = flags.Args()
 }
 
 // initAppData initializes the application data directories
@@ -102,6 +153,9 @@ func (a *App) initAppData() {
 		a.execName = a.execName[:len(a.execName)-len(ext)]
 	}
 
+	// Split EXE name into site and profile names, e.g. gmail@work
+	a.siteName, a.profileName = config.ParseExecName(a.execName)
+
 	// Get AppData directory
 	appData := os.Getenv("APPDATA")
 	if appData == "" {
@@ -118,19 +172,10 @@ func (a *App) initAppData() {
 	// Create icons directory
 	a.iconsDir = filepath.Join(a.appDataDir, "icons")
 	os.MkdirAll(a.iconsDir, 0755)
-
-	// Set WebView directory to AppData directory
-	a.webViewDir = a.appDataDir
 }
 
 // extractResources extracts resources to the AppData directory
 func (a *App) extractResources() {
-	// Copy rcedit.exe if it doesn't exist
-	rceditPath := filepath.Join(a.appDataDir, "rcedit.exe")
-	if _, err := os.Stat(rceditPath); os.IsNotExist(err) {
-		resources.CopyRcedit(a.execDir, rceditPath)
-	}
-
 	// Copy default icon if it doesn't exist
 	iconPath := filepath.Join(a.iconsDir, "hobaa.ico")
 	if _, err := os.Stat(iconPath); os.IsNotExist(err) {
@@ -155,21 +200,18 @@ func (a *App) changeIconAndExit() {
 		os.Exit(1)
 	}
 
-	// Get rcedit path
-	rceditPath := filepath.Join(a.appDataDir, "rcedit.exe")
-
 	// Wait a bit to ensure the original process has exited
-	winapi.Sleep(1000)
+	time.Sleep(1 * time.Second)
 
 	// Change the icon
-	err := utils.SetExecutableIcon(a.targetExe, a.iconPath, rceditPath)
+	err := utils.SetExecutableIcon(a.targetExe, a.iconPath)
 	if err != nil {
 		fmt.Printf("Failed to set icon: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Clear icon cache
-	winapi.ClearIconCache()
+	a.platform.ClearIconCache()
 
 	// Start the original executable with --force parameter
 	cmd := exec.Command(a.targetExe, "--force")
@@ -181,42 +223,50 @@ func (a *App) changeIconAndExit() {
 
 // loadSiteConfig loads the site configuration
 func (a *App) loadSiteConfig() {
-	// Create site configuration
-	a.siteConfig = config.NewSiteConfig(a.appDataDir)
-
-	// Get sites.json paths
+	// Create site store
 	appDataSitesPath := config.GetAppDataSitesPath(a.appDataDir)
+	a.siteStore = config.NewStore(appDataSitesPath, config.DefaultSaveDelay)
 
 	// Load sites from AppData
-	a.siteConfig.LoadFromFile(appDataSitesPath)
+	a.siteStore.Load()
 
 	// Check if current EXE filename exists in sites.json
-	a.currentSite = a.siteConfig.GetSiteByName(a.execName)
+	a.currentSite, _ = a.siteStore.Site(a.siteName)
 
 	// If this is the main hobaa.exe and the site doesn't exist, create a default site
-	if a.execName == "hobaa" && a.currentSite == nil {
+	if a.siteName == "hobaa" && a.currentSite == nil {
 		// Create default site for hobaa
-		site := config.CreateSiteFromURL(a.execName, "https://www.google.com")
+		site := config.CreateSiteFromURL(a.siteName, config.DefaultSiteURL)
 		site.IsActive = true
-		a.siteConfig.AddSite(site)
-		a.siteConfig.SaveToFile(appDataSitesPath)
-		a.currentSite = a.siteConfig.GetSiteByName(a.execName)
+		a.updateSites(func(c *config.SiteConfig) {
+			if c.GetSiteByName(site.Name) == nil {
+				c.AddSite(site)
+			}
+		})
 	}
 }
 
+// updateSites writes changes to the AppData sites.json right away and refreshes the current site.
+// Changes are applied to the latest file contents, so sites changed by other processes are kept.
+func (a *App) updateSites(update func(c *config.SiteConfig)) error {
+	err := a.siteStore.UpdateNow(update)
+	a.currentSite, _ = a.siteStore.Site(a.siteName)
+	return err
+}
+
+// activateSite sets the site as active and all others as inactive
+func (a *App) activateSite(name string) {
+	a.updateSites(func(c *config.SiteConfig) {
+		c.SetActiveSite(name)
+	})
+}
+
 // handleSiteConfig handles site configuration based on EXE filename
 func (a *App) handleSiteConfig() {
-	// Get sites.json paths
-	appDataSitesPath := config.GetAppDataSitesPath(a.appDataDir)
-	workingDirSitesPath := config.GetWorkingDirSitesPath(a.execDir)
-
 	// If force mode is enabled, set current site as active
 	if a.forceMode && a.currentSite != nil {
 		// Set current site as active and all others as inactive
-		a.siteConfig.SetActiveSite(a.execName)
-
-		// Save to AppData
-		a.siteConfig.SaveToFile(appDataSitesPath)
+		a.activateSite(a.siteName)
 
 		// Clear Windows application cache
 		a.clearWindowsCache()
@@ -231,7 +281,7 @@ func (a *App) handleSiteConfig() {
 	}
 
 	// Check if icon exists for current EXE name
-	iconPath := filepath.Join(a.iconsDir, a.execName+".ico")
+	iconPath := filepath.Join(a.iconsDir, a.siteName+".ico")
 	iconExists := false
 	if _, err := os.Stat(iconPath); !os.IsNotExist(err) {
 		iconExists = true
@@ -240,10 +290,7 @@ func (a *App) handleSiteConfig() {
 	// If site exists but is not active, and icon exists, just set it active and use existing icon
 	if a.currentSite != nil && !a.currentSite.IsActive && iconExists {
 		// Set current site as active
-		a.siteConfig.SetActiveSite(a.execName)
-
-		// Save to AppData
-		a.siteConfig.SaveToFile(appDataSitesPath)
+		a.activateSite(a.siteName)
 
 		// Set icon change flag and launch icon changer if needed
 		a.iconChanged = true
@@ -253,145 +300,131 @@ func (a *App) handleSiteConfig() {
 		return
 	}
 
-	// If site is not found or not active, check other sources
-	if a.currentSite == nil || !a.currentSite.IsActive {
-		// Check working directory sites.json
-		workingDirConfig := config.NewSiteConfig(a.execDir)
-		if err := workingDirConfig.LoadFromFile(workingDirSitesPath); err == nil {
-			if site := workingDirConfig.GetSiteByName(a.execName); site != nil {
-				a.updateSiteFromSource(site)
-				return
-			}
-		}
+	// Resolve site from the configured sources
+	resolution, err := a.newSiteResolver().Resolve(a.siteName)
+	if err != nil {
+		fmt.Printf("Failed to resolve site %s: %v\n", a.siteName, err)
 
-		// Check GitHub sites.json
-		githubConfig := config.NewSiteConfig("")
-		if err := githubConfig.LoadFromGitHub(config.DefaultGitHubSitesURL); err == nil {
-			if site := githubConfig.GetSiteByName(a.execName); site != nil {
-				a.updateSiteFromSource(site)
-				return
-			}
+		// Only fall back to the default site if the user agrees, a source may just be unreachable
+		question := fmt.Sprintf("Hobaa couldn't look up %s:\n\n%v\n\nOpen %s instead?", a.siteName, err, config.DefaultSiteURL)
+		if !a.platform.Confirm(0, "Hobaa", question) {
+			return
 		}
+		site, _ := config.NewDefaultSource(config.DefaultSiteURL).Lookup(a.siteName)
+		resolution = &config.Resolution{Site: *site, Source: config.SourceDefault}
+	}
 
-		// If still not found, check if EXE name is a URL
-		if utils.IsValidURL(a.execName) || utils.IsValidURL("https://"+a.execName) {
-			// Create URL if needed
-			url := a.execName
-			if !strings.HasPrefix(url, "http") {
-				url = "https://" + url
-			}
-
-			// Get favicon URL
-			faviconURL, err := utils.GetFaviconURL(url)
+	switch resolution.Source {
+	case config.SourceURL:
+		a.applyURLSite(resolution.Site, iconExists)
+	case config.SourceDefault:
+		a.applyDefaultSite(resolution.Site, iconExists)
+	default:
+		a.updateSiteFromSource(&resolution.Site)
+		return
+	}
 
-			// Create site from URL
-			site := config.CreateSiteFromURL(a.execName, url)
+	// If icon was changed, restart application
+	if a.iconChanged && !a.forceMode {
+		a.restartWithForce()
+	}
+}
 
-			// Set icon URL if available
-			if err == nil && faviconURL != "" {
-				site.Icon = faviconURL
-			} else {
-				// If favicon URL cannot be retrieved, use default icon path
-				defaultIconPath := filepath.Join(a.iconsDir, "hobaa.ico")
-				if _, err := os.Stat(defaultIconPath); !os.IsNotExist(err) {
-					// Use a placeholder URL to indicate default icon
-					site.Icon = "default://hobaa.ico"
-				}
-			}
+// newSiteResolver creates the resolver used to find the current site.
+// Sites in the AppData sites.json are handled before resolving, so inactive ones are refreshed from the other sources.
+func (a *App) newSiteResolver() *config.Resolver {
+	return config.NewResolver(
+		config.NewFileSource(config.SourceWorkingDir, config.GetWorkingDirSitesPath(a.execDir)),
+		config.NewRemoteSource(config.DefaultGitHubSitesURL),
+		config.NewCatalogSource(config.SourceEmbedded, resources.ReadSitesJson),
+		config.NewURLSource(),
+		config.NewDefaultSource(config.DefaultSiteURL),
+	)
+}
 
-			// Try to download favicon only if icon doesn't exist
-			if !iconExists {
-				a.downloadFavicon(url, a.execName)
-			} else {
-				// If icon exists, set icon change flag and launch icon changer if needed
-				a.iconChanged = true
-				if !a.forceMode {
-					a.launchIconChanger(iconPath)
-				}
-			}
+// applyURLSite adds a site created from a URL-formatted EXE name
+func (a *App) applyURLSite(site config.Site, iconExists bool) {
+	iconPath := filepath.Join(a.iconsDir, a.siteName+".ico")
 
-			// Add site to config and set as active
-			a.siteConfig.AddSite(site)
-			a.siteConfig.SetActiveSite(a.execName)
-			a.siteConfig.SaveToFile(appDataSitesPath)
-			a.currentSite = a.siteConfig.GetSiteByName(a.execName)
-		} else {
-			// EXE name is not a URL and not found in any config, create default site with Google
-			// Use default icon
-			defaultIconPath := filepath.Join(a.iconsDir, "hobaa.ico")
-			iconPath := filepath.Join(a.iconsDir, a.execName+".ico")
-
-			// Copy default icon to site-specific icon if it doesn't exist
-			if !iconExists {
-				// First check if the icon exists in resources
-				err := resources.EnsureIconExists(a.execName+".ico", a.iconsDir)
-				if err != nil && defaultIconPath != "" {
-					// If not in resources, copy the default icon
-					if _, err := os.Stat(defaultIconPath); !os.IsNotExist(err) {
-						resources.CopyFile(defaultIconPath, iconPath)
-						iconExists = true
-					}
-				} else {
-					iconExists = true
-				}
-			}
+	// Use the icon found on the start page by the URL source, or the conventional favicon location
+	faviconURL := site.Icon
+	if faviconURL == "" {
+		faviconURL, _ = utils.ProbeFaviconURL(site.URL)
+	}
 
-			// Create default site with Google URL
-			site := config.CreateSiteFromURL(a.execName, "https://www.google.com")
+	// Set icon URL if available
+	if faviconURL != "" {
+		site.Icon = faviconURL
+	} else {
+		// If favicon URL cannot be retrieved, use default icon path
+		defaultIconPath := filepath.Join(a.iconsDir, "hobaa.ico")
+		if _, err := os.Stat(defaultIconPath); !os.IsNotExist(err) {
+			// Use a placeholder URL to indicate default icon
 			site.Icon = "default://hobaa.ico"
-			a.siteConfig.AddSite(site)
-			a.siteConfig.SetActiveSite(a.execName)
-			a.siteConfig.SaveToFile(appDataSitesPath)
-			a.currentSite = a.siteConfig.GetSiteByName(a.execName)
-
-			// Set icon change flag and launch icon changer if needed
-			if iconExists && !a.forceMode {
-				a.iconChanged = true
-				a.launchIconChanger(iconPath)
-			}
 		}
-	} else if iconExists && !a.forceMode {
-		// If icon exists but we're not in force mode, trigger icon change
-		a.iconChanged = true
-		a.launchIconChanger(iconPath)
 	}
 
-	// If icon was changed, restart application
-	if a.iconChanged && !a.forceMode {
-		a.restartWithForce()
+	// Try to download favicon only if icon doesn't exist
+	if !iconExists {
+		a.downloadFavicon(faviconURL, a.siteName)
+	} else {
+		// If icon exists, set icon change flag and launch icon changer if needed
+		a.iconChanged = true
+		if !a.forceMode {
+			a.launchIconChanger(iconPath)
+		}
 	}
-}
 
-// updateSiteFromSource updates a site from a source configuration
-func (a *App) updateSiteFromSource(site *config.Site) {
-	// Get sites.json path
-	appDataSitesPath := config.GetAppDataSitesPath(a.appDataDir)
+	// Add site to config and set as active
+	a.updateSites(func(c *config.SiteConfig) {
+		c.AddSite(site)
+		c.SetActiveSite(a.siteName)
+	})
+}
 
-	// Check if site already exists in config
-	existingSite := a.siteConfig.GetSiteByName(site.Name)
+// applyDefaultSite adds a site using the default URL and icon
+func (a *App) applyDefaultSite(site config.Site, iconExists bool) {
+	// Use default icon
+	defaultIconPath := filepath.Join(a.iconsDir, "hobaa.ico")
+	iconPath := filepath.Join(a.iconsDir, a.siteName+".ico")
 
-	// If site exists, preserve width and height
-	if existingSite != nil {
-		// Preserve width and height if they exist
-		if existingSite.Width > 0 {
-			site.Width = existingSite.Width
-		}
-		if existingSite.Height > 0 {
-			site.Height = existingSite.Height
+	// Copy default icon to site-specific icon if it doesn't exist
+	if !iconExists {
+		// First check if the icon exists in resources
+		err := resources.EnsureIconExists(a.siteName+".ico", a.iconsDir)
+		if err != nil {
+			// If not in resources, copy the default icon
+			if _, err := os.Stat(defaultIconPath); !os.IsNotExist(err) {
+				resources.CopyFile(defaultIconPath, iconPath)
+				iconExists = true
+			}
+		} else {
+			iconExists = true
 		}
 	}
 
+	// Add site to config and set as active
+	a.updateSites(func(c *config.SiteConfig) {
+		c.AddSite(site)
+		c.SetActiveSite(a.siteName)
+	})
+
+	// Set icon change flag and launch icon changer if needed
+	if iconExists && !a.forceMode {
+		a.iconChanged = true
+		a.launchIconChanger(iconPath)
+	}
+}
+
+// updateSiteFromSource updates a site from a source configuration
+func (a *App) updateSiteFromSource(site *config.Site) {
 	// Set site as active
 	site.IsActive = true
 
-	// Add site to config
-	a.siteConfig.AddSite(*site)
-	a.siteConfig.SetActiveSite(site.Name)
-
 	// Check if icon URL is specified
 	if site.Icon != "" {
 		// Get icon filename from URL
-		iconName := a.execName + ".ico"
+		iconName := a.siteName + ".ico"
 
 		// If icon URL contains a filename, use that instead
 		if strings.Contains(site.Icon, "/") {
@@ -415,7 +448,7 @@ func (a *App) updateSiteFromSource(site *config.Site) {
 			err := resources.EnsureIconExists(iconName, a.iconsDir)
 			if err != nil {
 				// If not in resources, try to download it
-				a.downloadIcon(site.Icon, a.execName)
+				a.downloadIcon(site.Icon, a.siteName)
 			}
 		}
 
@@ -427,8 +460,42 @@ func (a *App) updateSiteFromSource(site *config.Site) {
 	}
 
 	// Save to AppData
-	a.siteConfig.SaveToFile(appDataSitesPath)
-	a.currentSite = a.siteConfig.GetSiteByName(site.Name)
+	a.updateSites(func(c *config.SiteConfig) {
+		// If site exists, preserve window placement, profile settings, executable, permissions, badge rule, title template and download directory if they exist
+		if existingSite := c.GetSiteByName(site.Name); existingSite != nil {
+			if existingSite.Width > 0 {
+				site.Width = existingSite.Width
+			}
+			if existingSite.Height > 0 {
+				site.Height = existingSite.Height
+			}
+			if site.Window == nil {
+				site.Window = existingSite.Window
+			}
+			if site.Executable == "" {
+				site.Executable = existingSite.Executable
+			}
+			if site.Notifications == "" {
+				site.Notifications = existingSite.Notifications
+			}
+			if site.Badge == nil {
+				site.Badge = existingSite.Badge
+			}
+			if site.TitleTemplate == "" {
+				site.TitleTemplate = existingSite.TitleTemplate
+			}
+			if site.DownloadDir == "" {
+				site.DownloadDir = existingSite.DownloadDir
+			}
+			if site.Profiles == nil {
+				site.Profiles = existingSite.Profiles
+			}
+		}
+
+		// Add site to config and set as active
+		c.AddSite(*site)
+		c.SetActiveSite(site.Name)
+	})
 }
 
 // downloadIcon downloads an icon from a URL
@@ -453,8 +520,7 @@ func (a *App) downloadIcon(iconURL, name string) {
 		// Check if downloaded file is an ICO file
 		if !utils.IsICOFile(tempPath) {
 			// Convert to ICO
-			rceditPath := filepath.Join(a.appDataDir, "rcedit.exe")
-			if err := utils.ConvertToICO(tempPath, rceditPath, iconPath); err != nil {
+			if err := utils.ConvertToICO(tempPath, iconPath); err != nil {
 				// If conversion fails, use default icon
 				defaultIconPath := filepath.Join(a.iconsDir, "hobaa.ico")
 				if _, err := os.Stat(defaultIconPath); !os.IsNotExist(err) {
@@ -529,11 +595,9 @@ func copyFile(src, dst string) error {
 	return dstFile.Sync()
 }
 
-// downloadFavicon downloads a favicon from a website
-func (a *App) downloadFavicon(websiteURL, name string) {
-	// Get favicon URL
-	faviconURL, err := utils.GetFaviconURL(websiteURL)
-	if err != nil {
+// downloadFavicon downloads the favicon of a website, or uses a bundled or default icon if it has none
+func (a *App) downloadFavicon(faviconURL, name string) {
+	if faviconURL == "" {
 		// If favicon URL cannot be retrieved, first check if the icon exists in resources
 		iconPath := filepath.Join(a.iconsDir, name+".ico")
 
@@ -564,38 +628,92 @@ func (a *App) downloadFavicon(websiteURL, name string) {
 
 	// Update site configuration with icon URL
 	if a.currentSite != nil {
-		a.currentSite.Icon = faviconURL
-
-		// Save to AppData
-		appDataSitesPath := config.GetAppDataSitesPath(a.appDataDir)
-		a.siteConfig.SaveToFile(appDataSitesPath)
+		a.updateSites(func(c *config.SiteConfig) {
+			if site := c.GetSiteByName(a.siteName); site != nil {
+				site.Icon = faviconURL
+			}
+		})
 	}
 }
 
 // clearWindowsCache clears Windows application cache without restarting Explorer
 func (a *App) clearWindowsCache() {
 	// Use Shell API to clear icon cache
-	winapi.ClearIconCache()
+	a.platform.ClearIconCache()
 }
 
 // restartWithForce restarts the application with the --force parameter
 func (a *App) restartWithForce() {
-	utils.RestartApplication()
+	utils.RestartApplication(a.platform.Command)
 }
 
-// SaveWindowSizeToConfig saves the window size to the site configuration
-func (a *App) SaveWindowSizeToConfig(width, height int) error {
-	// Update the current site with the new dimensions
-	if a.currentSite != nil {
-		a.currentSite.Width = width
-		a.currentSite.Height = height
+// SaveWindowPlacementToConfig saves the window size, position and state to the site configuration.
+// It is safe to call from any goroutine; the change is written after a short delay.
+func (a *App) SaveWindowPlacementToConfig(placement geometry.Placement) {
+	// Update the current site with the new placement
+	name, profile := a.siteName, a.profileName
+	a.siteStore.Update(func(c *config.SiteConfig) {
+		if site := c.GetSiteByName(name); site != nil {
+			site.SetWindowPlacement(profile, placement)
+		}
+	})
+}
 
-		// Save to AppData
-		appDataSitesPath := config.GetAppDataSitesPath(a.appDataDir)
-		return a.siteConfig.SaveToFile(appDataSitesPath)
+// watchWindow saves the window placement whenever the window is moved, resized or changes state.
+// Placements are not saved while the user is still dragging the window.
+// The returned channel is closed once the window is destroyed.
+func (a *App) watchWindow() <-chan struct{} {
+	done := make(chan struct{})
+	events, err := a.platform.WatchWindow(a.hwnd)
+	if err != nil {
+		fmt.Printf("Failed to watch window: %v\n", err)
+		close(done)
+		return done
 	}
 
-	return nil
+	go func() {
+		defer close(done)
+		for event := range events {
+			if !event.Dragging {
+				a.SaveWindowPlacementToConfig(event.Placement)
+			}
+		}
+	}()
+	return done
+}
+
+// restoreWindowPlacement moves the window to its saved position on a monitor that is still attached.
+// The saved size is converted to the DPI of that monitor.
+func (a *App) restoreWindowPlacement(monitors []geometry.Monitor) {
+	if a.currentSite == nil {
+		return
+	}
+	placement, ok := a.currentSite.WindowPlacement(a.profileName)
+	if !ok || len(monitors) == 0 {
+		return // Keep the window centered
+	}
+
+	placement = geometry.Clamp(placement, monitors)
+	if err := a.platform.SetWindowPlacement(a.hwnd, placement); err != nil {
+		fmt.Printf("Failed to restore window placement: %v\n", err)
+	}
+}
+
+// convertLegacySizes converts window sizes saved in physical pixels by older versions to DPI-independent pixels.
+// Those versions used the system DPI, which is the DPI of the primary monitor.
+func (a *App) convertLegacySizes(monitors []geometry.Monitor) {
+	if !a.siteStore.Settings().HasPendingSizeConversions() {
+		return
+	}
+	primary, ok := geometry.PrimaryMonitor(monitors)
+	if !ok || primary.DPI <= 0 {
+		return // Convert once the DPI is known
+	}
+	if err := a.updateSites(func(c *config.SiteConfig) {
+		c.ConvertLegacySizes(primary.DPI)
+	}); err != nil {
+		fmt.Printf("Failed to convert window sizes: %v\n", err)
+	}
 }
 
 // Run starts the application
@@ -607,9 +725,27 @@ func (a *App) Run() {
 
 	// Check if site exists and is active, or if force mode is enabled
 	if (a.currentSite != nil && a.currentSite.IsActive) || a.forceMode {
+		// Hand over to the running instance of the site if there is one
+		if !a.acquireInstance() {
+			return
+		}
+		if a.instance != nil {
+			defer a.instance.Close()
+		}
+
+		// Remember where the site app is so the router can launch it
+		a.recordExecutable()
+
+		// List monitors, converting window sizes saved in physical pixels by older versions first
+		monitors, err := a.platform.Monitors()
+		if err != nil {
+			fmt.Printf("Failed to list monitors: %v\n", err)
+		}
+		a.convertLegacySizes(monitors)
+
 		// Set default title, URL, and dimensions
 		title := "Hobaa"
-		url := "https://www.google.com"
+		url := config.DefaultSiteURL
 		width := 1920
 		height := 1080
 
@@ -621,18 +757,19 @@ func (a *App) Run() {
 			if a.currentSite.URL != "" {
 				url = a.currentSite.URL
 			}
-			if a.currentSite.Width > 0 {
-				width = a.currentSite.Width
+			siteWidth, siteHeight := a.currentSite.WindowSize(a.profileName)
+			if siteWidth > 0 {
+				width = siteWidth
 			}
-			if a.currentSite.Height > 0 {
-				height = a.currentSite.Height
+			if siteHeight > 0 {
+				height = siteHeight
 			}
 		}
 
 		// Validate URL
 		if !utils.IsValidURL(url) {
 			// If URL is not valid, use Google
-			url = "https://www.google.com"
+			url = config.DefaultSiteURL
 		}
 
 		// Capitalize first letter of title
@@ -640,17 +777,30 @@ func (a *App) Run() {
 			title = strings.ToUpper(title[:1]) + title[1:]
 		}
 
+		// Add profile name to the title so windows of different profiles can be told apart
+		title += a.currentSite.TitleSuffix(a.profileName)
+		a.title = title
+
 		// Get icon path for the window title
-		iconPath := filepath.Join(a.iconsDir, a.execName+".ico")
+		iconPath := filepath.Join(a.iconsDir, a.siteName+".ico")
 		if _, err := os.Stat(iconPath); os.IsNotExist(err) {
 			// Use default icon if specific icon doesn't exist
 			iconPath = filepath.Join(a.iconsDir, "hobaa.ico")
 		}
+		a.windowIcon = iconPath
+
+		// Convert the DPI-independent size to pixels of the primary monitor the window opens on
+		if primary, ok := geometry.PrimaryMonitor(monitors); ok {
+			width = geometry.ToPhysical(width, primary.DPI)
+			height = geometry.ToPhysical(height, primary.DPI)
+		}
+
+		// Use a separate WebView profile for each site
+		a.webViewDir = a.prepareProfile()
 
 		// Create webview
-		a.webView = webview.New(webview.WindowOptions{
+		a.webView = a.platform.NewWebView(webview.WindowOptions{
 			Title:   title,
-			URL:     url,
 			Width:   width,
 			Height:  height,
 			Debug:   true,
@@ -660,14 +810,39 @@ func (a *App) Run() {
 		defer a.webView.Destroy()
 
 		// Get window handle
-		a.hwnd = syscall.Handle(uintptr(a.webView.Window()))
+		a.hwnd = uintptr(a.webView.Window())
+
+		// Open links outside the site scope in the default browser and add window.hobaa and the user scripts,
+		// then load the site or a URL within its scope passed on the command line
+		a.setupNavigationScope(url)
+		a.setupBridge()
+		a.setupUserScripts()
+		if launchURL := a.launchURL(); launchURL != "" {
+			url = launchURL
+		}
+		a.webView.Navigate(url)
 
-		// Start monitoring window size in a goroutine
-		go winapi.MonitorWindowSize(a.hwnd, func(width, height int) {
-			a.SaveWindowSizeToConfig(width, height)
-		})
+		// Write pending configuration changes on shutdown
+		defer a.siteStore.Close()
+
+		// Move the window to where it was closed
+		a.restoreWindowPlacement(monitors)
+
+		// Save the window placement when it changes
+		done := a.watchWindow()
+
+		// Focus the window when the site is launched again
+		a.serveInstance()
 
 		// Run webview
 		a.webView.Run()
+
+		// Drop title changes arriving after the window is closed
+		if a.titleUpdates != nil {
+			a.titleUpdates.Stop()
+		}
+
+		// Wait for the last placement to be saved before the store is closed
+		<-done
 	}
 }
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/kemalersin/hobaa/pkg/scope"
+)
+
+// launchURL returns the first URL argument within the site scope to load instead of the site URL.
+// URL arguments outside the scope are opened in the default browser.
+func (a *App) launchURL() string {
+	launchURL := ""
+	for _, arg := range a.urlArgs {
+		u, err := parseURLArg(arg)
+		if err != nil {
+			fmt.Printf("Ignoring argument %q: %v\n", arg, err)
+			continue
+		}
+
+		switch {
+		case a.scope != nil && !a.scope.Match(u):
+			if err := a.platform.OpenBrowser(u.String()); err != nil {
+				fmt.Printf("Failed to open %s in the default browser: %v\n", u, err)
+			}
+		case launchURL == "":
+			launchURL = u.String()
+		default:
+			fmt.Printf("Ignoring argument %q: only one URL can be opened\n", arg)
+		}
+	}
+	return launchURL
+}
+
+// openArg opens a URL argument forwarded by a later launch inside the app or in the default browser
+func (a *App) openArg(arg string) error {
+	u, err := parseURLArg(arg)
+	if err != nil {
+		return err
+	}
+	return a.openURL(u.String())
+}
+
+// parseURLArg parses a command line argument as an absolute http or https URL
+func parseURLArg(arg string) (*url.URL, error) {
+	u, err := url.Parse(arg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := scope.Normalize(u); !ok {
+		return nil, fmt.Errorf("not an http or https URL")
+	}
+	return u, nil
+}
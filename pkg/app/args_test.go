@@ -0,0 +1,150 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/platform"
+	"github.com/kemalersin/hobaa/pkg/scope"
+)
+
+// browserPlatform records the URLs opened in the default browser
+type browserPlatform struct {
+	platform.Platform
+	opened []string
+}
+
+func (p *browserPlatform) OpenBrowser(url string) error {
+	p.opened = append(p.opened, url)
+	return nil
+}
+
+func TestLaunchURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantLaunch string
+		wantOpened []string
+	}{
+		{
+			name: "no arguments",
+		},
+		{
+			name:       "url within the scope",
+			args:       []string{"https://github.com/org/repo/pull/1"},
+			wantLaunch: "https://github.com/org/repo/pull/1",
+		},
+		{
+			name:       "subdomain of the site",
+			args:       []string{"https://gist.github.com/user/1"},
+			wantLaunch: "https://gist.github.com/user/1",
+		},
+		{
+			name:       "url outside the scope",
+			args:       []string{"https://example.com/page"},
+			wantOpened: []string{"https://example.com/page"},
+		},
+		{
+			name:       "urls within and outside the scope",
+			args:       []string{"https://example.com/", "https://github.com/notifications", "https://example.net/"},
+			wantLaunch: "https://github.com/notifications",
+			wantOpened: []string{"https://example.com/", "https://example.net/"},
+		},
+		{
+			name:       "only the first url within the scope is opened",
+			args:       []string{"https://github.com/a", "https://github.com/b"},
+			wantLaunch: "https://github.com/a",
+		},
+
+		// Arguments that aren't http or https URLs are ignored
+		{
+			name: "not a url",
+			args: []string{"github.com/org/repo", "notes.txt", "", "%zz"},
+		},
+		{
+			name: "other schemes",
+			args: []string{"file:///C:/Windows/System32/calc.exe", "javascript:alert(1)", "hobaa://open/github"},
+		},
+		{
+			name:       "not a url before a url",
+			args:       []string{"repo", "https://github.com/org/repo"},
+			wantLaunch: "https://github.com/org/repo",
+		},
+	}
+	for _, tt := range tests {
+		p := &browserPlatform{}
+		matcher, err := scope.ForSite("https://github.com", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := &App{platform: p, scope: matcher, urlArgs: tt.args}
+		if got := a.launchURL(); got != tt.wantLaunch {
+			t.Errorf("%s: launch URL %q, want %q", tt.name, got, tt.wantLaunch)
+		}
+		if !reflect.DeepEqual(p.opened, tt.wantOpened) {
+			t.Errorf("%s: opened in the browser %v, want %v", tt.name, p.opened, tt.wantOpened)
+		}
+	}
+
+	// Without a valid scope every URL stays in the app
+	p := &browserPlatform{}
+	a := &App{platform: p, urlArgs: []string{"https://example.com/"}}
+	if got := a.launchURL(); got != "https://example.com/" || p.opened != nil {
+		t.Errorf("without a scope launched %q and opened %v", got, p.opened)
+	}
+}
+
+func TestParseFlagsWithURLs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantForce bool
+		wantRoute string
+		wantURLs  []string
+	}{
+		{
+			name: "no arguments",
+			args: []string{},
+		},
+		{
+			name:     "url only",
+			args:     []string{"https://github.com/org/repo"},
+			wantURLs: []string{"https://github.com/org/repo"},
+		},
+		{
+			name:      "flag before the url",
+			args:      []string{"--force", "https://github.com/org/repo"},
+			wantForce: true,
+			wantURLs:  []string{"https://github.com/org/repo"},
+		},
+		{
+			name:      "flag with a value before urls",
+			args:      []string{"--route", "https://gitlab.com/", "https://github.com/a", "https://github.com/b"},
+			wantRoute: "https://gitlab.com/",
+			wantURLs:  []string{"https://github.com/a", "https://github.com/b"},
+		},
+
+		// Flags end at the first positional argument
+		{
+			name:     "flag after the url",
+			args:     []string{"https://github.com/org/repo", "--force"},
+			wantURLs: []string{"https://github.com/org/repo", "--force"},
+		},
+		{
+			name:      "terminator",
+			args:      []string{"-force", "--", "-https://github.com"},
+			wantForce: true,
+			wantURLs:  []string{"-https://github.com"},
+		},
+	}
+	for _, tt := range tests {
+		a := &App{}
+		a.parseFlags(tt.args)
+		if a.forceMode != tt.wantForce || a.routeURL != tt.wantRoute {
+			t.Errorf("%s: force %v and route %q, want %v and %q", tt.name, a.forceMode, a.routeURL, tt.wantForce, tt.wantRoute)
+		}
+		if len(a.urlArgs) != len(tt.wantURLs) || (len(tt.wantURLs) > 0 && !reflect.DeepEqual(a.urlArgs, tt.wantURLs)) {
+			t.Errorf("%s: url arguments %q, want %q", tt.name, a.urlArgs, tt.wantURLs)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kemalersin/hobaa/pkg/badge"
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// badgeRule compiles the badge rule of the site, returning nil for sites without one
+func (a *App) badgeRule() *badge.Rule {
+	if a.currentSite == nil || a.currentSite.Badge == nil {
+		return nil
+	}
+	rule, err := badge.Compile(a.currentSite.Badge.Title, a.currentSite.Badge.Selector)
+	if err != nil {
+		fmt.Printf("Failed to set up the badge of %s: %v\n", a.siteName, err)
+		return nil
+	}
+	return rule
+}
+
+// setBadge draws an unread count over the window icon, or restores the icon for 0
+func (a *App) setBadge(count int) {
+	if count == a.badgeCount {
+		return
+	}
+
+	iconPath := a.windowIcon
+	if count > 0 {
+		path, err := a.badgeIcon(count)
+		if err != nil {
+			fmt.Printf("Failed to draw badge: %v\n", err)
+			return
+		}
+		iconPath = path
+	}
+	a.platform.SetWindowIcon(a.hwnd, iconPath)
+	a.badgeCount = count
+}
+
+// badgeIcon returns the path of the window icon with the count drawn over it.
+// Icons are kept for each label and redrawn when the window icon is newer.
+func (a *App) badgeIcon(count int) (string, error) {
+	badgePath := filepath.Join(a.iconsDir, "badges", a.siteName+"-"+badge.Label(count)+".ico")
+
+	// Reuse an icon drawn from the current window icon
+	source, err := os.Stat(a.windowIcon)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(badgePath); err == nil && !info.ModTime().Before(source.ModTime()) {
+		return badgePath, nil
+	}
+
+	// Draw the badge over the window icon
+	data, err := os.ReadFile(a.windowIcon)
+	if err != nil {
+		return "", err
+	}
+	icon, err := badge.OverlayICO(data, count)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(badgePath), 0755); err != nil {
+		return "", err
+	}
+	if err := utils.WriteFileAtomic(badgePath, icon, 0644); err != nil {
+		return "", err
+	}
+	return badgePath, nil
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kemalersin/hobaa/pkg/badge"
+	"github.com/kemalersin/hobaa/pkg/bridge"
+	"github.com/kemalersin/hobaa/pkg/downloads"
+)
+
+// siteInfo is the result of hobaa.site.info()
+type siteInfo struct {
+	Name    string   `json:"name"`
+	Profile string   `json:"profile"`
+	Title   string   `json:"title"`
+	URL     string   `json:"url"`
+	Scope   []string `json:"scope"`
+}
+
+// urlArgs are the arguments of hobaa.openExternal(url)
+type urlArgs struct {
+	URL string `json:"url"`
+}
+
+// Validate checks that the URL is an http or https URL
+func (args *urlArgs) Validate() error {
+	if args.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	_, err := parseURLArg(args.URL)
+	return err
+}
+
+// badgeArgs are the arguments of hobaa.setBadge(count)
+type badgeArgs struct {
+	Count *int `json:"count"`
+}
+
+// Validate checks that the count is given and within range
+func (args *badgeArgs) Validate() error {
+	if args.Count == nil {
+		return fmt.Errorf("count is required")
+	}
+	if *args.Count < 0 || *args.Count > badge.MaxCount {
+		return fmt.Errorf("count must be between 0 and %d", badge.MaxCount)
+	}
+	return nil
+}
+
+// storageArgs are the arguments of the hobaa.storage methods
+type storageArgs struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Validate checks the key
+func (args *storageArgs) Validate() error {
+	return bridge.ValidateKey(args.Key)
+}
+
+// setupBridge adds window.hobaa to every page, giving pages access to the site app
+func (a *App) setupBridge() {
+	b := bridge.New()
+
+	bridge.Handle(b, "site.info", nil, func(struct{}) (siteInfo, error) {
+		return a.siteInfo(), nil
+	})
+	bridge.Handle(b, "openExternal", []string{"url"}, func(args urlArgs) (any, error) {
+		return nil, a.platform.OpenBrowser(args.URL)
+	})
+	bridge.Handle(b, "setBadge", []string{"count"}, func(args badgeArgs) (any, error) {
+		a.webView.Dispatch(func() {
+			a.setBadge(*args.Count)
+		})
+		return nil, nil
+	})
+	// Only pages of the site get window.hobaa. Bound functions run on the UI thread, where the
+	// webview can tell which page is calling.
+	if a.scope != nil {
+		var scope []string
+		for _, p := range a.scope.Patterns() {
+			scope = append(scope, p.Regexp())
+		}
+		b.Restrict(scope, func() bool {
+			return a.scope.MatchString(a.webView.Source())
+		})
+	}
+	a.setupNotifications(b)
+	a.setupPageWatch(b)
+	a.setupDownloads(b)
+
+	// Values are kept with the browser data of the profile, separately for each site sharing it.
+	// Pages don't get storage.* if the file can't be used, so its values aren't replaced.
+	storage, err := bridge.OpenStorage(a.storagePath())
+	if err != nil {
+		fmt.Printf("Failed to open page storage: %v\n", err)
+	}
+	if storage != nil {
+		a.setupStorage(b, storage)
+	}
+
+	// Install window.hobaa and the scripts using it
+	if err := b.Install(a.webView); err != nil {
+		fmt.Printf("Failed to install the page bridge: %v\n", err)
+	}
+}
+
+// setupStorage adds hobaa.storage backed by the storage file of the site
+func (a *App) setupStorage(b *bridge.Bridge, storage *bridge.Storage) {
+	bridge.Handle(b, "storage.get", []string{"key"}, func(args storageArgs) (json.RawMessage, error) {
+		if value, ok := storage.Get(args.Key); ok {
+			return value, nil
+		}
+		return json.RawMessage("null"), nil
+	})
+	bridge.Handle(b, "storage.set", []string{"key", "value"}, func(args storageArgs) (any, error) {
+		if args.Value == nil {
+			return nil, fmt.Errorf("value is required")
+		}
+		return nil, storage.Set(args.Key, args.Value)
+	})
+	bridge.Handle(b, "storage.remove", []string{"key"}, func(args storageArgs) (any, error) {
+		return nil, storage.Remove(args.Key)
+	})
+	bridge.Handle(b, "storage.keys", nil, func(struct{}) ([]string, error) {
+		return storage.Keys(), nil
+	})
+}
+
+// siteInfo describes the current site to pages
+func (a *App) siteInfo() siteInfo {
+	info := siteInfo{Name: a.siteName, Profile: a.profileName, Title: a.title, Scope: []string{}}
+	if a.currentSite != nil {
+		info.URL = a.currentSite.URL
+	}
+	if a.scope != nil {
+		for _, p := range a.scope.Patterns() {
+			info.Scope = append(info.Scope, p.String())
+		}
+	}
+	return info
+}
+
+// storagePath returns the file of hobaa.storage for the current site in its profile directory.
+// Profiles can be shared by several sites, so each site has its own file.
+func (a *App) storagePath() string {
+	return filepath.Join(a.webViewDir, "storage", downloads.SanitizeFileName(a.siteName)+".json")
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/kemalersin/hobaa/pkg/bridge"
+	"github.com/kemalersin/hobaa/pkg/downloads"
+	"github.com/kemalersin/hobaa/pkg/webview"
+)
+
+// downloadArgs are the arguments of the hobaa.downloads methods acting on one download
+type downloadArgs struct {
+	ID int `json:"id"`
+}
+
+// Validate checks that an ID is given
+func (args *downloadArgs) Validate() error {
+	if args.ID <= 0 {
+		return fmt.Errorf("id is required")
+	}
+	return nil
+}
+
+// setupDownloads saves downloads to the download directory of the site and adds the download panel
+func (a *App) setupDownloads(b *bridge.Bridge) {
+	configured := ""
+	if a.currentSite != nil {
+		configured = a.currentSite.DownloadDir
+	}
+	dir := downloads.ResolveDir(configured, downloadsFolder(), a.siteName)
+
+	// The log is kept with the browser data of the profile, separately for each site sharing it
+	log, err := downloads.OpenLog(a.downloadsLogPath())
+	if err != nil {
+		fmt.Printf("Failed to open downloads log: %v\n", err)
+	}
+	manager := downloads.NewManager(dir, log, func(entry downloads.Entry) {
+		a.webView.Eval(downloads.EventScript(entry))
+	})
+	if err := a.webView.SetDownloadHandler(manager); err != nil {
+		fmt.Printf("Failed to handle downloads: %v\n", err)
+		return
+	}
+
+	bridge.Handle(b, "downloads.list", nil, func(struct{}) ([]downloads.Entry, error) {
+		return log.Entries(), nil
+	})
+	// Pages can call these without the user, so the user confirms in a native dialog before anything is opened
+	var asking atomic.Bool
+	bridge.Handle(b, "downloads.open", []string{"id"}, func(args downloadArgs) (any, error) {
+		entry, err := completedDownload(log, args.ID)
+		if err != nil {
+			return nil, err
+		}
+		a.confirmDownloadAction(&asking, entry, true)
+		return nil, nil
+	})
+	bridge.Handle(b, "downloads.showInFolder", []string{"id"}, func(args downloadArgs) (any, error) {
+		entry, err := completedDownload(log, args.ID)
+		if err != nil {
+			return nil, err
+		}
+		a.confirmDownloadAction(&asking, entry, false)
+		return nil, nil
+	})
+	bridge.Handle(b, "downloads.cancel", []string{"id"}, func(args downloadArgs) (any, error) {
+		id, ok := manager.DownloadID(args.ID)
+		if !ok {
+			return nil, fmt.Errorf("download %d is not in progress", args.ID)
+		}
+		return nil, a.webView.CancelDownload(id)
+	})
+	bridge.Handle(b, "downloads.clear", nil, func(struct{}) (any, error) {
+		return nil, log.Clear()
+	})
+
+	b.AddScript(downloads.PanelScript())
+}
+
+// downloadsLogPath returns the downloads log of the current site in its profile directory.
+// Profiles can be shared by several sites, so each site has its own log, as with page storage.
+func (a *App) downloadsLogPath() string {
+	return filepath.Join(a.webViewDir, "downloads", downloads.SanitizeFileName(a.siteName)+".json")
+}
+
+// confirmDownloadAction asks the user before a download is opened or shown in its folder.
+// The dialog is owned by the window and shown on the UI thread after the bridge call returns; calls made
+// while it is open are dropped. Programs and scripts are never opened, only shown in their folder.
+func (a *App) confirmDownloadAction(asking *atomic.Bool, entry downloads.Entry, open bool) {
+	if !asking.CompareAndSwap(false, true) {
+		return
+	}
+	a.webView.Dispatch(func() {
+		defer asking.Store(false)
+
+		name := filepath.Base(entry.Path)
+		var question string
+		switch {
+		case !open:
+			question = fmt.Sprintf("Show %s in its folder?\n\n%s", name, entry.Path)
+		case downloads.IsExecutable(name):
+			question = fmt.Sprintf("%s is a program or script and won't be opened from here.\n\nShow it in its folder?\n\n%s", name, entry.Path)
+			open = false
+		default:
+			question = fmt.Sprintf("Open %s?\n\n%s\n\nDownloaded from %s", name, entry.Path, entry.URL)
+		}
+		if !a.platform.Confirm(a.hwnd, a.title, question) {
+			return
+		}
+
+		var err error
+		if open {
+			err = a.platform.OpenFile(entry.Path)
+		} else {
+			err = a.platform.ShowInFolder(entry.Path)
+		}
+		if err != nil {
+			fmt.Printf("Failed to open %s: %v\n", entry.Path, err)
+		}
+	})
+}
+
+// completedDownload returns a finished download whose file still exists
+func completedDownload(log *downloads.Log, id int) (downloads.Entry, error) {
+	entry, ok := log.Get(id)
+	if !ok {
+		return entry, fmt.Errorf("download %d not found", id)
+	}
+	if entry.State != webview.DownloadCompleted {
+		return entry, fmt.Errorf("download %d is not completed", id)
+	}
+	if _, err := os.Stat(entry.Path); err != nil {
+		return entry, fmt.Errorf("%s no longer exists", filepath.Base(entry.Path))
+	}
+	return entry, nil
+}
+
+// downloadsFolder returns the Downloads folder of the user
+func downloadsFolder() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	return filepath.Join(home, "Downloads")
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kemalersin/hobaa/pkg/instance"
+)
+
+// acquireInstance makes this process the only running instance of the site and profile.
+// If another instance is running, the arguments are forwarded to it and false is returned.
+func (a *App) acquireInstance() bool {
+	inst, err := instance.Acquire(a.execName)
+	if errors.Is(err, instance.ErrAlreadyRunning) {
+		workDir, _ := os.Getwd()
+		message := instance.NewActivateMessage(a.urlArgs, workDir)
+		if err := instance.Send(a.execName, message); err != nil {
+			fmt.Printf("Failed to reach the running instance: %v\n", err)
+		}
+		return false
+	}
+	if err != nil {
+		// Run anyway rather than not starting at all
+		fmt.Printf("Failed to check for a running instance: %v\n", err)
+		return true
+	}
+
+	a.instance = inst
+	return true
+}
+
+// serveInstance handles messages from later launches until the instance is closed
+func (a *App) serveInstance() {
+	if a.instance != nil {
+		go a.instance.Serve(a.handleInstanceMessage)
+	}
+}
+
+// handleInstanceMessage handles a message from a later launch of the same site and profile
+func (a *App) handleInstanceMessage(m instance.Message) error {
+	switch m.Command {
+	case instance.CommandActivate:
+		// Open forwarded URLs, then bring the window to the front
+		for _, arg := range m.Args {
+			if err := a.openArg(arg); err != nil {
+				fmt.Printf("Ignoring argument %q: %v\n", arg, err)
+			}
+		}
+		a.webView.Dispatch(func() {
+			a.platform.FocusWindow(a.hwnd)
+		})
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", m.Command)
+	}
+}
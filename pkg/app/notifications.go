@@ -0,0 +1,147 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/kemalersin/hobaa/pkg/bridge"
+	"github.com/kemalersin/hobaa/pkg/notify"
+)
+
+// Limits of notification arguments
+const (
+	maxNotificationID    = 64
+	maxNotificationTitle = 256
+	maxNotificationBody  = 4096
+)
+
+// notifyArgs are the arguments of hobaa.notify(title, body)
+type notifyArgs struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Validate checks that the title is given and the texts are not too long
+func (args *notifyArgs) Validate() error {
+	return validateNotificationText(args.Title, args.Body)
+}
+
+// notificationArgs are the arguments of hobaa.notifications.show(id, title, options) used by the Notification polyfill
+type notificationArgs struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Options struct {
+		Body string `json:"body"`
+		Tag  string `json:"tag"`
+	} `json:"options"`
+}
+
+// Validate checks the ID and texts of the notification
+func (args *notificationArgs) Validate() error {
+	if err := validateNotificationID(args.ID); err != nil {
+		return err
+	}
+	return validateNotificationText(args.Title, args.Options.Body)
+}
+
+// notificationIDArgs are the arguments of hobaa.notifications.close(id)
+type notificationIDArgs struct {
+	ID string `json:"id"`
+}
+
+// Validate checks the ID
+func (args *notificationIDArgs) Validate() error {
+	return validateNotificationID(args.ID)
+}
+
+// setupNotifications shows notifications of pages as desktop notifications.
+// It registers the window.hobaa methods used by the Notification polyfill and adds the polyfill.
+func (a *App) setupNotifications(b *bridge.Bridge) {
+	notifier, err := a.platform.NewNotifier(a.hwnd, a.windowIcon, a.title)
+	if err != nil {
+		fmt.Printf("Failed to set up notifications: %v\n", err)
+		return
+	}
+	manager := notify.NewManager(notifier, a.siteStore, a.siteName, a.title, a.webView.Dispatch, a.onNotificationClick)
+
+	bridge.Handle(b, "notifications.permission", nil, func(struct{}) (notify.Permission, error) {
+		return manager.Permission(), nil
+	})
+	bridge.Handle(b, "notifications.requestPermission", nil, func(struct{}) (notify.Permission, error) {
+		return manager.RequestPermission(func(p notify.Permission, err error) {
+			if err != nil {
+				fmt.Printf("Failed to request notification permission: %v\n", err)
+			}
+			a.webView.Eval(notify.PermissionScript(p))
+		}), nil
+	})
+	bridge.Handle(b, "notifications.show", []string{"id", "title", "options"}, func(args notificationArgs) (any, error) {
+		return nil, manager.Show(notify.Notification{
+			ID:    args.ID,
+			Title: args.Title,
+			Body:  args.Options.Body,
+			Tag:   args.Options.Tag,
+		})
+	})
+	bridge.Handle(b, "notifications.close", []string{"id"}, func(args notificationIDArgs) (any, error) {
+		return nil, manager.Close(args.ID)
+	})
+
+	// Show a desktop notification if allowed, otherwise flash the taskbar button
+	var nextID atomic.Int64
+	bridge.Handle(b, "notify", []string{"title", "body"}, func(args notifyArgs) (bool, error) {
+		err := manager.Show(notify.Notification{
+			ID:    "hobaa-" + strconv.FormatInt(nextID.Add(1), 10),
+			Title: args.Title,
+			Body:  args.Body,
+		})
+		if err != nil {
+			if !errors.Is(err, notify.ErrNotAllowed) {
+				fmt.Printf("Failed to show notification: %v\n", err)
+			}
+			a.webView.Dispatch(func() {
+				a.platform.FlashWindow(a.hwnd)
+			})
+			return false, nil
+		}
+		return true, nil
+	})
+
+	b.AddScript(notify.PolyfillScript(manager.Permission()))
+}
+
+// onNotificationClick brings the window to the front and lets the page handle the click
+func (a *App) onNotificationClick(id string) {
+	a.webView.Dispatch(func() {
+		a.platform.FocusWindow(a.hwnd)
+		a.webView.Eval(notify.EventScript(id, "click"))
+	})
+}
+
+// validateNotificationID checks that a notification ID is given and not too long
+func validateNotificationID(id string) error {
+	if id == "" {
+		return fmt.Errorf("id is required")
+	}
+	if len(id) > maxNotificationID {
+		return fmt.Errorf("id is longer than %d bytes", maxNotificationID)
+	}
+	return nil
+}
+
+// validateNotificationText checks that the title is given and the texts are not too long
+func validateNotificationText(title, body string) error {
+	if title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if utf8.RuneCountInString(title) > maxNotificationTitle {
+		return fmt.Errorf("title is longer than %d characters", maxNotificationTitle)
+	}
+	if utf8.RuneCountInString(body) > maxNotificationBody {
+		return fmt.Errorf("body is longer than %d characters", maxNotificationBody)
+	}
+	return nil
+}
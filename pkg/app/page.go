@@ -0,0 +1,113 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/kemalersin/hobaa/pkg/bridge"
+)
+
+// Limits of the page state reported to Go
+const (
+	maxPageTitle = 1024
+	maxBadgeText = 256
+)
+
+// pageArgs are the arguments of hobaa.page.update(title, text) sent by the page watcher
+type pageArgs struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// Validate checks that the texts are not too long
+func (args *pageArgs) Validate() error {
+	if utf8.RuneCountInString(args.Title) > maxPageTitle {
+		return fmt.Errorf("title is longer than %d characters", maxPageTitle)
+	}
+	if utf8.RuneCountInString(args.Text) > maxBadgeText {
+		return fmt.Errorf("text is longer than %d characters", maxBadgeText)
+	}
+	return nil
+}
+
+// pageScript reports the title and the text of the badge element to Go whenever they change.
+// Changes are collected for a short while since pages often update the DOM in bursts.
+const pageScript = `
+(function() {
+	const api = window.hobaa && window.hobaa.page;
+	if (!api) {
+		return;
+	}
+	const selector = %s;
+	let last = null;
+	let timer = 0;
+
+	function report() {
+		timer = 0;
+		let text = '';
+		if (selector) {
+			try {
+				const element = document.querySelector(selector);
+				text = element ? element.textContent.trim().slice(0, %d) : '';
+			} catch (e) {
+				// Invalid selectors report no count
+			}
+		}
+		const title = document.title.slice(0, %d);
+		const state = title + '\n' + text;
+		if (state !== last) {
+			last = state;
+			api.update(title, text).catch(() => {});
+		}
+	}
+
+	function schedule() {
+		if (!timer) {
+			timer = setTimeout(report, 250);
+		}
+	}
+
+	// The title lives in the head, other elements anywhere in the document
+	function start() {
+		const target = selector ? document.documentElement : (document.head || document.documentElement);
+		new MutationObserver(schedule).observe(target, {subtree: true, childList: true, characterData: true});
+		schedule();
+	}
+	if (document.readyState === 'loading') {
+		document.addEventListener('DOMContentLoaded', start);
+	} else {
+		start();
+	}
+})();
+`
+
+// setupPageWatch reports changes of the page to Go when the site has a badge rule or a title template
+func (a *App) setupPageWatch(b *bridge.Bridge) {
+	rule := a.badgeRule()
+	a.titleUpdates = a.newTitleUpdates()
+	if rule == nil && a.titleUpdates == nil {
+		return
+	}
+
+	bridge.Handle(b, "page.update", []string{"title", "text"}, func(args pageArgs) (any, error) {
+		if rule != nil {
+			count := rule.Count(args.Title, args.Text)
+			a.webView.Dispatch(func() {
+				a.setBadge(count)
+			})
+		}
+		if a.titleUpdates != nil {
+			a.titleUpdates.Update(args.Title)
+		}
+		return nil, nil
+	})
+
+	// Only badge rules read an element, title templates just need the title
+	selector := ""
+	if rule != nil {
+		selector = rule.Selector()
+	}
+	selectorJSON, _ := json.Marshal(selector)
+	b.AddScript(fmt.Sprintf(pageScript, selectorJSON, maxBadgeText, maxPageTitle))
+}
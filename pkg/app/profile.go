@@ -0,0 +1,56 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/kemalersin/hobaa/pkg/config"
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// profileCacheDirs are WebView cache directories that aren't copied when migrating a profile
+var profileCacheDirs = map[string]bool{
+	"Cache":         true,
+	"Code Cache":    true,
+	"GPUCache":      true,
+	"ShaderCache":   true,
+	"GrShaderCache": true,
+}
+
+// prepareProfile returns the WebView data directory of the current site and profile, creating it if needed.
+// Sites that existed before profiles were separated get a copy of the legacy shared profile.
+func (a *App) prepareProfile() string {
+	// Get profile of the current site
+	site := a.currentSite
+	if site == nil {
+		site = &config.Site{Name: a.siteName}
+	}
+	profile := site.NamedProfileName(a.profileName)
+	profileDir := config.GetProfileDir(a.appDataDir, profile)
+
+	// Copy the legacy shared profile once for sites that were already in use
+	if a.profileName == "" && a.siteStore.Settings().HasPendingProfileMigration(site.Name) {
+		sharedDataPath := config.GetSharedProfileDataPath(a.appDataDir)
+		dataPath := filepath.Join(profileDir, config.WebViewDataDirName)
+		if profile != config.SharedProfile && dirExists(sharedDataPath) && !dirExists(dataPath) {
+			utils.CopyDir(sharedDataPath, dataPath, func(name string) bool {
+				return profileCacheDirs[name]
+			})
+		}
+
+		a.siteStore.UpdateNow(func(c *config.SiteConfig) {
+			c.CompleteProfileMigration(site.Name)
+		})
+	}
+
+	// Create profile directory
+	os.MkdirAll(profileDir, 0755)
+
+	return profileDir
+}
+
+// dirExists reports whether a directory exists
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
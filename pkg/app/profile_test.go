@@ -0,0 +1,158 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/config"
+)
+
+// newProfileApp returns an app for a site of a sites.json with the given sites and pending profile migrations
+func newProfileApp(t *testing.T, sites []config.Site, pending []string, execName string) *App {
+	t.Helper()
+	dir := t.TempDir()
+	c := config.NewSiteConfig("")
+	c.Sites = sites
+	c.Settings.PendingProfileMigrations = pending
+	if err := c.SaveToFile(config.GetAppDataSitesPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &App{appDataDir: dir, siteStore: config.NewStore(config.GetAppDataSitesPath(dir), config.DefaultSaveDelay)}
+	if err := a.siteStore.Load(); err != nil {
+		t.Fatal(err)
+	}
+	a.siteName, a.profileName = config.ParseExecName(execName)
+	a.currentSite, _ = a.siteStore.Site(a.siteName)
+	return a
+}
+
+// writeTestFile creates a file and its directories
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrepareProfileDirs(t *testing.T) {
+	sites := []config.Site{
+		{Name: "gmail", URL: "https://mail.google.com"},
+		{Name: "calendar", URL: "https://calendar.google.com", Profile: "google"},
+		{Name: "legacy", URL: "https://legacy.example.com", Profile: config.SharedProfile},
+	}
+	tests := []struct {
+		execName string
+		want     string // Relative to the AppData directory
+	}{
+		{"gmail", filepath.Join("profiles", "gmail")},
+		{"gmail@work", filepath.Join("profiles", "gmail@work")},
+		{"calendar", filepath.Join("profiles", "google")},
+		{"calendar@work", filepath.Join("profiles", "google@work")},
+		{"legacy", "."},
+		{"unknown", filepath.Join("profiles", "unknown")},
+	}
+	for _, tt := range tests {
+		a := newProfileApp(t, sites, nil, tt.execName)
+		got := a.prepareProfile()
+		if want := filepath.Join(a.appDataDir, tt.want); got != want {
+			t.Errorf("%s: profile %s, want %s", tt.execName, got, want)
+		}
+		if !dirExists(got) {
+			t.Errorf("%s: profile directory wasn't created", tt.execName)
+		}
+	}
+}
+
+func TestPrepareProfileCopiesSharedProfileOnce(t *testing.T) {
+	sites := []config.Site{{Name: "gmail", URL: "https://mail.google.com"}, {Name: "slack", URL: "https://app.slack.com"}}
+	a := newProfileApp(t, sites, []string{"gmail", "slack"}, "gmail")
+	shared := config.GetSharedProfileDataPath(a.appDataDir)
+	writeTestFile(t, filepath.Join(shared, "Default", "Cookies"), "session")
+	writeTestFile(t, filepath.Join(shared, "Default", "Cache", "data_0"), "cache")
+	writeTestFile(t, filepath.Join(shared, "GPUCache", "index"), "cache")
+
+	profileDir := a.prepareProfile()
+	data := filepath.Join(profileDir, config.WebViewDataDirName)
+
+	// Sign-ins are copied, caches aren't
+	if content, err := os.ReadFile(filepath.Join(data, "Default", "Cookies")); err != nil || string(content) != "session" {
+		t.Errorf("cookies weren't copied: %q, %v", content, err)
+	}
+	for _, cache := range []string{filepath.Join("Default", "Cache"), "GPUCache"} {
+		if dirExists(filepath.Join(data, cache)) {
+			t.Errorf("cache %s was copied", cache)
+		}
+	}
+
+	// The site is no longer pending, in memory and in sites.json; other sites still are
+	check := func(settings config.Settings) {
+		t.Helper()
+		if settings.HasPendingProfileMigration("gmail") || !settings.HasPendingProfileMigration("slack") {
+			t.Errorf("pending profile migrations %v, want only slack", settings.PendingProfileMigrations)
+		}
+	}
+	check(a.siteStore.Settings())
+	reloaded := config.NewSiteConfig("")
+	if err := reloaded.LoadFromFile(config.GetAppDataSitesPath(a.appDataDir)); err != nil {
+		t.Fatal(err)
+	}
+	check(reloaded.Settings)
+
+	// The next launch doesn't copy again
+	writeTestFile(t, filepath.Join(shared, "Default", "Cookies"), "changed")
+	if err := os.RemoveAll(data); err != nil {
+		t.Fatal(err)
+	}
+	a.prepareProfile()
+	if dirExists(data) {
+		t.Error("shared profile was copied again")
+	}
+}
+
+func TestPrepareProfileWithoutCopy(t *testing.T) {
+	sites := []config.Site{
+		{Name: "gmail", URL: "https://mail.google.com"},
+		{Name: "legacy", URL: "https://legacy.example.com", Profile: config.SharedProfile},
+	}
+	tests := []struct {
+		name        string
+		execName    string
+		shared      bool // The legacy shared profile exists
+		existing    bool // The site already has WebView data
+		wantPending bool
+	}{
+		{"no shared profile", "gmail", false, false, false},
+		{"site already has data", "gmail", true, true, false},
+		{"site opted into sharing", "legacy", true, false, false},
+		{"named profile", "gmail@work", true, false, true},
+	}
+	for _, tt := range tests {
+		a := newProfileApp(t, sites, []string{"gmail", "legacy"}, tt.execName)
+		if tt.shared {
+			writeTestFile(t, filepath.Join(config.GetSharedProfileDataPath(a.appDataDir), "Default", "Cookies"), "session")
+		}
+		profileDir := config.GetProfileDir(a.appDataDir, a.currentSite.NamedProfileName(a.profileName))
+		cookies := filepath.Join(profileDir, config.WebViewDataDirName, "Default", "Cookies")
+		if tt.existing {
+			writeTestFile(t, cookies, "own")
+		}
+
+		a.prepareProfile()
+
+		content, _ := os.ReadFile(cookies)
+		switch {
+		case tt.existing && string(content) != "own":
+			t.Errorf("%s: data of the site was replaced with %q", tt.name, content)
+		case !tt.existing && tt.execName != "legacy" && content != nil:
+			t.Errorf("%s: shared profile was copied", tt.name)
+		}
+		if got := a.siteStore.Settings().HasPendingProfileMigration(a.siteName); got != tt.wantPending {
+			t.Errorf("%s: pending = %v, want %v", tt.name, got, tt.wantPending)
+		}
+	}
+}
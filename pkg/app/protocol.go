@@ -0,0 +1,230 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kemalersin/hobaa/pkg/config"
+	"github.com/kemalersin/hobaa/pkg/downloads"
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// ProtocolScheme is the URL scheme of hobaa:// links
+const ProtocolScheme = "hobaa"
+
+// Actions of hobaa:// links
+const (
+	protocolOpen     = "open"     // hobaa://open/<site>[?path=/page] opens a site app, e.g. hobaa://open/github?path=/notifications
+	protocolAdd      = "add"      // hobaa://add?url=<url>[&name=<site>] adds a site and opens it
+	protocolNavigate = "navigate" // hobaa://navigate?url=<url> opens a URL in the site app that owns it, like --route
+)
+
+// protocolAction is the action requested by a hobaa:// link
+type protocolAction struct {
+	Kind string
+	Site string // Site name, optionally with a profile, e.g. gmail@work
+	Path string // Path of the page to open in the site, with query and fragment
+	URL  string // URL to add as a site or to navigate to
+}
+
+// protocolHandler carries out the actions of hobaa:// links
+type protocolHandler interface {
+	openSite(name, path string) error
+	addSite(name, siteURL string) error
+	route(rawURL string) error
+}
+
+// parseProtocolLink parses a hobaa:// link into an action
+func parseProtocolLink(link string) (protocolAction, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return protocolAction{}, err
+	}
+	if !strings.EqualFold(u.Scheme, ProtocolScheme) || u.Opaque != "" {
+		return protocolAction{}, fmt.Errorf("not a %s:// link", ProtocolScheme)
+	}
+
+	// Browsers may add a trailing slash to the link
+	rest := strings.Trim(u.Path, "/")
+	action := protocolAction{Kind: strings.ToLower(u.Host)}
+	query := u.Query()
+
+	switch action.Kind {
+	case protocolOpen:
+		if !validSiteName(rest) {
+			return protocolAction{}, fmt.Errorf("invalid site name %q", rest)
+		}
+		action.Site = rest
+
+		// Only paths within the site are allowed, not other hosts such as //example.com
+		action.Path = query.Get("path")
+		if action.Path != "" && (!strings.HasPrefix(action.Path, "/") || strings.HasPrefix(action.Path, "//")) {
+			return protocolAction{}, fmt.Errorf("invalid path %q: must be an absolute path within the site", action.Path)
+		}
+	case protocolAdd, protocolNavigate:
+		if rest != "" {
+			return protocolAction{}, fmt.Errorf("unexpected path %q in %s link", rest, action.Kind)
+		}
+		target, err := parseURLArg(query.Get("url"))
+		if err != nil {
+			return protocolAction{}, fmt.Errorf("invalid url %q: %v", query.Get("url"), err)
+		}
+		action.URL = target.String()
+
+		if action.Kind == protocolAdd {
+			// Name sites after their host by default, as sites named after a URL are
+			action.Site = query.Get("name")
+			if action.Site == "" {
+				action.Site = strings.TrimPrefix(strings.ToLower(target.Hostname()), "www.")
+			}
+			if !validSiteName(action.Site) || strings.Contains(action.Site, config.ProfileSeparator) {
+				return protocolAction{}, fmt.Errorf("invalid site name %q", action.Site)
+			}
+		}
+	case "":
+		return protocolAction{}, fmt.Errorf("missing action in %s:// link", ProtocolScheme)
+	default:
+		return protocolAction{}, fmt.Errorf("unknown action %q", action.Kind)
+	}
+	return action, nil
+}
+
+// dispatch carries out the action with the handler
+func (action protocolAction) dispatch(h protocolHandler) error {
+	switch action.Kind {
+	case protocolOpen:
+		return h.openSite(action.Site, action.Path)
+	case protocolAdd:
+		return h.addSite(action.Site, action.URL)
+	case protocolNavigate:
+		return h.route(action.URL)
+	default:
+		return fmt.Errorf("unknown action %q", action.Kind)
+	}
+}
+
+// maxSiteNameLength keeps the paths of site EXEs well below the 260 characters Windows allows
+const maxSiteNameLength = 64
+
+// validSiteName reports whether a site name can be used as an EXE file name as it is.
+// Names Windows would change or refuse, such as device names, names with path separators or reserved
+// characters and names ending in a dot or space, are invalid.
+func validSiteName(name string) bool {
+	return name != "" && utf8.RuneCountInString(name) <= maxSiteNameLength && downloads.SanitizeFileName(name) == name
+}
+
+// siteURLWithPath returns the URL of a page of a site, keeping the scheme and host of the site URL
+func siteURLWithPath(siteURL, path string) (string, error) {
+	base, err := url.Parse(siteURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	if ref.Scheme != "" || ref.Host != "" {
+		return "", fmt.Errorf("path %q is not within the site", path)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// protocolCommand returns the command registered to open hobaa:// links with the EXE
+func protocolCommand(execPath string) string {
+	return `"` + execPath + `" --link "%1"`
+}
+
+// handleLinkAndExit carries out the hobaa:// link given with --link and exits
+func (a *App) handleLinkAndExit() {
+	action, err := parseProtocolLink(a.link)
+	if err == nil {
+		err = action.dispatch(a)
+	}
+	if err != nil {
+		fmt.Printf("Failed to open %s: %v\n", a.link, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// registerProtocolAndExit registers or unregisters this EXE as the handler of hobaa:// links and exits
+func (a *App) registerProtocolAndExit() {
+	var err error
+	if a.registerProtocol {
+		err = a.platform.RegisterProtocol(ProtocolScheme, protocolCommand(a.execPath), a.execPath+",0")
+	} else {
+		err = a.platform.UnregisterProtocol(ProtocolScheme)
+	}
+	if err != nil {
+		fmt.Printf("Failed to update the %s:// protocol: %v\n", ProtocolScheme, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// openSite opens a site app by name, at a page of the site if a path is given
+func (a *App) openSite(name, path string) error {
+	siteName, profile := config.ParseExecName(name)
+	site, ok := a.loadStore().Site(siteName)
+	if !ok {
+		return fmt.Errorf("unknown site %q", siteName)
+	}
+
+	var args []string
+	if path != "" {
+		target, err := siteURLWithPath(site.URL, path)
+		if err != nil {
+			return err
+		}
+		args = append(args, target)
+	}
+	return a.launchSite(site, profile, args)
+}
+
+// addSite adds a site to sites.json, creates its EXE next to this one and opens it.
+// A site that already exists with another URL is not changed, so links cannot redirect an existing app.
+func (a *App) addSite(name, siteURL string) error {
+	// Links can come from any web page, so nothing is saved or created without the user's consent
+	exePath := filepath.Join(a.execDir, name+filepath.Ext(a.execPath))
+	question := fmt.Sprintf("Add %s as a site app?\n\nName: %s\nURL: %s\n\n%s will be created and opened.",
+		name, name, siteURL, filepath.Base(exePath))
+	if !a.platform.Confirm(0, "Hobaa", question) {
+		return fmt.Errorf("adding %s was cancelled", name)
+	}
+
+	site := config.CreateSiteFromURL(name, siteURL)
+	site.IsActive = false // Activated with its icon on the first launch
+	if faviconURL, err := utils.GetFaviconURL(siteURL); err == nil && faviconURL != "" {
+		site.Icon = faviconURL
+	}
+
+	var conflict error
+	err := a.loadStore().UpdateNow(func(c *config.SiteConfig) {
+		if existing := c.GetSiteByName(name); existing != nil {
+			if existing.URL != siteURL {
+				conflict = fmt.Errorf("site %q already exists with URL %s", name, existing.URL)
+			}
+			site = existing.Clone()
+			return
+		}
+		c.AddSite(site)
+	})
+	if conflict != nil {
+		return conflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save site: %v", err)
+	}
+
+	// Create the site EXE as a copy of this one
+	if _, err := os.Stat(exePath); os.IsNotExist(err) {
+		if err := copyFile(a.execPath, exePath); err != nil {
+			return fmt.Errorf("failed to create %s: %v", exePath, err)
+		}
+	}
+	return a.launchSite(&site, "", nil)
+}
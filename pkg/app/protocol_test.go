@@ -0,0 +1,255 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/config"
+	"github.com/kemalersin/hobaa/pkg/platform"
+)
+
+func TestParseProtocolLink(t *testing.T) {
+	tests := []struct {
+		link    string
+		want    protocolAction
+		wantErr string
+	}{
+		// open
+		{link: "hobaa://open/github", want: protocolAction{Kind: "open", Site: "github"}},
+		{link: "hobaa://open/github/", want: protocolAction{Kind: "open", Site: "github"}},
+		{link: "HOBAA://OPEN/github", want: protocolAction{Kind: "open", Site: "github"}},
+		{link: "  hobaa://open/gmail@work  ", want: protocolAction{Kind: "open", Site: "gmail@work"}},
+		{
+			link: "hobaa://open/github?path=/notifications%3Fquery%3Dis%3Aunread%23top",
+			want: protocolAction{Kind: "open", Site: "github", Path: "/notifications?query=is:unread#top"},
+		},
+		{link: "hobaa://open/", wantErr: "invalid site name"},
+		{link: "hobaa://open/a/b", wantErr: "invalid site name"},
+		{link: "hobaa://open/..", wantErr: "invalid site name"},
+		{link: "hobaa://open/con", wantErr: "invalid site name"},
+		{link: "hobaa://open/github?path=notifications", wantErr: "invalid path"},
+		{link: "hobaa://open/github?path=//evil.example/", wantErr: "invalid path"},
+		{link: "hobaa://open/github?path=https://evil.example/", wantErr: "invalid path"},
+
+		// add
+		{
+			link: "hobaa://add?url=https%3A%2F%2Fwww.Example.com%2Fapp",
+			want: protocolAction{Kind: "add", Site: "example.com", URL: "https://www.Example.com/app"},
+		},
+		{
+			link: "hobaa://add/?url=https://notion.so&name=notes",
+			want: protocolAction{Kind: "add", Site: "notes", URL: "https://notion.so"},
+		},
+		{link: "hobaa://add?url=example.com&name=example", wantErr: "invalid url"},
+		{link: "hobaa://add", wantErr: "invalid url"},
+		{link: "hobaa://add?name=notes", wantErr: "invalid url"},
+		{link: "hobaa://add?url=javascript:alert(1)", wantErr: "invalid url"},
+		{link: "hobaa://add?url=file:///C:/Windows/System32/calc.exe", wantErr: "invalid url"},
+		{link: "hobaa://add?url=ftp://example.com/", wantErr: "invalid url"},
+		{link: "hobaa://add/extra?url=https://example.com", wantErr: "unexpected path"},
+		{link: "hobaa://add?url=https://example.com&name=..%5C..%5Cevil", wantErr: "invalid site name"},
+		{link: "hobaa://add?url=https://example.com&name=work%2Fnotes", wantErr: "invalid site name"},
+		{link: "hobaa://add?url=https://example.com&name=gmail@work", wantErr: "invalid site name"},
+		{link: "hobaa://add?url=https://example.com&name=NUL.txt", wantErr: "invalid site name"},
+		{link: "hobaa://add?url=https://example.com&name=notes.", wantErr: "invalid site name"},
+		{link: "hobaa://add?url=https://example.com&name=%20notes", wantErr: "invalid site name"},
+		{link: "hobaa://add?url=https://example.com&name=a%00b", wantErr: "invalid site name"},
+		{link: "hobaa://add?url=https://example.com&name=" + strings.Repeat("a", maxSiteNameLength+1), wantErr: "invalid site name"},
+
+		// navigate
+		{
+			link: "hobaa://navigate?url=https%3A%2F%2Fgithub.com%2Fpulls",
+			want: protocolAction{Kind: "navigate", URL: "https://github.com/pulls"},
+		},
+		{link: "hobaa://navigate?url=", wantErr: "invalid url"},
+		{link: "hobaa://navigate?url=data:text/html,hi", wantErr: "invalid url"},
+		{link: "hobaa://navigate/github?url=https://github.com", wantErr: "unexpected path"},
+
+		// Malformed links
+		{link: "https://open/github", wantErr: "not a hobaa:// link"},
+		{link: "hobaa:open/github", wantErr: "not a hobaa:// link"},
+		{link: "hobaa:///github", wantErr: "missing action"},
+		{link: "hobaa://delete/github", wantErr: "unknown action"},
+		{link: "hobaa://open%zz/github", wantErr: "invalid"},
+		{link: "", wantErr: "not a hobaa:// link"},
+	}
+	for _, tt := range tests {
+		got, err := parseProtocolLink(tt.link)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseProtocolLink(%q) = %+v, %v, want error %q", tt.link, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseProtocolLink(%q): %v", tt.link, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseProtocolLink(%q) = %+v, want %+v", tt.link, got, tt.want)
+		}
+	}
+}
+
+// fakeProtocolHandler records the calls of dispatch
+type fakeProtocolHandler struct {
+	calls []string
+	err   error
+}
+
+func (h *fakeProtocolHandler) openSite(name, path string) error {
+	h.calls = append(h.calls, "open "+name+" "+path)
+	return h.err
+}
+
+func (h *fakeProtocolHandler) addSite(name, siteURL string) error {
+	h.calls = append(h.calls, "add "+name+" "+siteURL)
+	return h.err
+}
+
+func (h *fakeProtocolHandler) route(rawURL string) error {
+	h.calls = append(h.calls, "route "+rawURL)
+	return h.err
+}
+
+func TestDispatch(t *testing.T) {
+	tests := []struct {
+		link string
+		want []string
+	}{
+		{"hobaa://open/gmail@work?path=/mail/u/0/", []string{"open gmail@work /mail/u/0/"}},
+		{"hobaa://add?url=https://notion.so&name=notes", []string{"add notes https://notion.so"}},
+		{"hobaa://navigate?url=https://github.com/pulls", []string{"route https://github.com/pulls"}},
+	}
+	for _, tt := range tests {
+		action, err := parseProtocolLink(tt.link)
+		if err != nil {
+			t.Fatalf("parseProtocolLink(%q): %v", tt.link, err)
+		}
+		h := &fakeProtocolHandler{}
+		if err := action.dispatch(h); err != nil {
+			t.Errorf("dispatch(%q): %v", tt.link, err)
+		}
+		if !reflect.DeepEqual(h.calls, tt.want) {
+			t.Errorf("dispatch(%q) called %v, want %v", tt.link, h.calls, tt.want)
+		}
+	}
+
+	// Handler errors are returned
+	failed := errors.New("unknown site")
+	action, _ := parseProtocolLink("hobaa://open/missing")
+	if err := action.dispatch(&fakeProtocolHandler{err: failed}); err != failed {
+		t.Errorf("dispatch error = %v, want %v", err, failed)
+	}
+
+	// Actions not created by parseProtocolLink are refused
+	h := &fakeProtocolHandler{}
+	if err := (protocolAction{Kind: "delete", Site: "github"}).dispatch(h); err == nil || len(h.calls) != 0 {
+		t.Errorf("unknown action dispatched: %v, %v", err, h.calls)
+	}
+}
+
+func TestValidSiteName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"github", true},
+		{"example.com", true},
+		{"gmail@work", true},
+		{"My Notes", true},
+		{"ağ", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{" github", false},
+		{"github ", false},
+		{"github.", false},
+		{`a\b`, false},
+		{"a/b", false},
+		{"a:b", false},
+		{"a*b", false},
+		{"a?b", false},
+		{"a|b", false},
+		{"a\tb", false},
+		{"CON", false},
+		{"com1", false},
+		{"lpt9.site", false},
+		{"console", true},
+		{strings.Repeat("a", maxSiteNameLength), true},
+		{strings.Repeat("a", maxSiteNameLength+1), false},
+	}
+	for _, tt := range tests {
+		if got := validSiteName(tt.name); got != tt.want {
+			t.Errorf("validSiteName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSiteURLWithPath(t *testing.T) {
+	tests := []struct {
+		siteURL, path string
+		want          string
+		wantErr       bool
+	}{
+		{"https://github.com", "/notifications", "https://github.com/notifications", false},
+		{"https://mail.google.com/mail/", "/mail/u/1/?tab=1#inbox", "https://mail.google.com/mail/u/1/?tab=1#inbox", false},
+		{"https://github.com/org", "/../etc", "https://github.com/etc", false},
+		{"https://github.com", "//evil.example/", "", true},
+		{"https://github.com", "https://evil.example/", "", true},
+	}
+	for _, tt := range tests {
+		got, err := siteURLWithPath(tt.siteURL, tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("siteURLWithPath(%q, %q) = %q, %v, want %q", tt.siteURL, tt.path, got, err, tt.want)
+		}
+	}
+}
+
+// confirmPlatform answers confirmation questions without a dialog
+type confirmPlatform struct {
+	platform.Platform
+	answer    bool
+	questions []string
+}
+
+func (p *confirmPlatform) Confirm(window uintptr, title, text string) bool {
+	p.questions = append(p.questions, text)
+	return p.answer
+}
+
+func TestAddSiteAsksFirst(t *testing.T) {
+	dir := t.TempDir()
+	p := &confirmPlatform{}
+	a := &App{
+		platform:   p,
+		appDataDir: dir,
+		execDir:    dir,
+		execPath:   filepath.Join(dir, "hobaa.exe"),
+	}
+
+	err := a.addSite("notes", "https://notes.example.com/app")
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("addSite error = %v, want cancelled", err)
+	}
+	if len(p.questions) != 1 {
+		t.Fatalf("asked %d questions, want 1", len(p.questions))
+	}
+	for _, want := range []string{"notes", "https://notes.example.com/app", "notes.exe"} {
+		if !strings.Contains(p.questions[0], want) {
+			t.Errorf("question %q doesn't mention %q", p.questions[0], want)
+		}
+	}
+
+	// Nothing is saved or created when the user declines
+	if _, err := os.Stat(config.GetAppDataSitesPath(dir)); !os.IsNotExist(err) {
+		t.Errorf("sites.json was written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.exe")); !os.IsNotExist(err) {
+		t.Errorf("site EXE was created: %v", err)
+	}
+}
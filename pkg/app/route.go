@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kemalersin/hobaa/pkg/config"
+	"github.com/kemalersin/hobaa/pkg/instance"
+)
+
+// routeAndExit opens the URL given with --route in the site app owning it and exits
+func (a *App) routeAndExit() {
+	if err := a.route(a.routeURL); err != nil {
+		fmt.Printf("Failed to route %s: %v\n", a.routeURL, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// route opens a URL in the site app whose scope contains it.
+// A running site app gets the URL forwarded, otherwise the site app is launched with it.
+// URLs no site owns are opened in the default browser.
+func (a *App) route(rawURL string) error {
+	u, err := parseURLArg(rawURL)
+	if err != nil {
+		return err
+	}
+
+	// Open the URL in the site owning it
+	if site, ok := config.NewHostIndex(a.loadStore().Sites()).Lookup(u); ok {
+		err := a.launchSite(site, "", []string{u.String()})
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("Failed to open %s in %s: %v\n", u, site.Name, err)
+	}
+
+	return a.platform.OpenBrowser(u.String())
+}
+
+// loadStore loads sites.json for the modes that run without a site
+func (a *App) loadStore() *config.Store {
+	store := config.NewStore(config.GetAppDataSitesPath(a.appDataDir), config.DefaultSaveDelay)
+	if err := store.Load(); err != nil {
+		fmt.Printf("Failed to load sites: %v\n", err)
+	}
+	return store
+}
+
+// launchSite opens the arguments in the app of a site and profile.
+// A running app gets them forwarded, otherwise the app is started with them.
+func (a *App) launchSite(site *config.Site, profile string, args []string) error {
+	execName := site.Name
+	if profile != "" {
+		execName += config.ProfileSeparator + profile
+	}
+
+	// Forward the arguments to the running app
+	workDir, _ := os.Getwd()
+	if err := instance.Send(execName, instance.NewActivateMessage(args, workDir)); err == nil {
+		return nil
+	}
+
+	// Start the app with the arguments
+	exe := a.siteExecutable(site, execName)
+	if exe == "" {
+		return fmt.Errorf("no app found for %s", execName)
+	}
+	if err := exec.Command(exe, args...).Start(); err != nil {
+		return fmt.Errorf("failed to launch %s: %v", exe, err)
+	}
+	return nil
+}
+
+// siteExecutable returns the EXE of a site app, either where it last ran or next to this EXE.
+// Profile EXEs such as gmail@work.exe are only looked up next to this EXE.
+func (a *App) siteExecutable(site *config.Site, execName string) string {
+	candidates := []string{filepath.Join(a.execDir, execName+filepath.Ext(a.execPath))}
+	if execName == site.Name && site.Executable != "" {
+		candidates = append([]string{site.Executable}, candidates...)
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// recordExecutable remembers where the site app runs from so the router can launch it
+func (a *App) recordExecutable() {
+	// Profile EXEs such as gmail@work.exe are not used by the router
+	if a.currentSite == nil || a.profileName != "" || a.currentSite.Executable == a.execPath {
+		return
+	}
+
+	name, execPath := a.siteName, a.execPath
+	a.siteStore.Update(func(c *config.SiteConfig) {
+		if site := c.GetSiteByName(name); site != nil {
+			site.Executable = execPath
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/kemalersin/hobaa/pkg/config"
+	"github.com/kemalersin/hobaa/pkg/instance"
+)
+
+// newRouteApp returns an app in router mode for a sites.json with the given sites
+func newRouteApp(t *testing.T, sites []config.Site) (*App, *browserPlatform) {
+	t.Helper()
+	dir := t.TempDir()
+	c := config.NewSiteConfig("")
+	c.Sites = sites
+	if err := c.SaveToFile(config.GetAppDataSitesPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &browserPlatform{}
+	return &App{platform: p, appDataDir: dir, execDir: dir, execPath: os.Args[0]}, p
+}
+
+func TestRoute(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	}
+	sites := []config.Site{
+		{Name: "route-test-github", URL: "https://github.com"},
+		{Name: "route-test-gitlab", URL: "https://gitlab.com"},
+	}
+
+	// The github app is running, the gitlab app is neither running nor installed
+	inst, err := instance.Acquire("route-test-github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Close()
+	forwarded := make(chan instance.Message, 1)
+	go inst.Serve(func(m instance.Message) error {
+		forwarded <- m
+		return nil
+	})
+
+	tests := []struct {
+		name          string
+		url           string
+		wantForwarded []string
+		wantOpened    []string
+		wantErr       bool
+	}{
+		{
+			name:          "running site",
+			url:           "https://github.com/org/repo/pull/1",
+			wantForwarded: []string{"https://github.com/org/repo/pull/1"},
+		},
+		{
+			name:          "parent domain of a running site",
+			url:           "https://www.github.com/notifications",
+			wantForwarded: []string{"https://www.github.com/notifications"},
+		},
+		{
+			name:       "site without an app",
+			url:        "https://gitlab.com/org/repo",
+			wantOpened: []string{"https://gitlab.com/org/repo"},
+		},
+		{
+			name:       "no site owns the url",
+			url:        "https://example.com/page",
+			wantOpened: []string{"https://example.com/page"},
+		},
+		{
+			name:    "not a url",
+			url:     "github.com/org/repo",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		a, p := newRouteApp(t, sites)
+		if err := a.route(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("%s: route error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(p.opened, tt.wantOpened) {
+			t.Errorf("%s: opened in the browser %v, want %v", tt.name, p.opened, tt.wantOpened)
+		}
+
+		var got []string
+		select {
+		case m := <-forwarded:
+			got = m.Args
+		case <-time.After(100 * time.Millisecond):
+		}
+		if !reflect.DeepEqual(got, tt.wantForwarded) {
+			t.Errorf("%s: forwarded %v, want %v", tt.name, got, tt.wantForwarded)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/kemalersin/hobaa/pkg/scope"
+)
+
+// scopeScript sends links and window.open calls outside the site scope to the default browser
+// before the page starts navigating. The scope is injected as regular expressions generated by the scope package.
+const scopeScript = `
+(function() {
+	const scope = %s.map(source => new RegExp(source));
+
+	// Check a URL the same way scope.Normalize does
+	function outsideScope(href) {
+		let u;
+		try {
+			u = new URL(href, location.href);
+		} catch (e) {
+			return null;
+		}
+		if (u.protocol !== 'http:' && u.protocol !== 'https:') {
+			return null;
+		}
+		const target = u.protocol + '//' + u.host + u.pathname;
+		return scope.some(re => re.test(target)) ? null : u.href;
+	}
+
+	// Intercept clicked links, including middle clicks
+	function onClick(event) {
+		if (event.defaultPrevented || event.button > 1) {
+			return;
+		}
+		const link = event.target.closest && event.target.closest('a[href]');
+		const external = link && outsideScope(link.href);
+		if (external) {
+			event.preventDefault();
+			event.stopPropagation();
+			window.hobaaOpenExternal(external);
+		}
+	}
+	document.addEventListener('click', onClick, true);
+	document.addEventListener('auxclick', onClick, true);
+
+	// Intercept popups opened by scripts
+	const open = window.open;
+	window.open = function(href, ...args) {
+		const external = href ? outsideScope(String(href)) : null;
+		if (external) {
+			window.hobaaOpenExternal(external);
+			return null;
+		}
+		return open.call(window, href, ...args);
+	};
+})();
+`
+
+// setupNavigationScope makes links outside the site scope open in the default browser
+func (a *App) setupNavigationScope(siteURL string) {
+	// Build the matcher from the site scope, or the site host if none is configured
+	var patterns []string
+	if a.currentSite != nil {
+		patterns = a.currentSite.Scope
+	}
+	matcher, err := scope.ForSite(siteURL, patterns)
+	if err != nil {
+		fmt.Printf("Invalid site scope, links stay in the app: %v\n", err)
+		return
+	}
+	if len(matcher.Patterns()) == 0 {
+		return
+	}
+	a.scope = matcher
+
+	// Cancel all other navigations leaving the scope, such as location changes, form posts, meta refreshes
+	// and server redirects, and open them in the default browser instead
+	if err := a.webView.SetNavigationFilter(func(rawURL string) bool {
+		external, ok := matcher.Outside(rawURL)
+		if ok {
+			go func() {
+				if err := a.platform.OpenBrowser(external); err != nil {
+					fmt.Printf("Failed to open %s: %v\n", external, err)
+				}
+			}()
+		}
+		return !ok
+	}); err != nil {
+		fmt.Printf("Failed to filter navigations, only links are checked: %v\n", err)
+	}
+
+	// Open URLs reported by the page after checking them again
+	a.webView.Bind("hobaaOpenExternal", func(rawURL string) error {
+		return a.openURL(rawURL)
+	})
+
+	// Inject the scope check into every page
+	sources := make([]string, 0, len(matcher.Patterns()))
+	for _, p := range matcher.Patterns() {
+		sources = append(sources, p.Regexp())
+	}
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return
+	}
+	a.webView.Init(fmt.Sprintf(scopeScript, data))
+}
+
+// openURL navigates to a URL inside the app if it is within the site scope,
+// or opens it in the default browser otherwise
+func (a *App) openURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if _, ok := scope.Normalize(u); !ok {
+		return fmt.Errorf("only http and https URLs can be opened: %s", rawURL)
+	}
+
+	if a.scope == nil || a.scope.Match(u) {
+		a.webView.Dispatch(func() {
+			a.webView.Navigate(u.String())
+		})
+		return nil
+	}
+	return a.platform.OpenBrowser(u.String())
+}
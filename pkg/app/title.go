@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kemalersin/hobaa/pkg/pagetitle"
+)
+
+// titleInterval is the shortest time between two changes of the window title
+const titleInterval = 500 * time.Millisecond
+
+// newTitleUpdates returns a throttle setting the window title from page titles through the title template
+// of the site, or nil for sites without a template
+func (a *App) newTitleUpdates() *pagetitle.Throttle {
+	if a.currentSite == nil || a.currentSite.TitleTemplate == "" {
+		return nil
+	}
+	tmpl, err := pagetitle.Parse(a.currentSite.TitleTemplate)
+	if err != nil {
+		fmt.Printf("Failed to set up the title of %s: %v\n", a.siteName, err)
+		return nil
+	}
+
+	return pagetitle.NewThrottle(titleInterval, func(pageTitle string) {
+		a.webView.Dispatch(func() {
+			a.webView.SetTitle(a.renderTitle(tmpl, pageTitle))
+		})
+	})
+}
+
+// renderTitle renders the window title for a page title, using the site title for pages without one
+func (a *App) renderTitle(tmpl *pagetitle.Template, pageTitle string) string {
+	if pageTitle == "" {
+		return a.title
+	}
+	title, err := tmpl.Render(pagetitle.Data{
+		PageTitle: pageTitle,
+		SiteTitle: a.title,
+		Profile:   a.profileName,
+	})
+	if err != nil {
+		fmt.Printf("Failed to render title: %v\n", err)
+		return a.title
+	}
+	if title == "" {
+		return a.title
+	}
+	return title
+}
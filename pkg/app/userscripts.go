@@ -0,0 +1,25 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kemalersin/hobaa/pkg/userscript"
+)
+
+// scriptsDir returns the directory of the site's user scripts and styles
+func (a *App) scriptsDir() string {
+	return filepath.Join(a.appDataDir, "sites", a.siteName, "scripts")
+}
+
+// setupUserScripts adds the user scripts and styles of the site to every page.
+// They run after window.hobaa is added, so scripts can use it.
+func (a *App) setupUserScripts() {
+	scripts, errs := userscript.Load(a.scriptsDir())
+	for _, err := range errs {
+		fmt.Printf("Failed to load user script: %v\n", err)
+	}
+	for _, script := range scripts {
+		a.webView.Init(script.InitScript())
+	}
+}
@@ -0,0 +1,175 @@
+package badge
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// Sizes are the icon sizes written with a badge, covering the small, medium and large window icons
+var Sizes = []int{16, 24, 32, 48, 64}
+
+// Badge colors
+var (
+	badgeColor  = color.NRGBA{R: 0xd9, G: 0x30, B: 0x25, A: 0xff}
+	borderColor = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	textColor   = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// glyphs is a 3x5 pixel font for the characters of badge labels
+var glyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", ".##", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+}
+
+// Glyph layout in font pixels
+const (
+	glyphWidth   = 3
+	glyphHeight  = 5
+	glyphSpacing = 1
+	padding      = 2 // Space around the label inside the badge
+)
+
+// OverlayICO decodes an ICO file and returns a new ICO file with the count drawn over each size
+func OverlayICO(data []byte, count int) ([]byte, error) {
+	img, err := utils.DecodeICO(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode icon: %v", err)
+	}
+
+	images := make([]*image.NRGBA, len(Sizes))
+	for i, size := range Sizes {
+		images[i] = Overlay(utils.ResizeImage(img, size), count)
+	}
+
+	var buf bytes.Buffer
+	if err := utils.WriteICO(&buf, images); err != nil {
+		return nil, fmt.Errorf("failed to encode icon: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Overlay draws the label of the count as a pill in the bottom right corner of the image.
+// The image is changed in place and returned; a count of 0 leaves it unchanged.
+func Overlay(img *image.NRGBA, count int) *image.NRGBA {
+	if count <= 0 {
+		return img
+	}
+	label := Label(count)
+	bounds := img.Bounds()
+	size := bounds.Dx()
+	if bounds.Dy() < size {
+		size = bounds.Dy()
+	}
+
+	// Scale the font with the icon, 16 pixel icons use one pixel per font pixel
+	scale := size / 16
+	if scale < 1 {
+		scale = 1
+	}
+	border := 0
+	if scale > 1 {
+		border = scale / 2
+	}
+	textWidth := (len(label)*(glyphWidth+glyphSpacing) - glyphSpacing) * scale
+	height := (glyphHeight+2*padding)*scale + 2*border
+	width := textWidth + 2*padding*scale + 2*border
+	if width < height {
+		width = height
+	}
+	if width > bounds.Dx() {
+		width = bounds.Dx()
+	}
+
+	// Draw the bordered pill aligned to the bottom right corner
+	pill := image.Rect(bounds.Max.X-width, bounds.Max.Y-height, bounds.Max.X, bounds.Max.Y)
+	if border > 0 {
+		fillPill(img, pill, borderColor)
+	}
+	fillPill(img, pill.Inset(border), badgeColor)
+
+	// Draw the label centered in the pill
+	x := pill.Min.X + (pill.Dx()-textWidth)/2
+	y := pill.Min.Y + (pill.Dy()-glyphHeight*scale)/2
+	for _, r := range label {
+		glyph := glyphs[r]
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if glyph[row][col] == '#' {
+					fillRect(img, image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale), textColor)
+				}
+			}
+		}
+		x += (glyphWidth + glyphSpacing) * scale
+	}
+	return img
+}
+
+// fillPill draws an anti-aliased rectangle with fully rounded ends
+func fillPill(img *image.NRGBA, r image.Rectangle, c color.NRGBA) {
+	radius := float64(r.Dy()) / 2
+	centerY := float64(r.Min.Y) + radius
+	left := float64(r.Min.X) + radius
+	right := float64(r.Max.X) - radius
+	if right < left {
+		left = (left + right) / 2
+		right = left
+	}
+
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			// Distance of the pixel center from the center line of the pill
+			px := math.Max(left, math.Min(right, float64(x)+0.5))
+			distance := math.Hypot(float64(x)+0.5-px, float64(y)+0.5-centerY)
+			coverage := math.Max(0, math.Min(1, radius-distance+0.5))
+			if coverage > 0 {
+				blend(img, x, y, c, coverage)
+			}
+		}
+	}
+}
+
+// fillRect draws an opaque rectangle
+func fillRect(img *image.NRGBA, r image.Rectangle, c color.NRGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+}
+
+// blend draws a color over a pixel with the given coverage
+func blend(img *image.NRGBA, x, y int, c color.NRGBA, coverage float64) {
+	dst := img.NRGBAAt(x, y)
+	srcA := float64(c.A) / 255 * coverage
+	dstA := float64(dst.A) / 255
+	outA := srcA + dstA*(1-srcA)
+	if outA == 0 {
+		img.SetNRGBA(x, y, color.NRGBA{})
+		return
+	}
+	mix := func(s, d uint8) uint8 {
+		return uint8(math.Round((float64(s)*srcA + float64(d)*dstA*(1-srcA)) / outA))
+	}
+	img.SetNRGBA(x, y, color.NRGBA{
+		R: mix(c.R, dst.R),
+		G: mix(c.G, dst.G),
+		B: mix(c.B, dst.B),
+		A: uint8(math.Round(outA * 255)),
+	})
+}
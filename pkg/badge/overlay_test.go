@@ -0,0 +1,78 @@
+package badge
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// blueIcon returns an opaque blue image
+func blueIcon(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []byte{0x20, 0x40, 0xc0, 0xff})
+	}
+	return img
+}
+
+func TestOverlay(t *testing.T) {
+	for _, size := range Sizes {
+		img := Overlay(blueIcon(size), 7)
+
+		// The badge is in the bottom right corner and the top left corner is untouched
+		corner := img.NRGBAAt(size-1-size/8, size-1-size/16)
+		if corner == (color.NRGBA{R: 0x20, G: 0x40, B: 0xc0, A: 0xff}) {
+			t.Errorf("%dpx: no badge in the bottom right corner", size)
+		}
+		if got := img.NRGBAAt(0, 0); got != (color.NRGBA{R: 0x20, G: 0x40, B: 0xc0, A: 0xff}) {
+			t.Errorf("%dpx: top left pixel changed to %v", size, got)
+		}
+
+		// The label is drawn in white
+		white := 0
+		for i := 0; i < len(img.Pix); i += 4 {
+			if img.Pix[i] == 0xff && img.Pix[i+1] == 0xff && img.Pix[i+2] == 0xff {
+				white++
+			}
+		}
+		if white == 0 {
+			t.Errorf("%dpx: label wasn't drawn", size)
+		}
+	}
+}
+
+func TestOverlayWithoutCount(t *testing.T) {
+	img := blueIcon(32)
+	want := append([]byte(nil), img.Pix...)
+	for _, count := range []int{0, -1} {
+		if got := Overlay(img, count); !bytes.Equal(got.Pix, want) {
+			t.Errorf("Overlay with count %d changed the image", count)
+		}
+	}
+}
+
+func TestOverlayICO(t *testing.T) {
+	var ico bytes.Buffer
+	if err := utils.EncodeICO(&ico, blueIcon(256), []int{16, 32, 256}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := OverlayICO(ico.Bytes(), 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := utils.DecodeICO(data)
+	if err != nil {
+		t.Fatalf("badge icon is not a valid ICO file: %v", err)
+	}
+	if got := img.Bounds().Dx(); got != Sizes[len(Sizes)-1] {
+		t.Errorf("largest image is %dpx, want %dpx", got, Sizes[len(Sizes)-1])
+	}
+
+	if _, err := OverlayICO([]byte("not an icon"), 1); err == nil {
+		t.Error("OverlayICO accepted invalid data")
+	}
+}
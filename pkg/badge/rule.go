@@ -0,0 +1,95 @@
+// Package badge extracts unread counts from pages and draws them over the icons of site windows.
+package badge
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxCount is the largest count a badge keeps
+const MaxCount = 99999
+
+// DefaultTitlePattern matches counts in parentheses such as "Inbox (12)" or "(1,234+) Chat"
+const DefaultTitlePattern = `\((\d[\d,.]*)\+?\)`
+
+// maxSelectorLength is the longest CSS selector accepted in a rule
+const maxSelectorLength = 512
+
+// digitsPattern finds the first number of a text, allowing thousands separators
+var digitsPattern = regexp.MustCompile(`\d[\d,.]*`)
+
+// Rule extracts an unread count either from the page title or from the text of an element
+type Rule struct {
+	title    *regexp.Regexp
+	selector string
+}
+
+// Compile creates a rule from a regular expression matched against the title or a CSS selector.
+// The count is taken from the first group of the expression, or the whole match without groups.
+// A rule without either uses DefaultTitlePattern.
+func Compile(titlePattern, selector string) (*Rule, error) {
+	if titlePattern != "" && selector != "" {
+		return nil, fmt.Errorf("badge rule has both a title pattern and a selector")
+	}
+	if selector != "" {
+		if len(selector) > maxSelectorLength {
+			return nil, fmt.Errorf("badge selector is longer than %d bytes", maxSelectorLength)
+		}
+		return &Rule{selector: selector}, nil
+	}
+
+	if titlePattern == "" {
+		titlePattern = DefaultTitlePattern
+	}
+	re, err := regexp.Compile(titlePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid badge title pattern: %v", err)
+	}
+	return &Rule{title: re}, nil
+}
+
+// Selector returns the CSS selector of the element holding the count, empty for title rules
+func (r *Rule) Selector() string {
+	return r.selector
+}
+
+// Count returns the unread count found in the page title or in the text of the selected element
+func (r *Rule) Count(title, text string) int {
+	if r.selector != "" {
+		return ParseCount(text)
+	}
+
+	match := r.title.FindStringSubmatch(title)
+	switch {
+	case match == nil:
+		return 0
+	case len(match) > 1:
+		return ParseCount(match[1])
+	default:
+		return ParseCount(match[0])
+	}
+}
+
+// ParseCount returns the first number of a text such as "12", "1,234" or "99+", or 0 without one
+func ParseCount(text string) int {
+	digits := digitsPattern.FindString(text)
+	digits = strings.NewReplacer(",", "", ".", "").Replace(digits)
+	if digits == "" {
+		return 0
+	}
+	count, err := strconv.Atoi(digits)
+	if err != nil || count > MaxCount {
+		return MaxCount
+	}
+	return count
+}
+
+// Label returns the text drawn for a count, which is shortened to "99+" above 99
+func Label(count int) string {
+	if count > 99 {
+		return "99+"
+	}
+	return strconv.Itoa(count)
+}
@@ -0,0 +1,142 @@
+package badge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		title, selector string
+	}{
+		{`\((\d+)\)`, ".unread"},
+		{`(\d+`, ""},
+		{"", strings.Repeat("a", maxSelectorLength+1)},
+	}
+	for _, tt := range tests {
+		if _, err := Compile(tt.title, tt.selector); err == nil {
+			t.Errorf("Compile(%q, %q) succeeded", tt.title, tt.selector)
+		}
+	}
+}
+
+func TestTitleRules(t *testing.T) {
+	tests := []struct {
+		pattern string
+		title   string
+		want    int
+	}{
+		// Default pattern
+		{"", "Inbox (12) - Gmail", 12},
+		{"", "(3) Chat", 3},
+		{"", "(1,234+) Notifications", 1234},
+		{"", "(99+) Feed", 99},
+		{"", "(1.234) Posts", 1234},
+		{"", "Inbox - Gmail", 0},
+		{"", "Version (beta)", 0},
+		{"", "", 0},
+		{"", "(0) Inbox", 0},
+		{"", "(1) Inbox (5)", 1},
+
+		// First group
+		{`^(\d+) unread`, "7 unread - Slack", 7},
+		{`^(\d+) unread`, "Slack - 7 unread", 0},
+		{`\[(\d+)\]`, "[42] Jira", 42},
+		{`(?i)(\d+) NEW`, "5 new messages", 5},
+
+		// Whole match without groups
+		{`\d+ notifications`, "You have 8 notifications", 8},
+
+		// Group without digits
+		{`Inbox (\w+)`, "Inbox empty", 0},
+	}
+	for _, tt := range tests {
+		r, err := Compile(tt.pattern, "")
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.pattern, err)
+			continue
+		}
+		if r.Selector() != "" {
+			t.Errorf("title rule %q has selector %q", tt.pattern, r.Selector())
+		}
+		if got := r.Count(tt.title, "123"); got != tt.want {
+			t.Errorf("rule %q: Count(%q) = %d, want %d", tt.pattern, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestSelectorRule(t *testing.T) {
+	r, err := Compile("", `[aria-label="Unread"] .count`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Selector() != `[aria-label="Unread"] .count` {
+		t.Errorf("Selector() = %q", r.Selector())
+	}
+
+	// The count comes from the element text, not the title
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"4", 4},
+		{" 12 new ", 12},
+		{"99+", 99},
+		{"", 0},
+		{"none", 0},
+	}
+	for _, tt := range tests {
+		if got := r.Count("Inbox (50)", tt.text); got != tt.want {
+			t.Errorf("Count with text %q = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"12", 12},
+		{"1,234", 1234},
+		{"1.234.567", MaxCount},
+		{"99+", 99},
+		{"a3b4", 3},
+		{"007", 7},
+		{"", 0},
+		{"no digits", 0},
+		{"-5", 5},
+		{"99999", 99999},
+		{"100000", MaxCount},
+		{"99999999999999999999999", MaxCount},
+	}
+	for _, tt := range tests {
+		if got := ParseCount(tt.text); got != tt.want {
+			t.Errorf("ParseCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{9, "9"},
+		{99, "99"},
+		{100, "99+"},
+		{MaxCount, "99+"},
+	}
+	for _, tt := range tests {
+		if got := Label(tt.count); got != tt.want {
+			t.Errorf("Label(%d) = %q, want %q", tt.count, got, tt.want)
+		}
+		for _, r := range tt.want {
+			if _, ok := glyphs[r]; !ok {
+				t.Errorf("Label(%d) uses %q, which the badge font can't draw", tt.count, r)
+			}
+		}
+	}
+}
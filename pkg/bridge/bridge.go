@@ -0,0 +1,210 @@
+// Package bridge exposes Go handlers to pages as the window.hobaa JavaScript API.
+// Every method returns a promise that resolves with the result of its handler,
+// or rejects with an Error if the arguments are invalid or the handler fails.
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// bindingName is the webview binding all calls from window.hobaa go through
+const bindingName = "hobaaBridgeCall"
+
+// Binder registers Go functions and page scripts with a webview
+type Binder interface {
+	Bind(name string, f interface{}) error
+	Init(js string)
+}
+
+// Validator is implemented by argument types that check their values before the handler runs
+type Validator interface {
+	Validate() error
+}
+
+// method is a registered window.hobaa method
+type method struct {
+	params []string // Names of the JavaScript arguments in order
+	call   func(args json.RawMessage) (any, error)
+}
+
+// Bridge holds the methods of window.hobaa
+type Bridge struct {
+	mu      sync.RWMutex
+	methods map[string]method
+	scripts []string
+	scope   []string    // Regular expressions of the pages that get window.hobaa, nil for all pages
+	allowed func() bool // Checks the page before every call, nil to allow all calls
+}
+
+// New creates a bridge without methods
+func New() *Bridge {
+	return &Bridge{methods: map[string]method{}}
+}
+
+// Handle registers a method of window.hobaa.
+// The JavaScript arguments are named by params and decoded into A by their JSON field names,
+// e.g. params ["url"] passes hobaa.openExternal(u) to the handler as a struct with a `json:"url"` field set to u.
+// Dotted names such as "storage.get" become nested objects.
+func Handle[A, R any](b *Bridge, name string, params []string, handler func(args A) (R, error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.methods[name] = method{
+		params: params,
+		call: func(raw json.RawMessage) (any, error) {
+			var args A
+			if err := decodeArgs(raw, &args); err != nil {
+				return nil, fmt.Errorf("%s: invalid arguments: %v", name, err)
+			}
+			if v, ok := any(&args).(Validator); ok {
+				if err := v.Validate(); err != nil {
+					return nil, fmt.Errorf("%s: %v", name, err)
+				}
+			}
+			return handler(args)
+		},
+	}
+}
+
+// Restrict limits window.hobaa to top-level documents whose URL matches one of the regular expressions,
+// which are matched against the scheme, host and path of the URL as the scope package normalizes them.
+// Pages can call the binding without window.hobaa, so allowed is also checked before every call
+// and should check the top-level document of the webview.
+func (b *Bridge) Restrict(scope []string, allowed func() bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.scope = append([]string{}, scope...)
+	b.allowed = allowed
+}
+
+// Call runs a method with its arguments as a JSON object
+func (b *Bridge) Call(name string, args json.RawMessage) (any, error) {
+	b.mu.RLock()
+	m, ok := b.methods[name]
+	allowed := b.allowed
+	b.mu.RUnlock()
+	if allowed != nil && !allowed() {
+		return nil, fmt.Errorf("window.hobaa is not available on this page")
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown method %q", name)
+	}
+	return m.call(args)
+}
+
+// AddScript adds JavaScript that runs in every page that gets window.hobaa, after it is defined, such as polyfills using it
+func (b *Bridge) AddScript(js string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.scripts = append(b.scripts, js)
+}
+
+// Install binds the bridge to a webview and adds window.hobaa to every page.
+// Methods must be registered before, later ones are not part of window.hobaa.
+func (b *Bridge) Install(w Binder) error {
+	if err := w.Bind(bindingName, b.Call); err != nil {
+		return err
+	}
+	w.Init(b.Script())
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, js := range b.scripts {
+		w.Init("if (window.hobaa) {\n" + js + "\n}")
+	}
+	return nil
+}
+
+// Script returns the JavaScript defining window.hobaa
+func (b *Bridge) Script() string {
+	b.mu.RLock()
+	names := make([]string, 0, len(b.methods))
+	for name := range b.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make(map[string][]string, len(names))
+	for _, name := range names {
+		params[name] = append([]string{}, b.methods[name].params...)
+	}
+	scope := b.scope
+	b.mu.RUnlock()
+
+	data, _ := json.Marshal(params)
+	scopeData, _ := json.Marshal(scope)
+	return fmt.Sprintf(facadeScript, scopeData, data, bindingName)
+}
+
+// decodeArgs decodes the JSON object of arguments into args, rejecting unknown arguments
+func decodeArgs(raw json.RawMessage, args any) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		raw = json.RawMessage("{}")
+	}
+	if raw[0] != '{' {
+		return fmt.Errorf("arguments must be an object")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(args); err != nil {
+		return fmt.Errorf("%s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// facadeScript defines window.hobaa from the method names and their argument names
+// in top-level documents within the scope. Frames and other pages don't get it.
+// Rejections of the binding are strings, so they are turned into Errors.
+const facadeScript = `
+(function() {
+	const scope = %s;
+	const page = location.protocol + '//' + location.host + location.pathname;
+	if (window.top !== window || (scope && !scope.some(source => new RegExp(source).test(page)))) {
+		return;
+	}
+
+	const methods = %s;
+	const binding = %q;
+
+	const api = {};
+	for (const [name, params] of Object.entries(methods)) {
+		// Create nested objects for dotted names
+		const path = name.split('.');
+		let target = api;
+		for (const key of path.slice(0, -1)) {
+			target = target[key] = target[key] || {};
+		}
+
+		target[path[path.length - 1]] = function(...values) {
+			if (values.length > params.length) {
+				return Promise.reject(new TypeError('hobaa.' + name + ' takes at most ' + params.length + ' arguments'));
+			}
+			const args = {};
+			params.forEach((param, i) => {
+				if (values[i] !== undefined) {
+					args[param] = values[i];
+				}
+			});
+			return window[binding](name, args).catch(message => {
+				throw new Error(String(message));
+			});
+		};
+	}
+
+	// Keep pages from replacing the methods
+	(function freeze(object) {
+		Object.values(object).forEach(value => typeof value === 'object' && freeze(value));
+		return Object.freeze(object);
+	})(api);
+	Object.defineProperty(window, 'hobaa', {value: api, enumerable: true});
+})();
+`
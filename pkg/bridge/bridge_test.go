@@ -0,0 +1,134 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeBinder records what Install registers with a webview
+type fakeBinder struct {
+	bindings map[string]interface{}
+	scripts  []string
+}
+
+func (f *fakeBinder) Bind(name string, fn interface{}) error {
+	if f.bindings == nil {
+		f.bindings = map[string]interface{}{}
+	}
+	f.bindings[name] = fn
+	return nil
+}
+
+func (f *fakeBinder) Init(js string) {
+	f.scripts = append(f.scripts, js)
+}
+
+// echoArgs are the arguments of the test method
+type echoArgs struct {
+	Text string `json:"text"`
+}
+
+// Validate rejects empty text
+func (args *echoArgs) Validate() error {
+	if args.Text == "" {
+		return fmt.Errorf("text is required")
+	}
+	return nil
+}
+
+func newEchoBridge() *Bridge {
+	b := New()
+	Handle(b, "test.echo", []string{"text"}, func(args echoArgs) (string, error) {
+		return args.Text, nil
+	})
+	return b
+}
+
+func TestCall(t *testing.T) {
+	b := newEchoBridge()
+	tests := []struct {
+		name    string
+		args    string
+		want    any
+		wantErr string
+	}{
+		{"test.echo", `{"text":"hi"}`, "hi", ""},
+		{"test.echo", `{}`, nil, "text is required"},
+		{"test.echo", `null`, nil, "text is required"},
+		{"test.echo", `{"text":1}`, nil, "invalid arguments"},
+		{"test.echo", `{"text":"hi","other":1}`, nil, "unknown field"},
+		{"test.echo", `["hi"]`, nil, "must be an object"},
+		{"test.missing", `{}`, nil, "unknown method"},
+	}
+	for _, tt := range tests {
+		got, err := b.Call(tt.name, json.RawMessage(tt.args))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Call(%s, %s) error = %v, want %q", tt.name, tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Call(%s, %s) = %v, %v, want %v", tt.name, tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestRestrictChecksEveryCall(t *testing.T) {
+	b := newEchoBridge()
+	page := "https://github.com/"
+	b.Restrict([]string{`^https://github\.com(/.*)?$`}, func() bool {
+		return strings.HasPrefix(page, "https://github.com/")
+	})
+
+	if got, err := b.Call("test.echo", json.RawMessage(`{"text":"hi"}`)); err != nil || got != "hi" {
+		t.Errorf("call from the site = %v, %v", got, err)
+	}
+
+	// A page outside the scope calling the binding directly is refused before the method runs
+	page = "https://evil.example/"
+	if _, err := b.Call("test.echo", json.RawMessage(`{"text":"hi"}`)); err == nil {
+		t.Error("call from a page outside the scope succeeded")
+	}
+	if _, err := b.Call("test.missing", nil); err == nil || strings.Contains(err.Error(), "unknown method") {
+		t.Errorf("call from a page outside the scope learned about methods: %v", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	b := newEchoBridge()
+	b.Restrict([]string{`^https://github\.com(/.*)?$`}, nil)
+	b.AddScript("window.Notification = hobaa.test;")
+
+	var w fakeBinder
+	if err := b.Install(&w); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.bindings[bindingName]; !ok {
+		t.Errorf("binding %s wasn't registered", bindingName)
+	}
+	if len(w.scripts) != 2 {
+		t.Fatalf("got %d scripts, want the facade and one added script", len(w.scripts))
+	}
+
+	// The facade only defines window.hobaa for top-level pages in the scope
+	facade := w.scripts[0]
+	for _, want := range []string{`const scope = ["^https://github\\.com(/.*)?$"]`, "window.top !== window", `"test.echo":["text"]`} {
+		if !strings.Contains(facade, want) {
+			t.Errorf("facade doesn't contain %s:\n%s", want, facade)
+		}
+	}
+
+	// Added scripts only run where window.hobaa is defined
+	if want := "if (window.hobaa) {\nwindow.Notification = hobaa.test;\n}"; w.scripts[1] != want {
+		t.Errorf("added script = %q, want %q", w.scripts[1], want)
+	}
+}
+
+func TestScriptWithoutRestriction(t *testing.T) {
+	if script := newEchoBridge().Script(); !strings.Contains(script, "const scope = null;") {
+		t.Errorf("unrestricted facade has a scope:\n%s", script)
+	}
+}
@@ -0,0 +1,161 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// Storage limits, so pages can't fill the disk through window.hobaa.storage
+const (
+	MaxKeyLength   = 256
+	MaxValueSize   = 64 << 10
+	MaxStorageSize = 1 << 20
+)
+
+// Storage is a key-value store persisted to a JSON file.
+// Values are JSON, so pages get back what they stored.
+type Storage struct {
+	mu     sync.Mutex
+	path   string
+	values map[string]json.RawMessage
+	size   int
+}
+
+// CorruptSuffix is appended to storage files that can't be parsed when they are moved aside
+const CorruptSuffix = ".corrupt"
+
+// OpenStorage loads the storage file, or starts empty if it doesn't exist.
+// A file that can't be parsed is moved aside before starting empty, so it isn't overwritten by the next change;
+// the storage is returned together with an error then. If the storage can't be used, it returns nil.
+func OpenStorage(path string) (*Storage, error) {
+	s := &Storage{path: path, values: map[string]json.RawMessage{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.values); err != nil {
+		s.values = map[string]json.RawMessage{}
+		if renameErr := os.Rename(path, path+CorruptSuffix); renameErr != nil {
+			return nil, fmt.Errorf("failed to parse storage %s: %v", path, err)
+		}
+		return s, fmt.Errorf("failed to parse storage %s, moved it to %s: %v", path, path+CorruptSuffix, err)
+	}
+	for key, value := range s.values {
+		s.size += len(key) + len(value)
+	}
+	return s, nil
+}
+
+// Get returns the value of a key
+func (s *Storage) Get(key string) (json.RawMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores a JSON value under a key and writes the file
+func (s *Storage) Set(key string, value json.RawMessage) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	// Store values compacted so the size limit counts what is written
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, value); err != nil {
+		return fmt.Errorf("value is not valid JSON: %v", err)
+	}
+	if compact.Len() > MaxValueSize {
+		return fmt.Errorf("value is larger than %d bytes", MaxValueSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := s.size + len(key) + compact.Len()
+	if old, ok := s.values[key]; ok {
+		size -= len(key) + len(old)
+	}
+	if size > MaxStorageSize {
+		return fmt.Errorf("storage is full (%d bytes)", MaxStorageSize)
+	}
+
+	previous, existed := s.values[key]
+	s.values[key] = compact.Bytes()
+	if err := s.save(); err != nil {
+		// Keep memory in sync with the file
+		if existed {
+			s.values[key] = previous
+		} else {
+			delete(s.values, key)
+		}
+		return err
+	}
+	s.size = size
+	return nil
+}
+
+// Remove deletes a key and writes the file
+func (s *Storage) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[key]
+	if !ok {
+		return nil
+	}
+	delete(s.values, key)
+	if err := s.save(); err != nil {
+		s.values[key] = value
+		return err
+	}
+	s.size -= len(key) + len(value)
+	return nil
+}
+
+// Keys returns the stored keys in order
+func (s *Storage) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// save writes the values while holding the mutex
+func (s *Storage) save() error {
+	data, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save storage: %v", err)
+	}
+	return nil
+}
+
+// ValidateKey checks that a storage key is not empty and not too long
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if len(key) > MaxKeyLength {
+		return fmt.Errorf("key is longer than %d bytes", MaxKeyLength)
+	}
+	return nil
+}
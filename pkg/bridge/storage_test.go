@@ -0,0 +1,113 @@
+package bridge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStorageKeepsValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage", "github.json")
+	s, err := OpenStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("theme", json.RawMessage(`{ "dark": true }`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("count", json.RawMessage(`3`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove("count"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next launch reads the values back, compacted
+	s, err = OpenStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := s.Get("theme"); !ok || string(value) != `{"dark":true}` {
+		t.Errorf("theme = %s, %v", value, ok)
+	}
+	if keys := s.Keys(); !reflect.DeepEqual(keys, []string{"theme"}) {
+		t.Errorf("keys %v, want [theme]", keys)
+	}
+}
+
+func TestStorageLimits(t *testing.T) {
+	s, err := OpenStorage(filepath.Join(t.TempDir(), "github.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key     string
+		value   string
+		wantErr string
+	}{
+		{"", `1`, "key is required"},
+		{strings.Repeat("k", MaxKeyLength+1), `1`, "key is longer"},
+		{"invalid", `{`, "not valid JSON"},
+		{"large", `"` + strings.Repeat("v", MaxValueSize) + `"`, "larger than"},
+	}
+	for _, tt := range tests {
+		if err := s.Set(tt.key, json.RawMessage(tt.value)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Set(%.20q) error = %v, want %q", tt.key, err, tt.wantErr)
+		}
+	}
+
+	// Values fill the storage up to its size
+	value := json.RawMessage(`"` + strings.Repeat("v", MaxValueSize-2) + `"`)
+	for i := 0; ; i++ {
+		err := s.Set(strings.Repeat("k", i+1), value)
+		if err != nil {
+			if !strings.Contains(err.Error(), "storage is full") {
+				t.Fatalf("Set error = %v, want storage is full", err)
+			}
+			break
+		}
+	}
+	if err := s.Set("k", json.RawMessage(`1`)); err != nil {
+		t.Errorf("replacing a value with a smaller one failed: %v", err)
+	}
+}
+
+func TestOpenCorruptStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github.json")
+	corrupt := []byte(`{"theme": {"dark": tr`)
+	if err := os.WriteFile(path, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The storage starts empty, and the file is moved aside rather than replaced by the next change
+	s, err := OpenStorage(path)
+	if err == nil {
+		t.Error("no error for a corrupt file")
+	}
+	if s == nil {
+		t.Fatal("no storage after moving the corrupt file aside")
+	}
+	if keys := s.Keys(); len(keys) != 0 {
+		t.Errorf("keys %v from a corrupt file", keys)
+	}
+	if err := s.Set("theme", json.RawMessage(`1`)); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path + CorruptSuffix); err != nil || string(data) != string(corrupt) {
+		t.Errorf("moved file has %q, %v, want the corrupt file", data, err)
+	}
+}
+
+func TestOpenUnreadableStorage(t *testing.T) {
+	// A directory in place of the file can't be read, and must not be replaced
+	path := filepath.Join(t.TempDir(), "github.json")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := OpenStorage(path); err == nil || s != nil {
+		t.Errorf("OpenStorage = %v, %v, want no storage and an error", s, err)
+	}
+}
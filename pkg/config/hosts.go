@@ -0,0 +1,79 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/kemalersin/hobaa/pkg/scope"
+)
+
+// HostIndex finds the site that owns a URL by its host
+type HostIndex struct {
+	hosts    map[string][]int // Host to indexes of sites and matchers
+	sites    []Site
+	matchers []*scope.Matcher
+}
+
+// NewHostIndex indexes the sites by the hosts of their URL and scope patterns.
+// Sites with an invalid scope are skipped.
+func NewHostIndex(sites []Site) *HostIndex {
+	index := &HostIndex{hosts: map[string][]int{}}
+	for _, site := range sites {
+		matcher, err := scope.ForSite(site.URL, site.Scope)
+		if err != nil {
+			continue
+		}
+
+		i := len(index.sites)
+		index.sites = append(index.sites, site.Clone())
+		index.matchers = append(index.matchers, matcher)
+
+		// Index each host once per site
+		seen := map[string]bool{}
+		for _, p := range matcher.Patterns() {
+			host := indexHost(p.Host())
+			if host != "" && !seen[host] {
+				seen[host] = true
+				index.hosts[host] = append(index.hosts[host], i)
+			}
+		}
+	}
+	return index
+}
+
+// Lookup returns the site whose scope contains the URL.
+// The most specific host wins, so gist.github.com prefers a gist site over a github.com site.
+// Among sites with the same host, the first site in sites.json wins.
+func (index *HostIndex) Lookup(u *url.URL) (*Site, bool) {
+	if _, ok := scope.Normalize(u); !ok {
+		return nil, false
+	}
+
+	// Try the host and then each parent domain
+	host := indexHost(u.Host)
+	for host != "" {
+		for _, i := range index.hosts[host] {
+			if index.matchers[i].Match(u) {
+				site := index.sites[i].Clone()
+				return &site, true
+			}
+		}
+
+		dot := strings.Index(host, ".")
+		if dot < 0 {
+			break
+		}
+		host = host[dot+1:]
+	}
+	return nil, false
+}
+
+// indexHost returns the lowercase host name without port and "www." prefix
+func indexHost(host string) string {
+	host = strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimPrefix(host, "www.")
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHostIndexLookup(t *testing.T) {
+	index := NewHostIndex([]Site{
+		{Name: "github", URL: "https://github.com"},
+		{Name: "gist", URL: "https://gist.github.com"},
+		{Name: "github-work", URL: "https://github.com/work-org"},
+		{Name: "issues", URL: "https://gitlab.com", Scope: []string{"gitlab.com/*/issues/*"}},
+		{Name: "gitlab", URL: "https://gitlab.com"},
+		{Name: "news", URL: "https://www.example.org/news", Scope: []string{"www.example.org"}},
+		{Name: "docs", URL: "https://www.example.net"},
+		{Name: "local", URL: "http://localhost:8080", Scope: []string{"http://localhost:8080"}},
+		{Name: "broken", URL: "https://broken.example.com", Scope: []string{"ftp://broken.example.com"}},
+	})
+	tests := []struct {
+		url  string
+		want string // Empty if no site owns the URL
+	}{
+		// Exact host
+		{"https://github.com/org/repo", "github"},
+		{"http://github.com/", "github"},
+		{"https://GitHub.com:443/org/repo", "github"},
+		{"https://gist.github.com/user/1", "gist"},
+		{"http://localhost:8080/app", "local"},
+
+		// Parent domain
+		{"https://api.github.com/repos", "github"},
+		{"https://raw.gist.github.com/user/1", "gist"},
+
+		// "www." is ignored in indexed hosts, but scopes still decide what they match
+		{"https://www.github.com/org/repo", "github"},
+		{"https://www.example.org/news/today", "news"},
+		{"https://example.org/news/today", ""},
+		{"https://example.net/", "docs"},
+		{"https://www.example.net/", "docs"},
+
+		// The first configured site wins among sites of the same host
+		{"https://github.com/work-org/repo", "github"},
+		{"https://gitlab.com/org/repo/issues/1", "issues"},
+		{"https://gitlab.com/org/repo/merge_requests/1", "gitlab"},
+
+		// Misses
+		{"https://example.com/", ""},
+		{"https://notgithub.com/", ""},
+		{"https://github.com.example.com/", ""},
+		{"http://localhost:9090/", ""},
+		{"https://broken.example.com/", ""},
+		{"ftp://github.com/", ""},
+		{"github.com/org/repo", ""},
+		{"mailto:me@github.com", ""},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		site, ok := index.Lookup(u)
+		got := ""
+		if ok {
+			got = site.Name
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	// Returned sites are copies
+	u, _ := url.Parse("https://gitlab.com/org/repo/issues/1")
+	site, _ := index.Lookup(u)
+	site.Scope[0] = "example.com"
+	if site, _ := index.Lookup(u); site == nil || site.Name != "issues" {
+		t.Error("index changed through a returned site")
+	}
+}
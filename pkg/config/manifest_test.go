@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+func TestApplyManifest(t *testing.T) {
+	tests := []struct {
+		name        string
+		manifest    utils.WebManifest
+		manifestURL string
+		wantTitle   string
+		wantURL     string
+		wantScope   []string
+	}{
+		// Titles prefer the full name
+		{
+			name:        "name and short name",
+			manifest:    utils.WebManifest{Name: "Example Mail", ShortName: "Mail"},
+			manifestURL: "https://example.com/manifest.json",
+			wantTitle:   "Example Mail",
+			wantURL:     "https://example.com",
+		},
+		{
+			name:        "short name only",
+			manifest:    utils.WebManifest{ShortName: "Mail"},
+			manifestURL: "https://example.com/manifest.json",
+			wantTitle:   "Mail",
+			wantURL:     "https://example.com",
+		},
+		{
+			name:        "no names",
+			manifest:    utils.WebManifest{},
+			manifestURL: "https://example.com/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://example.com",
+		},
+
+		// Start URL and scope are resolved against the manifest URL
+		{
+			name:        "relative start url",
+			manifest:    utils.WebManifest{StartURL: "/app/?source=pwa", Scope: "/app/"},
+			manifestURL: "https://example.com/static/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://example.com/app/?source=pwa",
+			wantScope:   []string{"https://example.com/app/"},
+		},
+		{
+			name:        "start url relative to the manifest directory",
+			manifest:    utils.WebManifest{StartURL: "./", Scope: "."},
+			manifestURL: "https://example.com/static/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://example.com/static/",
+			wantScope:   []string{"https://example.com/static/"},
+		},
+		{
+			name:        "absolute start url of the same origin",
+			manifest:    utils.WebManifest{StartURL: "https://EXAMPLE.com/inbox"},
+			manifestURL: "https://example.com/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://EXAMPLE.com/inbox",
+		},
+
+		// Members of another origin are ignored
+		{
+			name:        "cross-origin start url and scope",
+			manifest:    utils.WebManifest{StartURL: "https://evil.example.net/", Scope: "https://evil.example.net/"},
+			manifestURL: "https://example.com/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://example.com",
+		},
+		{
+			name:        "start url with another scheme",
+			manifest:    utils.WebManifest{StartURL: "http://example.com/"},
+			manifestURL: "https://example.com/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://example.com",
+		},
+		{
+			name:        "relative start url of a manifest on another origin",
+			manifest:    utils.WebManifest{StartURL: "/", Scope: "/"},
+			manifestURL: "https://cdn.example.net/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://example.com",
+		},
+		{
+			name:        "start url with an unsupported scheme",
+			manifest:    utils.WebManifest{StartURL: "javascript:alert(1)"},
+			manifestURL: "https://example.com/manifest.json",
+			wantTitle:   "example.com",
+			wantURL:     "https://example.com",
+		},
+	}
+	for _, tt := range tests {
+		manifestURL, err := url.Parse(tt.manifestURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		site := CreateSiteFromURL("example.com", "https://example.com")
+		ApplyManifest(&site, &tt.manifest, manifestURL)
+		if site.Title != tt.wantTitle {
+			t.Errorf("%s: title %q, want %q", tt.name, site.Title, tt.wantTitle)
+		}
+		if site.URL != tt.wantURL {
+			t.Errorf("%s: URL %s, want %s", tt.name, site.URL, tt.wantURL)
+		}
+		if !reflect.DeepEqual(site.Scope, tt.wantScope) {
+			t.Errorf("%s: scope %v, want %v", tt.name, site.Scope, tt.wantScope)
+		}
+		if site.Name != "example.com" || !site.IsActive {
+			t.Errorf("%s: name or active state changed: %+v", tt.name, site)
+		}
+	}
+}
+
+func TestApplyManifestAppearance(t *testing.T) {
+	site := CreateSiteFromURL("example.com", "https://example.com")
+	ApplyManifest(&site, &utils.WebManifest{ThemeColor: "#1a73e8", BackgroundColor: "#ffffff", Display: "standalone"}, nil)
+	if site.ThemeColor != "#1a73e8" || site.BackgroundColor != "#ffffff" || site.Display != "standalone" {
+		t.Errorf("appearance stored as %q, %q, %q", site.ThemeColor, site.BackgroundColor, site.Display)
+	}
+}
+
+// TestManifestFromStartPage creates a site from a start page and the manifest it links, without a network
+func TestManifestFromStartPage(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/login?next=/")
+	document := `<html><head>
+		<link rel="manifest" href="/static/app.webmanifest">
+		<link rel="icon" href="/favicon.ico">
+	</head></html>`
+
+	var loaded []string
+	page := utils.ParseStartPage(document, pageURL, func(manifestURL string) (*utils.WebManifest, error) {
+		loaded = append(loaded, manifestURL)
+		return utils.ParseManifest([]byte(`{
+			"name": "Example",
+			"short_name": "Ex",
+			"start_url": "../inbox",
+			"scope": "/",
+			"display": "standalone",
+			"theme_color": "#000000",
+			"icons": [{"src": "icons/512.png", "sizes": "512x512", "type": "image/png"}]
+		}`))
+	})
+	if want := []string{"https://example.com/static/app.webmanifest"}; !reflect.DeepEqual(loaded, want) {
+		t.Fatalf("loaded manifests %v, want %v", loaded, want)
+	}
+
+	site := CreateSiteFromURL("example.com", "https://example.com")
+	ApplyManifest(&site, page.Manifest, page.ManifestURL)
+	want := Site{
+		Name:       "example.com",
+		Title:      "Example",
+		URL:        "https://example.com/inbox",
+		IsActive:   true,
+		ThemeColor: "#000000",
+		Display:    "standalone",
+		Scope:      []string{"https://example.com/"},
+	}
+	if !reflect.DeepEqual(site, want) {
+		t.Errorf("site %+v, want %+v", site, want)
+	}
+	if icon, err := page.BestIcon(); err != nil || icon != "https://example.com/static/icons/512.png" {
+		t.Errorf("best icon %s, %v", icon, err)
+	}
+
+	// Pages whose manifest can't be loaded keep the site as created from the URL
+	page = utils.ParseStartPage(document, pageURL, func(string) (*utils.WebManifest, error) {
+		return nil, errors.New("not found")
+	})
+	if page.Manifest != nil {
+		t.Errorf("manifest %+v from a failed load", page.Manifest)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Migration upgrades a sites.json document from one version to the next
+type Migration func(raw rawDocument) error
+
+// migrations holds the registered migrations keyed by the version they upgrade from
+var migrations = map[int]Migration{}
+
+func init() {
+	RegisterMigration(0, migrateLegacyArray)
+	RegisterMigration(1, migrateSharedProfile)
+	RegisterMigration(2, migratePhysicalSizes)
+}
+
+// RegisterMigration registers a migration that upgrades documents from the given version
+func RegisterMigration(from int, migration Migration) {
+	if _, exists := migrations[from]; exists {
+		panic(fmt.Sprintf("migration from sites.json version %d is already registered", from))
+	}
+	migrations[from] = migration
+}
+
+// migrate upgrades a document from the given version to the current version
+func migrate(raw rawDocument, version int) error {
+	for ; version < CurrentVersion; version++ {
+		migration, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from sites.json version %d", version)
+		}
+		if err := migration(raw); err != nil {
+			return fmt.Errorf("failed to migrate sites.json from version %d: %v", version, err)
+		}
+		raw["version"] = version + 1
+	}
+	return nil
+}
+
+// migrateLegacyArray upgrades a bare array of sites to a versioned document
+func migrateLegacyArray(raw rawDocument) error {
+	if _, ok := raw["settings"]; !ok {
+		raw["settings"] = map[string]any{}
+	}
+	if raw["sites"] == nil {
+		raw["sites"] = []any{}
+	}
+	return nil
+}
+
+// migrateSharedProfile marks existing sites to copy the shared WebView profile into their own profile
+func migrateSharedProfile(raw rawDocument) error {
+	settings, ok := raw["settings"].(map[string]any)
+	if !ok {
+		settings = map[string]any{}
+		raw["settings"] = settings
+	}
+
+	var names []any
+	sites, _ := raw["sites"].([]any)
+	for _, site := range sites {
+		if site, ok := site.(map[string]any); ok {
+			if name, ok := site["name"].(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) > 0 {
+		settings["pending_profile_migrations"] = names
+	}
+	return nil
+}
+
+// migratePhysicalSizes marks sites with saved window sizes for conversion to DPI-independent pixels.
+// Older versions saved sizes in physical pixels at the system DPI, which is only known on the display,
+// so the app converts them on its next launch.
+func migratePhysicalSizes(raw rawDocument) error {
+	settings, ok := raw["settings"].(map[string]any)
+	if !ok {
+		settings = map[string]any{}
+		raw["settings"] = settings
+	}
+
+	var names []any
+	sites, _ := raw["sites"].([]any)
+	for _, site := range sites {
+		if site, ok := site.(map[string]any); ok {
+			if name, ok := site["name"].(string); ok && name != "" && hasSavedSize(site) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) > 0 {
+		settings["pending_size_conversions"] = names
+	}
+	return nil
+}
+
+// hasSavedSize reports whether a site or one of its profiles has a saved window size
+func hasSavedSize(site map[string]any) bool {
+	if site["width"] != nil || site["height"] != nil {
+		return true
+	}
+	profiles, _ := site["profiles"].(map[string]any)
+	for _, profile := range profiles {
+		if profile, ok := profile.(map[string]any); ok && (profile["width"] != nil || profile["height"] != nil) {
+			return true
+		}
+	}
+	return false
+}
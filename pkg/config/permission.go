@@ -0,0 +1,41 @@
+package config
+
+import "github.com/kemalersin/hobaa/pkg/notify"
+
+// NotificationPermission returns whether the site may show notifications
+func (s *Site) NotificationPermission() notify.Permission {
+	p, err := notify.ParsePermission(string(s.Notifications))
+	if err != nil {
+		return notify.PermissionDefault
+	}
+	return p
+}
+
+// SetNotificationPermission stores the notification decision of a site, clearing it for the default
+func (c *SiteConfig) SetNotificationPermission(name string, p notify.Permission) {
+	if site := c.GetSiteByName(name); site != nil {
+		if p == notify.PermissionDefault {
+			p = ""
+		}
+		site.Notifications = p
+	}
+}
+
+// NotificationPermission returns whether the site with the given name may show notifications
+func (s *Store) NotificationPermission(name string) notify.Permission {
+	site, ok := s.Site(name)
+	if !ok {
+		return notify.PermissionDefault
+	}
+	return site.NotificationPermission()
+}
+
+// SetNotificationPermission stores the notification decision of a site and writes it right away
+func (s *Store) SetNotificationPermission(name string, p notify.Permission) error {
+	if _, err := notify.ParsePermission(string(p)); err != nil {
+		return err
+	}
+	return s.UpdateNow(func(c *SiteConfig) {
+		c.SetNotificationPermission(name, p)
+	})
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/notify"
+)
+
+func TestNotificationPermissionPerSite(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	c := NewSiteConfig("")
+	c.AddSite(Site{Name: "gmail", URL: "https://mail.google.com"})
+	c.AddSite(Site{Name: "slack", URL: "https://app.slack.com"})
+	c.AddSite(Site{Name: "old", URL: "https://old.example.com", Notifications: "maybe"})
+	if err := c.SaveToFile(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewStore(filePath, DefaultSaveDelay)
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetNotificationPermission("gmail", notify.PermissionGranted); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetNotificationPermission("slack", "yes"); err == nil {
+		t.Error("invalid permission was stored")
+	}
+
+	// Decisions are written right away and kept per site
+	reloaded := NewStore(filePath, DefaultSaveDelay)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		site string
+		want notify.Permission
+	}{
+		{"gmail", notify.PermissionGranted},
+		{"slack", notify.PermissionDefault},
+		{"old", notify.PermissionDefault}, // Invalid values count as no decision
+		{"missing", notify.PermissionDefault},
+	}
+	for _, tt := range tests {
+		if got := reloaded.NotificationPermission(tt.site); got != tt.want {
+			t.Errorf("%s: permission %s, want %s", tt.site, got, tt.want)
+		}
+	}
+
+	// Resetting to the default removes the decision from sites.json
+	if err := reloaded.SetNotificationPermission("gmail", notify.PermissionDefault); err != nil {
+		t.Fatal(err)
+	}
+	if site, _ := reloaded.Site("gmail"); site.Notifications != "" {
+		t.Errorf("default permission stored as %q", site.Notifications)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Lock timing. Locks are held only while a file is read and rewritten. A lock whose owner process has exited
+// is reclaimed right away; a lock whose owner can't be checked, such as one left without its owner or by
+// another computer, is only reclaimed once it is older than any write could take.
+const (
+	lockTimeout    = 10 * time.Second
+	lockRetryDelay = 10 * time.Millisecond
+	staleLockAge   = 2 * time.Minute
+)
+
+// GetLockPath returns the path of the advisory lock file guarding a sites.json file
+func GetLockPath(filePath string) string {
+	return filePath + ".lock"
+}
+
+// lockFile acquires the advisory lock for a file shared by several processes.
+// The lock is a file created exclusively next to the guarded file and holding a token naming its owner
+// process and computer, so locks left behind by crashed processes can be reclaimed.
+func lockFile(filePath string) (func(), error) {
+	lockPath := GetLockPath(filePath)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, err
+	}
+	token := fmt.Sprintf("%d-%d@%s", os.Getpid(), lockCounter.Add(1), lockHost)
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		// Try to create the lock file
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = file.WriteString(token)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, err
+			}
+			return func() { unlockFile(lockPath, token) }, nil
+		}
+		if !os.IsExist(err) && !os.IsPermission(err) {
+			return nil, err
+		}
+
+		// Reclaim a stale lock
+		if lockIsStale(lockPath) {
+			reclaimStaleLock(lockPath, token)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", lockPath)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// lockCounter makes the tokens of locks taken by one process unique
+var lockCounter atomic.Int64
+
+// lockHost names this computer in lock tokens, since process IDs of other computers sharing the file can't be checked
+var lockHost, _ = os.Hostname()
+
+// lockOwner returns the process ID and computer in a lock token
+func lockOwner(token string) (pid int, host string, ok bool) {
+	owner, host, found := strings.Cut(token, "@")
+	if !found {
+		return 0, "", false
+	}
+	id, _, _ := strings.Cut(owner, "-")
+	pid, err := strconv.Atoi(id)
+	if err != nil || pid <= 0 {
+		return 0, "", false
+	}
+	return pid, host, true
+}
+
+// lockIsStale reports whether a lock file was left behind: its owner process on this computer has exited,
+// or its owner can't be checked and the lock is older than staleLockAge
+func lockIsStale(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		return false
+	}
+	if data, err := os.ReadFile(lockPath); err == nil {
+		if pid, host, ok := lockOwner(string(data)); ok && host == lockHost && !processRunning(pid) {
+			return true
+		}
+	}
+	return time.Since(info.ModTime()) > staleLockAge
+}
+
+// unlockFile removes a lock if it still belongs to the token, so an owner whose lock was
+// reclaimed doesn't remove the lock of the next owner
+func unlockFile(lockPath, token string) {
+	if data, err := os.ReadFile(lockPath); err == nil && string(data) == token {
+		os.Remove(lockPath)
+	}
+}
+
+// reclaimStaleLock removes a stale lock. Several waiters may find the same stale lock, and removing it
+// by path could remove a lock another waiter has just taken, so the lock is first renamed to a name only
+// this waiter uses and checked again there. A live lock taken by mistake is put back unless a new one exists.
+func reclaimStaleLock(lockPath, token string) {
+	claimed := lockPath + "." + strings.ReplaceAll(token, "@", ".") + ".stale"
+	if err := os.Rename(lockPath, claimed); err != nil {
+		return
+	}
+	defer os.Remove(claimed)
+
+	if !lockIsStale(claimed) {
+		os.Link(claimed, lockPath)
+	}
+}
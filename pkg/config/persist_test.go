@@ -0,0 +1,277 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Stress test size: writers each increment the width of their own site this many times
+const (
+	stressWriters = 16
+	stressUpdates = 25
+)
+
+// incrementWidth adds one to the width of a site in a sites.json file, adding the site if needed
+func incrementWidth(filePath, name string) error {
+	return NewSiteConfig("").Update(filePath, func(c *SiteConfig) error {
+		site := c.GetSiteByName(name)
+		if site == nil {
+			c.AddSite(Site{Name: name, URL: "https://" + name + ".example.com"})
+			site = c.GetSiteByName(name)
+		}
+		site.Width++
+		return nil
+	})
+}
+
+// checkWidths checks that no update of any writer was lost
+func checkWidths(t *testing.T, filePath string, writers, updates int) {
+	t.Helper()
+	c := NewSiteConfig("")
+	if err := c.LoadFromFile(filePath); err != nil {
+		t.Fatalf("sites.json is unreadable after concurrent writes: %v", err)
+	}
+	if len(c.Sites) != writers {
+		t.Errorf("got %d sites, want %d", len(c.Sites), writers)
+	}
+	for i := 0; i < writers; i++ {
+		name := fmt.Sprintf("site%d", i)
+		if site := c.GetSiteByName(name); site == nil || site.Width != updates {
+			t.Errorf("%s: got %+v, want width %d", name, site, updates)
+		}
+	}
+	if _, err := os.Stat(GetLockPath(filePath)); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, stressWriters*stressUpdates)
+	for i := 0; i < stressWriters; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			for j := 0; j < stressUpdates; j++ {
+				if err := incrementWidth(filePath, name); err != nil {
+					errs <- err
+				}
+			}
+		}(fmt.Sprintf("site%d", i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("update failed: %v", err)
+	}
+
+	checkWidths(t, filePath, stressWriters, stressUpdates)
+}
+
+// Environment variables running the test binary as a writer process
+const (
+	writerFileEnv = "HOBAA_TEST_WRITER_FILE"
+	writerNameEnv = "HOBAA_TEST_WRITER_NAME"
+)
+
+func TestConcurrentWriterProcesses(t *testing.T) {
+	// Run as a writer started by this test
+	if filePath := os.Getenv(writerFileEnv); filePath != "" {
+		for j := 0; j < stressUpdates; j++ {
+			if err := incrementWidth(filePath, os.Getenv(writerNameEnv)); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+	if testing.Short() {
+		t.Skip("starts several processes")
+	}
+
+	// Start writer processes together
+	const processes = 4
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	cmds := make([]*exec.Cmd, processes)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentWriterProcesses$")
+		cmd.Env = append(os.Environ(), writerFileEnv+"="+filePath, writerNameEnv+"=site"+strconv.Itoa(i))
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[i] = cmd
+	}
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("writer %d failed: %v", i, err)
+		}
+	}
+
+	checkWidths(t, filePath, processes, stressUpdates)
+}
+
+func TestStaleLockReclaimed(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	lockPath := GetLockPath(filePath)
+
+	// Leave a lock behind as a crashed process would
+	if err := os.WriteFile(lockPath, []byte("crashed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Waiters racing to reclaim it must still hold the lock one at a time
+	var holders, maxHolders int32
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockFile(filePath)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := atomic.AddInt32(&holders, 1)
+			for {
+				m := atomic.LoadInt32(&maxHolders)
+				if n <= m || atomic.CompareAndSwapInt32(&maxHolders, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&holders, -1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+
+	if maxHolders != 1 {
+		t.Errorf("%d waiters held the lock at once", maxHolders)
+	}
+	if elapsed := time.Since(start); elapsed > staleLockAge {
+		t.Errorf("reclaiming the stale lock took %v", elapsed)
+	}
+	if matches, _ := filepath.Glob(lockPath + "*"); len(matches) != 0 {
+		t.Errorf("lock files left behind: %v", matches)
+	}
+}
+
+// exitedProcess returns the ID of a process that has exited
+func exitedProcess(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestLockOfExitedProcessReclaimed(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	token := fmt.Sprintf("%d-1@%s", exitedProcess(t), lockHost)
+	if err := os.WriteFile(GetLockPath(filePath), []byte(token), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock is fresh, but its owner is gone
+	start := time.Now()
+	unlock, err := lockFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("reclaiming the lock of an exited process took %v", elapsed)
+	}
+}
+
+func TestLockIsStale(t *testing.T) {
+	dir := t.TempDir()
+	exited := exitedProcess(t)
+	tests := []struct {
+		name  string
+		token string
+		age   time.Duration
+		want  bool
+	}{
+		{"running owner", fmt.Sprintf("%d-1@%s", os.Getpid(), lockHost), 0, false},
+		{"running owner held longer than any write", fmt.Sprintf("%d-1@%s", os.Getpid(), lockHost), 10 * time.Second, false},
+		{"exited owner", fmt.Sprintf("%d-1@%s", exited, lockHost), 0, true},
+		{"owner on another computer", fmt.Sprintf("%d-1@%s", exited, lockHost+"-other"), 10 * time.Second, false},
+		{"owner not written yet", "", 0, false},
+		{"unreadable owner", "crashed", 10 * time.Second, false},
+		{"unreadable owner left for long", "crashed", 2 * staleLockAge, true},
+		{"running owner left for long", fmt.Sprintf("%d-1@%s", os.Getpid(), lockHost), 2 * staleLockAge, true},
+	}
+	for i, tt := range tests {
+		lockPath := filepath.Join(dir, strconv.Itoa(i)+".lock")
+		if err := os.WriteFile(lockPath, []byte(tt.token), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modified := time.Now().Add(-tt.age)
+		if err := os.Chtimes(lockPath, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+		if got := lockIsStale(lockPath); got != tt.want {
+			t.Errorf("%s: stale = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if lockIsStale(filepath.Join(dir, "missing.lock")) {
+		t.Error("missing lock is stale")
+	}
+}
+
+func TestLockOwner(t *testing.T) {
+	tests := []struct {
+		token string
+		pid   int
+		host  string
+		ok    bool
+	}{
+		{"1234-5@desktop", 1234, "desktop", true},
+		{"1234-5@", 1234, "", true},
+		{"1234-5", 0, "", false},
+		{"abc-5@desktop", 0, "", false},
+		{"0-1@desktop", 0, "", false},
+		{"", 0, "", false},
+	}
+	for _, tt := range tests {
+		pid, host, ok := lockOwner(tt.token)
+		if pid != tt.pid || host != tt.host || ok != tt.ok {
+			t.Errorf("lockOwner(%q) = %d, %q, %v, want %d, %q, %v", tt.token, pid, host, ok, tt.pid, tt.host, tt.ok)
+		}
+	}
+}
+
+func TestUnlockKeepsReclaimedLock(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	unlock, err := lockFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process reclaimed the lock and took it
+	if err := os.WriteFile(GetLockPath(filePath), []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+
+	if data, err := os.ReadFile(GetLockPath(filePath)); err != nil || string(data) != "other" {
+		t.Errorf("lock of the new owner was removed: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package config
+
+import "syscall"
+
+// processRunning reports whether a process with the ID is running
+func processRunning(pid int) bool {
+	// Signal 0 only checks that the process exists; processes of other users refuse it but exist
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,26 @@
+package config
+
+import "syscall"
+
+// Process access and exit code constants
+const (
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+	errorAccessDenied              = syscall.Errno(5)
+)
+
+// processRunning reports whether a process with the ID is running
+func processRunning(pid int) bool {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// Processes of other users can't be opened but exist
+		return err == errorAccessDenied
+	}
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Profile directory names
+const (
+	// ProfilesDirName is the directory inside AppData holding the WebView profiles
+	ProfilesDirName = "profiles"
+
+	// SharedProfile is the profile name that selects the legacy profile shared by all sites
+	SharedProfile = "shared"
+
+	// WebViewDataDirName is the directory WebView2 creates inside its user data folder
+	WebViewDataDirName = "EBWebView"
+)
+
+// ProfileSeparator separates the site name from a named profile in EXE names, e.g. gmail@work
+const ProfileSeparator = "@"
+
+// SiteProfile holds the settings of a named profile of a site
+type SiteProfile struct {
+	Width       int             `json:"width,omitempty"`
+	Height      int             `json:"height,omitempty"`
+	Window      *WindowPosition `json:"window,omitempty"`
+	TitleSuffix string          `json:"title_suffix,omitempty"`
+}
+
+// ParseExecName splits an EXE name into a site name and an optional profile name
+func ParseExecName(execName string) (string, string) {
+	i := strings.LastIndex(execName, ProfileSeparator)
+	if i <= 0 || i == len(execName)-len(ProfileSeparator) {
+		return execName, ""
+	}
+	return execName[:i], execName[i+len(ProfileSeparator):]
+}
+
+// ProfileName returns the name of the WebView profile used by the site
+func (s *Site) ProfileName() string {
+	if s.Profile != "" {
+		return s.Profile
+	}
+	return s.Name
+}
+
+// NamedProfileName returns the name of the WebView profile used by a named profile of the site
+func (s *Site) NamedProfileName(profile string) string {
+	if profile == "" {
+		return s.ProfileName()
+	}
+	return s.ProfileName() + ProfileSeparator + profile
+}
+
+// WindowSize returns the saved window size of a profile, falling back to the size of the site
+func (s *Site) WindowSize(profile string) (int, int) {
+	if p, ok := s.Profiles[profile]; ok && profile != "" && p.Width > 0 && p.Height > 0 {
+		return p.Width, p.Height
+	}
+	return s.Width, s.Height
+}
+
+// SetWindowSize saves the window size of a profile, or of the site if no profile is given
+func (s *Site) SetWindowSize(profile string, width, height int) {
+	if profile == "" {
+		s.Width = width
+		s.Height = height
+		return
+	}
+
+	p := s.Profiles[profile]
+	p.Width = width
+	p.Height = height
+	s.setProfile(profile, p)
+}
+
+// TitleSuffix returns the suffix added to the window title of a profile.
+// It may be called on a nil site, which returns the default suffix.
+func (s *Site) TitleSuffix(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	if p, ok := s.profile(profile); ok && p.TitleSuffix != "" {
+		return p.TitleSuffix
+	}
+	return " (" + profile + ")"
+}
+
+// profile returns the settings of a named profile
+func (s *Site) profile(profile string) (SiteProfile, bool) {
+	if s == nil {
+		return SiteProfile{}, false
+	}
+	p, ok := s.Profiles[profile]
+	return p, ok
+}
+
+// setProfile stores the settings of a named profile
+func (s *Site) setProfile(profile string, p SiteProfile) {
+	if s.Profiles == nil {
+		s.Profiles = map[string]SiteProfile{}
+	}
+	s.Profiles[profile] = p
+}
+
+// GetProfileDir returns the WebView data directory for a profile
+func GetProfileDir(appDataDir, profile string) string {
+	if profile == SharedProfile {
+		return appDataDir
+	}
+	return filepath.Join(appDataDir, ProfilesDirName, sanitizeProfileName(profile))
+}
+
+// GetSharedProfileDataPath returns the path of the WebView data of the legacy shared profile
+func GetSharedProfileDataPath(appDataDir string) string {
+	return filepath.Join(appDataDir, WebViewDataDirName)
+}
+
+// HasPendingProfileMigration reports whether the site still has to copy the legacy shared profile
+func (s Settings) HasPendingProfileMigration(name string) bool {
+	for _, pending := range s.PendingProfileMigrations {
+		if pending == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CompleteProfileMigration removes the site from the pending profile migrations
+func (c *SiteConfig) CompleteProfileMigration(name string) {
+	var pending []string
+	for _, n := range c.Settings.PendingProfileMigrations {
+		if n != name {
+			pending = append(pending, n)
+		}
+	}
+	c.Settings.PendingProfileMigrations = pending
+}
+
+// sanitizeProfileName makes a profile name safe to use as a directory name
+func sanitizeProfileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
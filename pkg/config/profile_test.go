@@ -0,0 +1,132 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseExecName(t *testing.T) {
+	tests := []struct {
+		execName string
+		site     string
+		profile  string
+	}{
+		{"gmail", "gmail", ""},
+		{"gmail@work", "gmail", "work"},
+		{"example.com@work", "example.com", "work"},
+		{"gmail@Work Account", "gmail", "Work Account"},
+
+		// The last separator splits, so site names may contain it
+		{"me@example.com@work", "me@example.com", "work"},
+
+		// Empty site or profile names don't split
+		{"gmail@", "gmail@", ""},
+		{"@work", "@work", ""},
+		{"@", "@", ""},
+		{"", "", ""},
+
+		// Profile names are kept as written, directory names are made safe separately
+		{"gmail@wo:rk", "gmail", "wo:rk"},
+		{"gmail@..", "gmail", ".."},
+	}
+	for _, tt := range tests {
+		site, profile := ParseExecName(tt.execName)
+		if site != tt.site || profile != tt.profile {
+			t.Errorf("ParseExecName(%q) = %q, %q, want %q, %q", tt.execName, site, profile, tt.site, tt.profile)
+		}
+	}
+}
+
+func TestProfileDirs(t *testing.T) {
+	appData := filepath.Join("AppData", "Hobaa")
+	tests := []struct {
+		site    Site
+		profile string
+		want    string
+	}{
+		{Site{Name: "gmail"}, "", filepath.Join(appData, "profiles", "gmail")},
+		{Site{Name: "gmail"}, "work", filepath.Join(appData, "profiles", "gmail@work")},
+		{Site{Name: "calendar", Profile: "google"}, "work", filepath.Join(appData, "profiles", "google@work")},
+		{Site{Name: "legacy", Profile: SharedProfile}, "", appData},
+
+		// Invalid characters can't leave the profiles directory
+		{Site{Name: "gmail"}, "wo:rk", filepath.Join(appData, "profiles", "gmail@wo_rk")},
+		{Site{Name: "gmail"}, `..\..\x`, filepath.Join(appData, "profiles", "gmail@.._.._x")},
+		{Site{Name: "gmail"}, "..", filepath.Join(appData, "profiles", "gmail@")},
+		{Site{Name: ".."}, "", filepath.Join(appData, "profiles", "_")},
+		{Site{Name: "a\x00b"}, "", filepath.Join(appData, "profiles", "a_b")},
+	}
+	for _, tt := range tests {
+		if got := GetProfileDir(appData, tt.site.NamedProfileName(tt.profile)); got != tt.want {
+			t.Errorf("%s profile %q: directory %s, want %s", tt.site.Name, tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestWindowSize(t *testing.T) {
+	site := Site{
+		Name:   "gmail",
+		Width:  1200,
+		Height: 800,
+		Profiles: map[string]SiteProfile{
+			"work":    {Width: 1600, Height: 900},
+			"partial": {Width: 1000},
+			"":        {Width: 10, Height: 10},
+		},
+	}
+	tests := []struct {
+		profile       string
+		width, height int
+	}{
+		{"", 1200, 800},
+		{"work", 1600, 900},
+		{"partial", 1200, 800}, // Incomplete sizes fall back to the site
+		{"home", 1200, 800},
+	}
+	for _, tt := range tests {
+		if width, height := site.WindowSize(tt.profile); width != tt.width || height != tt.height {
+			t.Errorf("WindowSize(%q) = %dx%d, want %dx%d", tt.profile, width, height, tt.width, tt.height)
+		}
+	}
+
+	// Sizes are saved to the profile, or to the site without one
+	fresh := Site{Name: "slack", Width: 1200, Height: 800}
+	fresh.SetWindowSize("work", 1400, 700)
+	if width, height := fresh.WindowSize("work"); width != 1400 || height != 700 {
+		t.Errorf("saved profile size is %dx%d", width, height)
+	}
+	if fresh.Width != 1200 || fresh.Height != 800 {
+		t.Errorf("saving a profile size changed the site size to %dx%d", fresh.Width, fresh.Height)
+	}
+	fresh.SetWindowSize("", 1000, 600)
+	if width, height := fresh.WindowSize(""); width != 1000 || height != 600 {
+		t.Errorf("saved site size is %dx%d", width, height)
+	}
+}
+
+func TestTitleSuffix(t *testing.T) {
+	site := &Site{
+		Name: "gmail",
+		Profiles: map[string]SiteProfile{
+			"work": {TitleSuffix: " — Work"},
+			"home": {Width: 800, Height: 600},
+		},
+	}
+	tests := []struct {
+		site    *Site
+		profile string
+		want    string
+	}{
+		{site, "", ""},
+		{site, "work", " — Work"},
+		{site, "home", " (home)"},
+		{site, "other", " (other)"},
+		{nil, "work", " (work)"},
+		{nil, "", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.site.TitleSuffix(tt.profile); got != tt.want {
+			t.Errorf("TitleSuffix(%q) = %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+}
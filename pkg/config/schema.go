@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// CurrentVersion is the sites.json schema version written by this build
+const CurrentVersion = 3
+
+// ErrNewerVersion is returned when saving over a sites.json written by a newer version of Hobaa
+var ErrNewerVersion = errors.New("sites.json was written by a newer version of Hobaa")
+
+// Settings holds application-wide settings stored in sites.json
+type Settings struct {
+	// PendingProfileMigrations lists sites that still have to copy the legacy shared WebView profile
+	PendingProfileMigrations []string `json:"pending_profile_migrations,omitempty"`
+
+	// PendingSizeConversions lists sites whose window sizes are still in physical pixels at the system DPI,
+	// as saved before version 3
+	PendingSizeConversions []string `json:"pending_size_conversions,omitempty"`
+}
+
+// Clone returns a copy of the settings that doesn't share slices with the original
+func (s Settings) Clone() Settings {
+	s.PendingProfileMigrations = append([]string(nil), s.PendingProfileMigrations...)
+	s.PendingSizeConversions = append([]string(nil), s.PendingSizeConversions...)
+	return s
+}
+
+// document represents a versioned sites.json file
+type document struct {
+	Version  int      `json:"version"`
+	Settings Settings `json:"settings"`
+	Sites    []Site   `json:"sites"`
+}
+
+// rawDocument is a sites.json document as generic JSON, used by migrations
+type rawDocument map[string]any
+
+// parseDocument parses a sites.json document of any version, upgrading it to the current version.
+// It returns the document with the version it was stored in; legacy arrays of sites are version 0.
+// Newer documents are read as far as this version understands them, but saving over them is refused.
+func parseDocument(data []byte) (*document, int, error) {
+	raw, version, err := decodeRawDocument(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Upgrade older documents
+	if err := migrate(raw, version); err != nil {
+		return nil, version, err
+	}
+
+	// Convert generic JSON to the document
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, version, err
+	}
+	doc := &document{}
+	if err := json.Unmarshal(upgraded, doc); err != nil {
+		return nil, version, err
+	}
+	if doc.Sites == nil {
+		doc.Sites = []Site{}
+	}
+
+	return doc, version, nil
+}
+
+// decodeRawDocument decodes a sites.json document as generic JSON and returns its version
+func decodeRawDocument(data []byte) (rawDocument, int, error) {
+	data = bytes.TrimSpace(data)
+
+	// Legacy files contain a bare array of sites
+	if len(data) > 0 && data[0] == '[' {
+		var sites []any
+		if err := json.Unmarshal(data, &sites); err != nil {
+			return nil, 0, err
+		}
+		return rawDocument{"sites": sites}, 0, nil
+	}
+
+	var raw rawDocument
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, err
+	}
+	if raw == nil {
+		return nil, 0, fmt.Errorf("sites.json document is empty")
+	}
+
+	// Get version, which JSON decodes as a number
+	version, ok := raw["version"].(float64)
+	if !ok || version < 1 || version != float64(int(version)) {
+		return nil, 0, fmt.Errorf("sites.json has an invalid version")
+	}
+
+	return raw, int(version), nil
+}
+
+// marshalDocument encodes the sites and settings as a current version document
+func marshalDocument(settings Settings, sites []Site) ([]byte, error) {
+	return json.MarshalIndent(document{
+		Version:  CurrentVersion,
+		Settings: settings,
+		Sites:    sites,
+	}, "", "  ")
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseLegacyArray(t *testing.T) {
+	doc, version, err := parseDocument([]byte(`  [
+		{"name": "gmail", "title": "Gmail", "url": "https://mail.google.com", "width": 1200, "height": 800, "is_active": true},
+		{"name": "slack", "title": "Slack", "url": "https://app.slack.com"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Errorf("legacy array has version %d, want 0", version)
+	}
+	if len(doc.Sites) != 2 || doc.Sites[0].Name != "gmail" || doc.Sites[0].Width != 1200 || !doc.Sites[0].IsActive {
+		t.Errorf("sites %+v", doc.Sites)
+	}
+
+	// Every migration ran: sites copy the shared profile and sized windows are converted
+	if want := []string{"gmail", "slack"}; !reflect.DeepEqual(doc.Settings.PendingProfileMigrations, want) {
+		t.Errorf("pending profile migrations %v, want %v", doc.Settings.PendingProfileMigrations, want)
+	}
+	if want := []string{"gmail"}; !reflect.DeepEqual(doc.Settings.PendingSizeConversions, want) {
+		t.Errorf("pending size conversions %v, want %v", doc.Settings.PendingSizeConversions, want)
+	}
+}
+
+func TestParseDocumentVersions(t *testing.T) {
+	tests := []struct {
+		data    string
+		version int
+		sites   int
+		wantErr bool
+	}{
+		{`[]`, 0, 0, false},
+		{`{"version": 1, "sites": [{"name": "gmail"}]}`, 1, 1, false},
+		{`{"version": 1}`, 1, 0, false},
+		{`{"version": 99, "sites": [{"name": "gmail", "future": true}]}`, 99, 1, false},
+
+		// Invalid documents and versions
+		{``, 0, 0, true},
+		{`not json`, 0, 0, true},
+		{`[{"name": `, 0, 0, true},
+		{`null`, 0, 0, true},
+		{`{}`, 0, 0, true},
+		{`{"sites": []}`, 0, 0, true},
+		{`{"version": 0, "sites": []}`, 0, 0, true},
+		{`{"version": -1, "sites": []}`, 0, 0, true},
+		{`{"version": 1.5, "sites": []}`, 0, 0, true},
+		{`{"version": "2", "sites": []}`, 0, 0, true},
+	}
+	for _, tt := range tests {
+		doc, version, err := parseDocument([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDocument(%s) error = %v, want error %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if version != tt.version || len(doc.Sites) != tt.sites {
+			t.Errorf("parseDocument(%s) = version %d with %d sites, want version %d with %d sites", tt.data, version, len(doc.Sites), tt.version, tt.sites)
+		}
+	}
+}
+
+func TestUpgradeWritesBackup(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	legacy := []byte(`[{"name": "gmail", "title": "Gmail", "url": "https://mail.google.com"}]`)
+	if err := os.WriteFile(filePath, legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewSiteConfig("")
+	if err := c.LoadFromFile(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// The original is kept byte for byte before the file is rewritten as the current version
+	backup, err := os.ReadFile(GetBackupPath(filePath, 0))
+	if err != nil {
+		t.Fatalf("no backup of the legacy file: %v", err)
+	}
+	if !bytes.Equal(backup, legacy) {
+		t.Errorf("backup is %s, want the original file", backup)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, version, err := decodeRawDocument(data); err != nil || version != CurrentVersion {
+		t.Errorf("upgraded file has version %d, %v, want %d", version, err, CurrentVersion)
+	}
+
+	// Loading a current file writes no further backups
+	if err := NewSiteConfig("").LoadFromFile(filePath); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := filepath.Glob(filePath + ".v*.bak")
+	if len(backups) != 1 {
+		t.Errorf("backups %v, want only the legacy one", backups)
+	}
+}
+
+func TestNewerVersionIsNotSaved(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	newer := []byte(`{"version": 99, "settings": {"future": 1}, "sites": [{"name": "gmail", "url": "https://mail.google.com", "future": true}]}`)
+	if err := os.WriteFile(filePath, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file can be read
+	c := NewSiteConfig("")
+	if err := c.LoadFromFile(filePath); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetSiteByName("gmail") == nil {
+		t.Error("site of a newer file wasn't read")
+	}
+
+	// Every way of saving refuses to replace it
+	if err := c.SaveToFile(filePath); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("SaveToFile error %v, want ErrNewerVersion", err)
+	}
+	if err := c.Update(filePath, func(c *SiteConfig) error {
+		c.SetActiveSite("gmail")
+		return nil
+	}); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Update error %v, want ErrNewerVersion", err)
+	}
+	store := NewStore(filePath, DefaultSaveDelay)
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateNow(func(c *SiteConfig) { c.SetActiveSite("gmail") }); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Store.UpdateNow error %v, want ErrNewerVersion", err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, newer) {
+		t.Errorf("newer file was rewritten as %s", data)
+	}
+}
+
+func TestRegisterMigrationTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a second migration from version 0 didn't panic")
+		}
+	}()
+	RegisterMigration(0, migrateLegacyArray)
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	for version := 0; version < CurrentVersion; version++ {
+		if _, ok := migrations[version]; !ok {
+			t.Errorf("no migration from version %d", version)
+		}
+	}
+	if _, ok := migrations[CurrentVersion]; ok {
+		t.Errorf("migration from the current version %d is registered", CurrentVersion)
+	}
+}
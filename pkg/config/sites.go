@@ -2,26 +2,78 @@
 package config
 
 import (
-	"encoding/json"
-	"github.com/kemalersin/hobaa/pkg/utils"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+
+	"github.com/kemalersin/hobaa/pkg/notify"
+	"github.com/kemalersin/hobaa/pkg/utils"
 )
 
 // Site represents a website configuration
 type Site struct {
-	Name     string `json:"name"`
-	Title    string `json:"title"`
-	URL      string `json:"url"`
-	Width    int    `json:"width,omitempty"`
-	Height   int    `json:"height,omitempty"`
-	Icon     string `json:"icon,omitempty"`
-	IsActive bool   `json:"is_active,omitempty"`
+	Name            string   `json:"name"`
+	Title           string   `json:"title"`
+	URL             string   `json:"url"`
+	Width           int      `json:"width,omitempty"`
+	Height          int      `json:"height,omitempty"`
+	Icon            string   `json:"icon,omitempty"`
+	IsActive        bool     `json:"is_active,omitempty"`
+	ThemeColor      string   `json:"theme_color,omitempty"`
+	BackgroundColor string   `json:"background_color,omitempty"`
+	Display         string   `json:"display,omitempty"`
+	Scope           []string `json:"scope,omitempty"`
+	Profile         string   `json:"profile,omitempty"`
+	Executable      string   `json:"executable,omitempty"`
+
+	// Notifications is the decision of the user on showing notifications of the site
+	Notifications notify.Permission `json:"notifications,omitempty"`
+
+	// DownloadDir is where downloads of the site are saved, relative paths are inside the Downloads folder
+	DownloadDir string `json:"download_dir,omitempty"`
+
+	// TitleTemplate renders the window title from the page title, e.g. "{{.PageTitle}} — {{.SiteTitle}}"
+	TitleTemplate string `json:"title_template,omitempty"`
+
+	// Badge extracts an unread count from the page to draw over the window icon
+	Badge *BadgeRule `json:"badge,omitempty"`
+
+	Window   *WindowPosition        `json:"window,omitempty"`
+	Profiles map[string]SiteProfile `json:"profiles,omitempty"`
+}
+
+// Clone returns a copy of the site that doesn't share maps or pointers with the original
+func (s Site) Clone() Site {
+	s.Window = s.Window.clone()
+	s.Scope = append([]string(nil), s.Scope...)
+	if s.Badge != nil {
+		badge := *s.Badge
+		s.Badge = &badge
+	}
+	if s.Profiles != nil {
+		profiles := make(map[string]SiteProfile, len(s.Profiles))
+		for name, profile := range s.Profiles {
+			profile.Window = profile.Window.clone()
+			profiles[name] = profile
+		}
+		s.Profiles = profiles
+	}
+	return s
+}
+
+// BadgeRule tells where a page shows its unread count.
+// Title is a regular expression matched against the page title, Selector a CSS selector of the element
+// holding the count. An empty rule matches counts in parentheses in the title.
+type BadgeRule struct {
+	Title    string `json:"title,omitempty"`
+	Selector string `json:"selector,omitempty"`
 }
 
 // SiteConfig represents the configuration for all sites
 type SiteConfig struct {
 	Sites     []Site
+	Settings  Settings
 	ConfigDir string
 }
 
@@ -43,14 +95,58 @@ func (c *SiteConfig) LoadFromFile(filePath string) error {
 		return nil // File doesn't exist, return empty config
 	}
 
+	// Lock file since it may be upgraded and rewritten
+	unlock, err := lockFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return c.loadLocked(filePath)
+}
+
+// loadLocked loads site configuration from a file while holding its lock
+func (c *SiteConfig) loadLocked(filePath string) error {
 	// Read file
 	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		c.Sites = []Site{}
+		c.Settings = Settings{}
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	// Parse JSON
-	return json.Unmarshal(data, &c.Sites)
+	// Parse JSON, upgrading older versions
+	doc, version, err := parseDocument(data)
+	if err != nil {
+		return err
+	}
+	c.Sites = doc.Sites
+	c.Settings = doc.Settings
+
+	// Rewrite upgraded files after keeping a backup of the original
+	if version < CurrentVersion {
+		if err := os.WriteFile(GetBackupPath(filePath, version), data, 0644); err != nil {
+			return err
+		}
+		return c.saveLocked(filePath)
+	}
+
+	return nil
+}
+
+// LoadFromJSON loads site configuration from JSON data of any schema version
+func (c *SiteConfig) LoadFromJSON(data []byte) error {
+	doc, _, err := parseDocument(data)
+	if err != nil {
+		return err
+	}
+
+	c.Sites = doc.Sites
+	c.Settings = doc.Settings
+	return nil
 }
 
 // LoadFromGitHub loads site configuration from GitHub
@@ -62,13 +158,13 @@ func (c *SiteConfig) LoadFromGitHub(url string) error {
 	}
 
 	// Parse JSON
-	var sites []Site
-	if err := json.Unmarshal(data, &sites); err != nil {
+	doc, _, err := parseDocument(data)
+	if err != nil {
 		return err
 	}
 
 	// Add sites to config without overwriting existing sites
-	for _, site := range sites {
+	for _, site := range doc.Sites {
 		// Check if site already exists
 		if existing := c.GetSiteByName(site.Name); existing == nil {
 			// Only add if it doesn't exist
@@ -79,22 +175,57 @@ func (c *SiteConfig) LoadFromGitHub(url string) error {
 	return nil
 }
 
-// SaveToFile saves site configuration to a file
+// SaveToFile saves site configuration to a file, replacing its contents
 func (c *SiteConfig) SaveToFile(filePath string) error {
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	unlock, err := lockFile(filePath)
+	if err != nil {
 		return err
 	}
+	defer unlock()
+
+	return c.saveLocked(filePath)
+}
+
+// Update applies changes to the configuration stored in a file.
+// The file is locked, reloaded so changes made by other processes are kept,
+// changed by the update function and atomically replaced.
+func (c *SiteConfig) Update(filePath string, update func(c *SiteConfig) error) error {
+	unlock, err := lockFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Reload current contents
+	if err := c.loadLocked(filePath); err != nil {
+		return err
+	}
+
+	// Apply changes
+	if err := update(c); err != nil {
+		return err
+	}
+
+	return c.saveLocked(filePath)
+}
+
+// saveLocked saves site configuration to a file while holding its lock.
+// Files written by a newer version are never replaced, since fields this version doesn't know would be lost.
+func (c *SiteConfig) saveLocked(filePath string) error {
+	if data, err := os.ReadFile(filePath); err == nil {
+		if _, version, err := decodeRawDocument(data); err == nil && version > CurrentVersion {
+			return fmt.Errorf("%s has version %d: %w", filePath, version, ErrNewerVersion)
+		}
+	}
 
 	// Marshal JSON
-	data, err := json.MarshalIndent(c.Sites, "", "  ")
+	data, err := marshalDocument(c.Settings, c.Sites)
 	if err != nil {
 		return err
 	}
 
 	// Write file
-	return os.WriteFile(filePath, data, 0644)
+	return utils.WriteFileAtomic(filePath, data, 0644)
 }
 
 // GetSiteByName returns a site by name
@@ -137,12 +268,39 @@ func CreateSiteFromURL(name, url string) Site {
 	}
 }
 
+// ApplyManifest fills site fields from a web app manifest located at manifestURL
+func ApplyManifest(site *Site, manifest *utils.WebManifest, manifestURL *url.URL) {
+	// Prefer the full name for the window title
+	if manifest.Name != "" {
+		site.Title = manifest.Name
+	} else if manifest.ShortName != "" {
+		site.Title = manifest.ShortName
+	}
+
+	// Start URL and scope must have the same origin as the site
+	if startURL := utils.ResolveSameOrigin(manifestURL, manifest.StartURL, site.URL); startURL != "" {
+		site.URL = startURL
+	}
+	if scope := utils.ResolveSameOrigin(manifestURL, manifest.Scope, site.URL); scope != "" {
+		site.Scope = []string{scope}
+	}
+
+	site.ThemeColor = manifest.ThemeColor
+	site.BackgroundColor = manifest.BackgroundColor
+	site.Display = manifest.Display
+}
+
 // GetAppDataSitesPath returns the path to the sites.json file in AppData
 func GetAppDataSitesPath(appDataDir string) string {
 	return filepath.Join(appDataDir, "sites.json")
 }
 
+// GetBackupPath returns the path of the backup written before upgrading a sites.json file
+func GetBackupPath(filePath string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", filePath, version)
+}
+
 // GetWorkingDirSitesPath returns the path to the sites.json file in the working directory
 func GetWorkingDirSitesPath(execDir string) string {
 	return filepath.Join(execDir, "sites.json")
-} 
\ No newline at end of file
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// Source names used to identify which source resolved a site
+const (
+	SourceWorkingDir = "working_dir"
+	SourceRemote     = "remote"
+	SourceEmbedded   = "embedded"
+	SourceURL        = "url"
+	SourceDefault    = "default"
+)
+
+// DefaultSiteURL is the URL used when a site cannot be resolved from any source
+const DefaultSiteURL = "https://www.google.com"
+
+// ErrSiteNotFound is returned when a source does not know the requested site
+var ErrSiteNotFound = errors.New("site not found")
+
+// SiteSource is a source that can resolve a site configuration by name
+type SiteSource interface {
+	// Name returns the name of the source
+	Name() string
+
+	// Lookup returns the site with the given name or ErrSiteNotFound
+	Lookup(name string) (*Site, error)
+}
+
+// CatalogSource resolves sites from a sites.json catalog loaded on first use
+type CatalogSource struct {
+	name   string
+	load   func() ([]byte, error)
+	config *SiteConfig
+	err    error
+}
+
+// NewCatalogSource creates a source that reads its catalog using the load function
+func NewCatalogSource(name string, load func() ([]byte, error)) *CatalogSource {
+	return &CatalogSource{
+		name: name,
+		load: load,
+	}
+}
+
+// NewFileSource creates a source that reads a sites.json file from disk
+func NewFileSource(name, filePath string) *CatalogSource {
+	return NewCatalogSource(name, func() ([]byte, error) {
+		data, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			return nil, ErrSiteNotFound
+		}
+		return data, err
+	})
+}
+
+// NewRemoteSource creates a source that downloads a sites.json catalog from a URL
+func NewRemoteSource(url string) *CatalogSource {
+	return NewCatalogSource(SourceRemote, func() ([]byte, error) {
+		return utils.DownloadJSON(url)
+	})
+}
+
+// Name returns the name of the source
+func (s *CatalogSource) Name() string {
+	return s.name
+}
+
+// Lookup returns the site with the given name from the catalog
+func (s *CatalogSource) Lookup(name string) (*Site, error) {
+	// Load the catalog only once
+	if s.config == nil && s.err == nil {
+		s.config = NewSiteConfig("")
+		if data, err := s.load(); err != nil {
+			s.err = err
+		} else {
+			s.err = s.config.LoadFromJSON(data)
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	// Return a copy so callers can't modify the catalog
+	site := s.config.GetSiteByName(name)
+	if site == nil {
+		return nil, ErrSiteNotFound
+	}
+	result := *site
+	return &result, nil
+}
+
+// URLSource resolves sites whose name looks like a URL
+type URLSource struct {
+	// FetchStartPage returns the icons and web app manifest of a page
+	FetchStartPage func(pageURL string) (*utils.StartPage, error)
+}
+
+// NewURLSource creates a source that treats URL-like names as sites
+func NewURLSource() *URLSource {
+	return &URLSource{
+		FetchStartPage: utils.FetchStartPage,
+	}
+}
+
+// Name returns the name of the source
+func (s *URLSource) Name() string {
+	return SourceURL
+}
+
+// Lookup creates a site from the name if it is formatted as a URL
+func (s *URLSource) Lookup(name string) (*Site, error) {
+	if !utils.IsValidURL(name) && !utils.IsValidURL("https://"+name) {
+		return nil, ErrSiteNotFound
+	}
+
+	// Add scheme if needed
+	siteURL := name
+	if !strings.HasPrefix(siteURL, "http") {
+		siteURL = "https://" + siteURL
+	}
+
+	site := CreateSiteFromURL(name, siteURL)
+
+	// Fill site from the web app manifest and icons of its start page, fetched once for both
+	if s.FetchStartPage != nil {
+		if page, err := s.FetchStartPage(siteURL); err == nil {
+			if page.Manifest != nil {
+				ApplyManifest(&site, page.Manifest, page.ManifestURL)
+			}
+			if iconURL, err := page.BestIcon(); err == nil {
+				site.Icon = iconURL
+			}
+		}
+	}
+
+	return &site, nil
+}
+
+// DefaultSource resolves every name to a site with a fixed URL
+type DefaultSource struct {
+	url string
+}
+
+// NewDefaultSource creates a source that always resolves to the given URL
+func NewDefaultSource(url string) *DefaultSource {
+	return &DefaultSource{url: url}
+}
+
+// Name returns the name of the source
+func (s *DefaultSource) Name() string {
+	return SourceDefault
+}
+
+// Lookup creates a site with the default URL and icon
+func (s *DefaultSource) Lookup(name string) (*Site, error) {
+	site := CreateSiteFromURL(name, s.url)
+	site.Icon = "default://hobaa.ico"
+	return &site, nil
+}
+
+// Resolution is the result of resolving a site
+type Resolution struct {
+	Site   Site
+	Source string
+}
+
+// Resolver resolves sites by querying its sources in order
+type Resolver struct {
+	Sources []SiteSource
+}
+
+// NewResolver creates a resolver with the given ordered sources
+func NewResolver(sources ...SiteSource) *Resolver {
+	return &Resolver{Sources: sources}
+}
+
+// Resolve returns the site from the first source that knows the name.
+// Sources that don't know the site are skipped; any other error of a source stops the resolution,
+// so a source that fails to load isn't mistaken for one without the site.
+func (r *Resolver) Resolve(name string) (*Resolution, error) {
+	for _, source := range r.Sources {
+		site, err := source.Lookup(name)
+		if errors.Is(err, ErrSiteNotFound) || (err == nil && site == nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up site in %s source: %v", source.Name(), err)
+		}
+
+		return &Resolution{
+			Site:   *site,
+			Source: source.Name(),
+		}, nil
+	}
+
+	return nil, ErrSiteNotFound
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/utils"
+)
+
+// fakeSource is a source knowing a fixed set of sites, or failing with err
+type fakeSource struct {
+	name    string
+	sites   map[string]string // Site name to URL
+	err     error
+	lookups int
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) Lookup(name string) (*Site, error) {
+	s.lookups++
+	if s.err != nil {
+		return nil, s.err
+	}
+	siteURL, ok := s.sites[name]
+	if !ok {
+		return nil, ErrSiteNotFound
+	}
+	site := CreateSiteFromURL(name, siteURL)
+	return &site, nil
+}
+
+func TestResolve(t *testing.T) {
+	failure := errors.New("network is unreachable")
+	tests := []struct {
+		name       string
+		sources    []*fakeSource
+		wantSource string
+		wantURL    string
+		wantErr    bool
+		lookups    []int // Lookups of each source
+	}{
+		{
+			name: "first source knowing the site wins",
+			sources: []*fakeSource{
+				{name: "a", sites: map[string]string{"gmail": "https://a.example.com"}},
+				{name: "b", sites: map[string]string{"gmail": "https://b.example.com"}},
+			},
+			wantSource: "a",
+			wantURL:    "https://a.example.com",
+			lookups:    []int{1, 0},
+		},
+		{
+			name: "sources without the site are skipped in order",
+			sources: []*fakeSource{
+				{name: "a"},
+				{name: "b", sites: map[string]string{"slack": "https://b.example.com"}},
+				{name: "c", sites: map[string]string{"gmail": "https://c.example.com"}},
+				{name: "d", sites: map[string]string{"gmail": "https://d.example.com"}},
+			},
+			wantSource: "c",
+			wantURL:    "https://c.example.com",
+			lookups:    []int{1, 1, 1, 0},
+		},
+		{
+			name: "not found errors wrapped by a source are skipped",
+			sources: []*fakeSource{
+				{name: "a", err: errors.Join(ErrSiteNotFound, errors.New("no catalog"))},
+				{name: "b", sites: map[string]string{"gmail": "https://b.example.com"}},
+			},
+			wantSource: "b",
+			wantURL:    "https://b.example.com",
+			lookups:    []int{1, 1},
+		},
+		{
+			name: "other errors stop the resolution",
+			sources: []*fakeSource{
+				{name: "a"},
+				{name: "b", err: failure},
+				{name: "c", sites: map[string]string{"gmail": "https://c.example.com"}},
+			},
+			wantErr: true,
+			lookups: []int{1, 1, 0},
+		},
+		{
+			name:    "no source knows the site",
+			sources: []*fakeSource{{name: "a"}, {name: "b"}},
+			wantErr: true,
+			lookups: []int{1, 1},
+		},
+	}
+	for _, tt := range tests {
+		var sources []SiteSource
+		for _, source := range tt.sources {
+			sources = append(sources, source)
+		}
+		resolution, err := NewResolver(sources...).Resolve("gmail")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (resolution.Source != tt.wantSource || resolution.Site.URL != tt.wantURL) {
+			t.Errorf("%s: resolved %s from %s, want %s from %s", tt.name, resolution.Site.URL, resolution.Source, tt.wantURL, tt.wantSource)
+		}
+		for i, source := range tt.sources {
+			if source.lookups != tt.lookups[i] {
+				t.Errorf("%s: source %s was asked %d times, want %d", tt.name, source.name, source.lookups, tt.lookups[i])
+			}
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	failure := errors.New("network is unreachable")
+
+	// Failures are reported, not mistaken for a missing site
+	_, err := NewResolver(&fakeSource{name: "remote", err: failure}, NewDefaultSource(DefaultSiteURL)).Resolve("gmail")
+	if err == nil || errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("failing source gave %v", err)
+	}
+
+	// Missing sites are reported as such
+	_, err = NewResolver(&fakeSource{name: "a"}).Resolve("gmail")
+	if !errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("unknown site gave %v, want ErrSiteNotFound", err)
+	}
+}
+
+func TestCatalogSource(t *testing.T) {
+	loads := 0
+	source := NewCatalogSource(SourceEmbedded, func() ([]byte, error) {
+		loads++
+		return []byte(`{"version":1,"sites":[{"name":"gmail","title":"Gmail","url":"https://mail.google.com"}]}`), nil
+	})
+
+	site, err := source.Lookup("gmail")
+	if err != nil || site.URL != "https://mail.google.com" {
+		t.Fatalf("Lookup(gmail) = %+v, %v", site, err)
+	}
+
+	// Callers get copies
+	site.URL = "https://changed.example.com"
+	if site, _ := source.Lookup("gmail"); site.URL != "https://mail.google.com" {
+		t.Errorf("catalog changed through a returned site: %s", site.URL)
+	}
+
+	if _, err := source.Lookup("slack"); !errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("Lookup(slack) error %v, want ErrSiteNotFound", err)
+	}
+	if loads != 1 {
+		t.Errorf("catalog loaded %d times, want once", loads)
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte(`{"sites": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing file doesn't know any site, a corrupt one is an error
+	if _, err := NewFileSource(SourceWorkingDir, filepath.Join(dir, "missing.json")).Lookup("gmail"); !errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("missing file gave %v, want ErrSiteNotFound", err)
+	}
+	if _, err := NewFileSource(SourceWorkingDir, corrupt).Lookup("gmail"); err == nil || errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("corrupt file gave %v", err)
+	}
+}
+
+func TestURLSource(t *testing.T) {
+	manifestURL, _ := url.Parse("https://example.com/app/manifest.json")
+	tests := []struct {
+		name      string
+		page      *utils.StartPage
+		fetchErr  error
+		wantURL   string
+		wantTitle string
+		wantIcon  string
+	}{
+		{
+			name: "example.com",
+			page: &utils.StartPage{
+				Icons:       []utils.IconCandidate{{URL: "https://example.com/icon.svg", Type: "image/svg+xml"}, {URL: "https://example.com/icon.png", Type: "image/png", Size: 192}},
+				Manifest:    &utils.WebManifest{Name: "Example App", StartURL: "/app/?source=pwa"},
+				ManifestURL: manifestURL,
+			},
+			wantURL:   "https://example.com/app/?source=pwa",
+			wantTitle: "Example App",
+			wantIcon:  "https://example.com/icon.png",
+		},
+		{
+			name:      "http://example.com",
+			page:      &utils.StartPage{},
+			wantURL:   "http://example.com",
+			wantTitle: "http://example.com",
+		},
+		{
+			name:      "example.com",
+			fetchErr:  errors.New("connection refused"),
+			wantURL:   "https://example.com",
+			wantTitle: "example.com",
+		},
+	}
+	for _, tt := range tests {
+		var fetched []string
+		source := &URLSource{FetchStartPage: func(pageURL string) (*utils.StartPage, error) {
+			fetched = append(fetched, pageURL)
+			return tt.page, tt.fetchErr
+		}}
+		site, err := source.Lookup(tt.name)
+		if err != nil {
+			t.Errorf("Lookup(%q): %v", tt.name, err)
+			continue
+		}
+		if site.URL != tt.wantURL || site.Title != tt.wantTitle || site.Icon != tt.wantIcon || !site.IsActive {
+			t.Errorf("Lookup(%q) = %+v, want URL %s, title %q and icon %q", tt.name, *site, tt.wantURL, tt.wantTitle, tt.wantIcon)
+		}
+		if want := []string{"https://example.com"}; tt.name == "example.com" && !reflect.DeepEqual(fetched, want) {
+			t.Errorf("Lookup(%q) fetched %v, want %v", tt.name, fetched, want)
+		}
+	}
+
+	// Names that don't look like URLs are left to the next source, without fetching anything
+	source := &URLSource{FetchStartPage: func(pageURL string) (*utils.StartPage, error) {
+		t.Errorf("fetched %s for a name that isn't a URL", pageURL)
+		return nil, errors.New("unexpected fetch")
+	}}
+	for _, name := range []string{"gmail", "my-app"} {
+		if _, err := source.Lookup(name); !errors.Is(err, ErrSiteNotFound) {
+			t.Errorf("Lookup(%q) error %v, want ErrSiteNotFound", name, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSaveDelay is how long the store waits for more changes before writing them
+const DefaultSaveDelay = time.Second
+
+// ChangeEvent describes the configuration after a change
+type ChangeEvent struct {
+	Sites    []Site
+	Settings Settings
+}
+
+// Store guards a site configuration shared by several goroutines.
+// Changes are applied in memory right away, published to subscribers and
+// written to the file after a quiet period, so bursts of changes cause one write.
+type Store struct {
+	mu          sync.Mutex
+	config      *SiteConfig
+	filePath    string
+	delay       time.Duration
+	pending     []func(c *SiteConfig)
+	timer       *time.Timer
+	subscribers map[chan ChangeEvent]struct{}
+}
+
+// NewStore creates a store for the sites.json file at filePath
+func NewStore(filePath string, delay time.Duration) *Store {
+	return &Store{
+		config:      NewSiteConfig(""),
+		filePath:    filePath,
+		delay:       delay,
+		subscribers: map[chan ChangeEvent]struct{}{},
+	}
+}
+
+// Load loads the configuration from the file
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.config.LoadFromFile(s.filePath)
+	s.publish()
+	return err
+}
+
+// Site returns a copy of the site with the given name
+func (s *Store) Site(name string) (*Site, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	site := s.config.GetSiteByName(name)
+	if site == nil {
+		return nil, false
+	}
+	result := site.Clone()
+	return &result, true
+}
+
+// Sites returns a copy of all sites
+func (s *Store) Sites() []Site {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return cloneSites(s.config.Sites)
+}
+
+// Settings returns a copy of the settings
+func (s *Store) Settings() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.config.Settings.Clone()
+}
+
+// Update applies a change in memory and schedules it to be written.
+// The change is replayed on the latest file contents when written, so it must
+// only modify what it means to change.
+func (s *Store) Update(update func(c *SiteConfig)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update(s.config)
+	s.pending = append(s.pending, update)
+	s.publish()
+
+	// Restart the debounce timer
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(s.delay, func() {
+		s.Flush()
+	})
+}
+
+// UpdateNow applies a change and writes it right away
+func (s *Store) UpdateNow(update func(c *SiteConfig)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update(s.config)
+	s.pending = append(s.pending, update)
+	err := s.flushLocked()
+	s.publish()
+	return err
+}
+
+// Flush writes pending changes to the file
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.flushLocked()
+	s.publish()
+	return err
+}
+
+// Close stops the debounce timer and writes pending changes
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return s.flushLocked()
+}
+
+// Subscribe returns a channel receiving the configuration after each change and a function to unsubscribe.
+// Slow subscribers only receive the latest change.
+func (s *Store) Subscribe() (<-chan ChangeEvent, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan ChangeEvent, 1)
+	s.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// flushLocked replays pending changes on the latest file contents while holding the mutex
+func (s *Store) flushLocked() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	// Replay changes on a fresh copy so changes of other processes are kept
+	latest := NewSiteConfig(s.config.ConfigDir)
+	err := latest.Update(s.filePath, func(c *SiteConfig) error {
+		for _, update := range s.pending {
+			update(c)
+		}
+		return nil
+	})
+	if err != nil {
+		return err // Keep pending changes for the next attempt
+	}
+
+	s.pending = nil
+	s.config = latest
+	return nil
+}
+
+// publish sends the current configuration to subscribers while holding the mutex
+func (s *Store) publish() {
+	if len(s.subscribers) == 0 {
+		return
+	}
+
+	event := ChangeEvent{
+		Sites:    cloneSites(s.config.Sites),
+		Settings: s.config.Settings.Clone(),
+	}
+	for ch := range s.subscribers {
+		// Replace an unread event with the latest one
+		select {
+		case ch <- event:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- event
+		}
+	}
+}
+
+// cloneSites returns a copy of the sites that doesn't share maps with the originals
+func cloneSites(sites []Site) []Site {
+	result := make([]Site, len(sites))
+	for i, site := range sites {
+		result[i] = site.Clone()
+	}
+	return result
+}
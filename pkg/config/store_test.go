@@ -0,0 +1,274 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSaveDelay is short enough to wait for, but long enough for a burst of updates to fall within it
+const testSaveDelay = 100 * time.Millisecond
+
+// setWidth returns an update setting the width of a site, adding the site if needed
+func setWidth(name string, width int) func(c *SiteConfig) {
+	return func(c *SiteConfig) {
+		site := c.GetSiteByName(name)
+		if site == nil {
+			c.AddSite(Site{Name: name, URL: "https://" + name + ".example.com"})
+			site = c.GetSiteByName(name)
+		}
+		site.Width = width
+	}
+}
+
+// loadSites reads a sites.json file written by a store
+func loadSites(t *testing.T, filePath string) *SiteConfig {
+	t.Helper()
+	c := NewSiteConfig("")
+	if err := c.LoadFromFile(filePath); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// waitForFile waits until a file exists
+func waitForFile(t *testing.T, filePath string, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := os.Stat(filePath); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s wasn't written within %v", filePath, timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStoreDebouncesWrites(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	store := NewStore(filePath, testSaveDelay)
+	defer store.Close()
+
+	// A burst of updates is applied in memory right away, but not written yet
+	for i := 1; i <= 20; i++ {
+		store.Update(setWidth("gmail", i))
+	}
+	if site, ok := store.Site("gmail"); !ok || site.Width != 20 {
+		t.Errorf("site in memory %+v, want width 20", site)
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Fatal("burst was written before the quiet period")
+	}
+
+	// The burst is written once after the quiet period
+	waitForFile(t, filePath, 20*testSaveDelay)
+	if site := loadSites(t, filePath).GetSiteByName("gmail"); site == nil || site.Width != 20 {
+		t.Errorf("written site %+v, want width 20", site)
+	}
+	if err := os.Remove(filePath); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * testSaveDelay)
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Error("burst was written more than once")
+	}
+}
+
+func TestStoreUpdatesPostponeWrite(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	store := NewStore(filePath, 4*testSaveDelay)
+	defer store.Close()
+
+	// Each update restarts the quiet period, so updates spread over longer than it aren't written yet
+	for i := 1; i <= 6; i++ {
+		store.Update(setWidth("gmail", i))
+		time.Sleep(testSaveDelay)
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Fatal("written while updates kept coming")
+	}
+	waitForFile(t, filePath, 20*testSaveDelay)
+}
+
+func TestStoreFlushAndClose(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	store := NewStore(filePath, time.Hour)
+
+	// Flush writes pending changes without waiting
+	store.Update(setWidth("gmail", 800))
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if site := loadSites(t, filePath).GetSiteByName("gmail"); site == nil || site.Width != 800 {
+		t.Errorf("after Flush the file has %+v, want width 800", site)
+	}
+
+	// Flushing without changes doesn't write
+	if err := os.Remove(filePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Error("Flush without changes wrote the file")
+	}
+
+	// Close writes pending changes
+	store.Update(setWidth("slack", 600))
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c := loadSites(t, filePath)
+	if site := c.GetSiteByName("slack"); site == nil || site.Width != 600 {
+		t.Errorf("after Close the file has %+v, want width 600", site)
+	}
+}
+
+func TestStoreUpdateNow(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	store := NewStore(filePath, time.Hour)
+	defer store.Close()
+
+	// Pending changes are written together with the immediate one
+	store.Update(setWidth("gmail", 800))
+	if err := store.UpdateNow(setWidth("slack", 600)); err != nil {
+		t.Fatal(err)
+	}
+	c := loadSites(t, filePath)
+	if len(c.Sites) != 2 {
+		t.Errorf("file has %d sites, want 2", len(c.Sites))
+	}
+}
+
+func TestStoreReplaysOnChangedFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	initial := NewSiteConfig("")
+	initial.AddSite(Site{Name: "gmail", URL: "https://mail.google.com", Width: 100, Height: 100})
+	if err := initial.SaveToFile(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewStore(filePath, time.Hour)
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	store.Update(setWidth("gmail", 1200))
+
+	// Another process changes the file meanwhile
+	if err := NewSiteConfig("").Update(filePath, func(c *SiteConfig) error {
+		c.AddSite(Site{Name: "slack", URL: "https://app.slack.com"})
+		c.GetSiteByName("gmail").Height = 900
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the pending change is replayed, so the other process's changes are kept
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c := loadSites(t, filePath)
+	gmail := c.GetSiteByName("gmail")
+	if gmail == nil || gmail.Width != 1200 || gmail.Height != 900 {
+		t.Errorf("gmail is %+v, want width 1200 from the store and height 900 from the other process", gmail)
+	}
+	if c.GetSiteByName("slack") == nil {
+		t.Error("site added by the other process was lost")
+	}
+
+	// The store now sees the file as written
+	if _, ok := store.Site("slack"); !ok {
+		t.Error("store doesn't have the site added by the other process")
+	}
+}
+
+func TestStoreSubscribers(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "sites.json"), time.Hour)
+	defer store.Close()
+
+	events, unsubscribe := store.Subscribe()
+	store.Update(setWidth("gmail", 800))
+	select {
+	case event := <-events:
+		if len(event.Sites) != 1 || event.Sites[0].Width != 800 {
+			t.Errorf("event has %+v", event.Sites)
+		}
+
+		// Events are copies
+		event.Sites[0].Width = 1
+		if site, _ := store.Site("gmail"); site.Width != 800 {
+			t.Error("store changed through an event")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no change event")
+	}
+
+	// Slow subscribers only get the latest change
+	store.Update(setWidth("gmail", 900))
+	store.Update(setWidth("gmail", 1000))
+	if event := <-events; event.Sites[0].Width != 1000 {
+		t.Errorf("slow subscriber got width %d, want the latest 1000", event.Sites[0].Width)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("slow subscriber got an extra event %+v", event)
+	default:
+	}
+
+	// Unsubscribing closes the channel once
+	unsubscribe()
+	unsubscribe()
+	store.Update(setWidth("gmail", 1100))
+	if _, ok := <-events; ok {
+		t.Error("channel received an event after unsubscribing")
+	}
+}
+
+func TestStoreConcurrentUse(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sites.json")
+	store := NewStore(filePath, time.Millisecond)
+	events, unsubscribe := store.Subscribe()
+	defer unsubscribe()
+	go func() {
+		for range events {
+		}
+	}()
+
+	// Goroutines update their own site and read the others, run with -race to check the locking
+	const goroutines, updates = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			for j := 1; j <= updates; j++ {
+				store.Update(setWidth(name, j))
+				store.Sites()
+				store.Settings()
+				if j%10 == 0 {
+					if err := store.Flush(); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(fmt.Sprintf("site%d", i))
+	}
+	wg.Wait()
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := loadSites(t, filePath)
+	for i := 0; i < goroutines; i++ {
+		name := fmt.Sprintf("site%d", i)
+		if site := c.GetSiteByName(name); site == nil || site.Width != updates {
+			t.Errorf("%s: written %+v, want width %d", name, site, updates)
+		}
+	}
+}
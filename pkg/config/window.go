@@ -0,0 +1,94 @@
+package config
+
+import "github.com/kemalersin/hobaa/pkg/geometry"
+
+// WindowPosition holds the saved position and state of a site window.
+// The size is kept in the width and height fields of the site or profile, in DPI-independent pixels.
+type WindowPosition struct {
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Monitor   string `json:"monitor,omitempty"`
+	Maximized bool   `json:"maximized,omitempty"`
+	Minimized bool   `json:"minimized,omitempty"`
+}
+
+// WindowPlacement returns the saved placement of a profile's window with its size in DPI-independent pixels.
+// The second result is false if no position was saved, so the window should be centered.
+func (s *Site) WindowPlacement(profile string) (geometry.Placement, bool) {
+	width, height := s.WindowSize(profile)
+	p := geometry.Placement{
+		Bounds: geometry.Rect{Width: width, Height: height},
+		DPI:    geometry.DefaultDPI,
+	}
+
+	position := s.Window
+	if profile != "" {
+		position = s.Profiles[profile].Window
+	}
+	if position == nil {
+		return p, false
+	}
+
+	p.Bounds.X = position.X
+	p.Bounds.Y = position.Y
+	p.Monitor = position.Monitor
+	p.Maximized = position.Maximized
+	p.Minimized = position.Minimized
+	return p, true
+}
+
+// SetWindowPlacement saves the placement of a profile's window, or of the site if no profile is given.
+// The size is converted to DPI-independent pixels so it is the same on monitors with different scaling.
+func (s *Site) SetWindowPlacement(profile string, p geometry.Placement) {
+	p = p.ToLogicalSize()
+	s.SetWindowSize(profile, p.Bounds.Width, p.Bounds.Height)
+
+	position := &WindowPosition{
+		X:         p.Bounds.X,
+		Y:         p.Bounds.Y,
+		Monitor:   p.Monitor,
+		Maximized: p.Maximized,
+		Minimized: p.Minimized,
+	}
+	if profile == "" {
+		s.Window = position
+		return
+	}
+
+	settings := s.Profiles[profile]
+	settings.Window = position
+	s.setProfile(profile, settings)
+}
+
+// clone returns a copy of the position
+func (p *WindowPosition) clone() *WindowPosition {
+	if p == nil {
+		return nil
+	}
+	position := *p
+	return &position
+}
+
+// HasPendingSizeConversions reports whether sites still have window sizes saved in physical pixels
+func (s Settings) HasPendingSizeConversions() bool {
+	return len(s.PendingSizeConversions) > 0
+}
+
+// ConvertLegacySizes converts the window sizes of sites saved in physical pixels by versions before 4
+// to DPI-independent pixels. Those versions were system DPI aware, so sizes are at the system DPI.
+func (c *SiteConfig) ConvertLegacySizes(systemDPI int) {
+	for _, name := range c.Settings.PendingSizeConversions {
+		site := c.GetSiteByName(name)
+		if site == nil {
+			continue
+		}
+		site.Width = geometry.ToLogical(site.Width, systemDPI)
+		site.Height = geometry.ToLogical(site.Height, systemDPI)
+		for profileName, profile := range site.Profiles {
+			profile.Width = geometry.ToLogical(profile.Width, systemDPI)
+			profile.Height = geometry.ToLogical(profile.Height, systemDPI)
+			site.Profiles[profileName] = profile
+		}
+	}
+	c.Settings.PendingSizeConversions = nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kemalersin/hobaa/pkg/geometry"
+)
+
+func TestWindowPlacementRoundTrip(t *testing.T) {
+	site := &Site{Name: "github"}
+	if _, ok := site.WindowPlacement(""); ok {
+		t.Error("new site has a saved placement")
+	}
+
+	// A 1200x900 window on a 150% monitor is saved as 800x600 DPI-independent pixels
+	saved := geometry.Placement{
+		Bounds:    geometry.Rect{X: 2000, Y: 50, Width: 1200, Height: 900},
+		Monitor:   `\\.\DISPLAY2`,
+		DPI:       144,
+		Maximized: true,
+	}
+	site.SetWindowPlacement("", saved)
+	if site.Width != 800 || site.Height != 600 {
+		t.Errorf("saved size %dx%d, want 800x600", site.Width, site.Height)
+	}
+
+	got, ok := site.WindowPlacement("")
+	want := geometry.Placement{
+		Bounds:    geometry.Rect{X: 2000, Y: 50, Width: 800, Height: 600},
+		Monitor:   `\\.\DISPLAY2`,
+		DPI:       geometry.DefaultDPI,
+		Maximized: true,
+	}
+	if !ok || got != want {
+		t.Errorf("WindowPlacement = %+v, %v, want %+v", got, ok, want)
+	}
+
+	// Profiles keep their own placement
+	site.SetWindowPlacement("work", geometry.Placement{Bounds: geometry.Rect{X: 1, Y: 2, Width: 500, Height: 400}, DPI: 96})
+	if got, _ := site.WindowPlacement("work"); got.Bounds != (geometry.Rect{X: 1, Y: 2, Width: 500, Height: 400}) {
+		t.Errorf("profile placement = %+v", got)
+	}
+	if got, _ := site.WindowPlacement(""); got != want {
+		t.Errorf("site placement changed to %+v by a profile", got)
+	}
+}
+
+func TestMigratePhysicalSizes(t *testing.T) {
+	data := []byte(`{
+		"version": 2,
+		"settings": {},
+		"sites": [
+			{"name": "github", "url": "https://github.com", "width": 1800, "height": 1200},
+			{"name": "gmail", "url": "https://mail.google.com", "profiles": {"work": {"width": 1500, "height": 900}}},
+			{"name": "new", "url": "https://example.com"}
+		]
+	}`)
+	doc, version, err := parseDocument(data)
+	if err != nil || version != 2 {
+		t.Fatalf("parseDocument: version %d, %v", version, err)
+	}
+	if want := []string{"github", "gmail"}; !reflect.DeepEqual(doc.Settings.PendingSizeConversions, want) {
+		t.Fatalf("pending conversions %v, want %v", doc.Settings.PendingSizeConversions, want)
+	}
+
+	// Convert sizes saved at 150%
+	c := &SiteConfig{Sites: doc.Sites, Settings: doc.Settings}
+	c.ConvertLegacySizes(144)
+	if github := c.GetSiteByName("github"); github.Width != 1200 || github.Height != 800 {
+		t.Errorf("github size %dx%d, want 1200x800", github.Width, github.Height)
+	}
+	if work := c.GetSiteByName("gmail").Profiles["work"]; work.Width != 1000 || work.Height != 600 {
+		t.Errorf("gmail@work size %dx%d, want 1000x600", work.Width, work.Height)
+	}
+	if c.Settings.HasPendingSizeConversions() {
+		t.Error("conversions still pending after ConvertLegacySizes")
+	}
+
+	// Current documents are not converted again
+	current, err := marshalDocument(c.Settings, c.Sites)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, _, err = parseDocument(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Settings.HasPendingSizeConversions() || doc.Sites[0].Width != 1200 {
+		t.Errorf("current document changed on load: %+v", doc)
+	}
+}
+
+func TestConvertLegacySizesSkipsNewSites(t *testing.T) {
+	c := NewSiteConfig("")
+	c.AddSite(Site{Name: "old", Width: 1920, Height: 1080})
+	c.AddSite(Site{Name: "added", Width: 1000, Height: 700})
+	c.Settings.PendingSizeConversions = []string{"old", "removed"}
+
+	c.ConvertLegacySizes(192)
+	if old := c.GetSiteByName("old"); old.Width != 960 || old.Height != 540 {
+		t.Errorf("old site size %dx%d, want 960x540", old.Width, old.Height)
+	}
+	if added := c.GetSiteByName("added"); added.Width != 1000 || added.Height != 700 {
+		t.Errorf("site added after the migration was converted to %dx%d", added.Width, added.Height)
+	}
+}
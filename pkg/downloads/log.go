@@ -0,0 +1,201 @@
+package downloads
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kemalersin/hobaa/pkg/utils"
+	"github.com/kemalersin/hobaa/pkg/webview"
+)
+
+// LogVersion is the version of the downloads log format
+const LogVersion = 1
+
+// MaxLogEntries is the number of downloads kept in the log, older finished downloads are dropped
+const MaxLogEntries = 200
+
+// Entry is a download recorded in the log
+type Entry struct {
+	ID       int                   `json:"id"`
+	URL      string                `json:"url"`
+	Path     string                `json:"path"`
+	MimeType string                `json:"mime_type,omitempty"`
+	State    webview.DownloadState `json:"state"`
+	Received int64                 `json:"received"`
+	Total    int64                 `json:"total,omitempty"`
+	Started  time.Time             `json:"started"`
+	Finished *time.Time            `json:"finished,omitempty"`
+}
+
+// logFile is the JSON document of the downloads log
+type logFile struct {
+	Version   int     `json:"version"`
+	Downloads []Entry `json:"downloads"`
+}
+
+// Log is the list of downloads of a site, persisted to a JSON file
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry // Oldest first
+	nextID  int
+}
+
+// OpenLog loads the downloads log, or starts empty if it doesn't exist.
+// Downloads still in progress when the app closed are marked as interrupted.
+func OpenLog(path string) (*Log, error) {
+	l := &Log{path: path, nextID: 1}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, fmt.Errorf("failed to read downloads log: %v", err)
+	}
+	entries, err := ParseLog(data)
+	if err != nil {
+		return l, fmt.Errorf("failed to parse downloads log %s: %v", path, err)
+	}
+
+	for i := range entries {
+		if entries[i].State == webview.DownloadInProgress {
+			entries[i].State = webview.DownloadInterrupted
+		}
+		if entries[i].ID >= l.nextID {
+			l.nextID = entries[i].ID + 1
+		}
+	}
+	l.entries = entries
+	return l, nil
+}
+
+// ParseLog decodes the entries of a downloads log
+func ParseLog(data []byte) ([]Entry, error) {
+	var file logFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version > LogVersion {
+		return nil, fmt.Errorf("downloads log version %d is newer than %d", file.Version, LogVersion)
+	}
+	return file.Downloads, nil
+}
+
+// MarshalLog encodes entries as a downloads log
+func MarshalLog(entries []Entry) ([]byte, error) {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return json.MarshalIndent(logFile{Version: LogVersion, Downloads: entries}, "", "  ")
+}
+
+// Add records a new download, assigning its ID, and writes the log
+func (l *Log) Add(e Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.ID = l.nextID
+	l.nextID++
+	l.entries = append(l.entries, e)
+	l.prune()
+	return e, l.save()
+}
+
+// Update changes a download in memory, call Save to write it
+func (l *Log) Update(id int, update func(e *Entry)) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.entries {
+		if l.entries[i].ID == id {
+			update(&l.entries[i])
+			return l.entries[i], true
+		}
+	}
+	return Entry{}, false
+}
+
+// Get returns a download by ID
+func (l *Log) Get(id int) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range l.entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Entries returns the downloads, newest first
+func (l *Log) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]Entry, 0, len(l.entries))
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		entries = append(entries, l.entries[i])
+	}
+	return entries
+}
+
+// Clear removes finished downloads and writes the log
+func (l *Log) Clear() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var active []Entry
+	for _, e := range l.entries {
+		if e.State == webview.DownloadInProgress {
+			active = append(active, e)
+		}
+	}
+	l.entries = active
+	return l.save()
+}
+
+// Save writes the log
+func (l *Log) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.save()
+}
+
+// prune drops the oldest finished downloads beyond MaxLogEntries, called with the mutex held
+func (l *Log) prune() {
+	excess := len(l.entries) - MaxLogEntries
+	if excess <= 0 {
+		return
+	}
+	kept := l.entries[:0]
+	for _, e := range l.entries {
+		if excess > 0 && e.State != webview.DownloadInProgress {
+			excess--
+			continue
+		}
+		kept = append(kept, e)
+	}
+	l.entries = kept
+}
+
+// save writes the log, called with the mutex held
+func (l *Log) save() error {
+	data, err := MarshalLog(l.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create downloads log directory: %v", err)
+	}
+	if err := utils.WriteFileAtomic(l.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write downloads log: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package downloads
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kemalersin/hobaa/pkg/webview"
+)
+
+func TestParseLog(t *testing.T) {
+	tests := []struct {
+		data    string
+		entries int
+		wantErr bool
+	}{
+		{`{"version":1,"downloads":[{"id":1,"path":"a.pdf","state":"completed"}]}`, 1, false},
+		{`{"downloads":[]}`, 0, false},
+		{`{"version":2,"downloads":[{"id":1}]}`, 0, true},
+		{`not json`, 0, true},
+	}
+	for _, tt := range tests {
+		entries, err := ParseLog([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLog(%s) error = %v, want error %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if len(entries) != tt.entries {
+			t.Errorf("ParseLog(%s) returned %d entries, want %d", tt.data, len(entries), tt.entries)
+		}
+	}
+}
+
+func TestMarshalLog(t *testing.T) {
+	data, err := MarshalLog(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"downloads": []`) {
+		t.Errorf("empty log marshaled as %s", data)
+	}
+
+	finished := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{{ID: 4, URL: "https://example.com/a.pdf", Path: "a.pdf", State: webview.DownloadCompleted, Received: 10, Total: 10, Finished: &finished}}
+	data, err = MarshalLog(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseLog(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 1 || parsed[0].ID != 4 || parsed[0].Path != "a.pdf" || !parsed[0].Finished.Equal(finished) {
+		t.Errorf("round trip returned %+v", parsed)
+	}
+}
+
+func TestOpenLog(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing log starts empty
+	l, err := OpenLog(filepath.Join(dir, "missing", "downloads.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := l.Add(Entry{Path: "a.pdf"}); err != nil || e.ID != 1 {
+		t.Errorf("first download got ID %d, %v", e.ID, err)
+	}
+
+	// Downloads left in progress are interrupted and IDs continue after the highest
+	path := filepath.Join(dir, "downloads.json")
+	data, err := MarshalLog([]Entry{
+		{ID: 5, Path: "b.pdf", State: webview.DownloadCompleted},
+		{ID: 3, Path: "c.pdf", State: webview.DownloadInProgress},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err = OpenLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := l.Get(3); e.State != webview.DownloadInterrupted {
+		t.Errorf("download left in progress has state %s", e.State)
+	}
+	if e, _ := l.Get(5); e.State != webview.DownloadCompleted {
+		t.Errorf("completed download has state %s", e.State)
+	}
+	if e, err := l.Add(Entry{Path: "d.pdf"}); err != nil || e.ID != 6 {
+		t.Errorf("new download got ID %d, %v", e.ID, err)
+	}
+
+	// A newer log isn't read, but the app can still record downloads
+	if err := os.WriteFile(path, []byte(`{"version":99,"downloads":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err = OpenLog(path)
+	if err == nil {
+		t.Error("newer log version was accepted")
+	}
+	if l == nil || len(l.Entries()) != 0 {
+		t.Error("OpenLog didn't return an empty log on error")
+	}
+}
+
+func TestLogPrune(t *testing.T) {
+	l, err := OpenLog(filepath.Join(t.TempDir(), "downloads.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Add(Entry{Path: "active.bin", State: webview.DownloadInProgress}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxLogEntries+4; i++ {
+		if _, err := l.Add(Entry{Path: "done.bin", State: webview.DownloadCompleted}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The oldest finished downloads are dropped, the one in progress is kept
+	entries := l.Entries()
+	if len(entries) != MaxLogEntries {
+		t.Fatalf("log keeps %d downloads, want %d", len(entries), MaxLogEntries)
+	}
+	if oldest := entries[len(entries)-1]; oldest.ID != 1 {
+		t.Errorf("oldest download is %d, want the one in progress", oldest.ID)
+	}
+	if newest := entries[0]; newest.ID != MaxLogEntries+5 {
+		t.Errorf("newest download is %d, want %d", newest.ID, MaxLogEntries+5)
+	}
+	for _, id := range []int{2, 6} {
+		if _, ok := l.Get(id); ok {
+			t.Errorf("download %d wasn't dropped", id)
+		}
+	}
+	if _, ok := l.Get(7); !ok {
+		t.Error("download 7 was dropped")
+	}
+}
+
+func TestLogClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "downloads.json")
+	l, err := OpenLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, state := range []webview.DownloadState{webview.DownloadCompleted, webview.DownloadInProgress, webview.DownloadInterrupted} {
+		if _, err := l.Add(Entry{Path: "file.bin", State: state}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Clear(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the download in progress is left, also in the file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ParseLog(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != 2 {
+		t.Errorf("log after Clear has %+v, want download 2", entries)
+	}
+}
@@ -0,0 +1,156 @@
+package downloads
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kemalersin/hobaa/pkg/webview"
+)
+
+// progressInterval is the shortest time between two progress reports of a download
+const progressInterval = 500 * time.Millisecond
+
+// Manager saves downloads to a directory with unique names and records them in a log.
+// It implements webview.DownloadHandler.
+type Manager struct {
+	dir      string
+	log      *Log
+	onChange func(Entry)
+	now      func() time.Time // Replaced in tests
+
+	mu     sync.Mutex
+	active map[int]*activeDownload // By webview download ID
+}
+
+// activeDownload is a download in progress
+type activeDownload struct {
+	logID    int
+	path     string
+	reported time.Time // When progress was last reported
+}
+
+// NewManager creates a manager saving to dir. onChange is called with each new or changed download,
+// with progress reported at most twice a second.
+func NewManager(dir string, log *Log, onChange func(Entry)) *Manager {
+	return &Manager{
+		dir:      dir,
+		log:      log,
+		onChange: onChange,
+		now:      time.Now,
+		active:   map[int]*activeDownload{},
+	}
+}
+
+// Dir returns the directory downloads are saved to
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Log returns the downloads log
+func (m *Manager) Log() *Log {
+	return m.log
+}
+
+// DownloadStarting picks a free path in the download directory and records the download
+func (m *Manager) DownloadStarting(req webview.DownloadRequest) (string, bool) {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		fmt.Printf("Failed to create download directory: %v\n", err)
+		return "", false
+	}
+
+	m.mu.Lock()
+	path := UniquePath(m.dir, FileName(req.SuggestedPath, req.URL), m.taken)
+	now := m.now()
+	entry, err := m.log.Add(Entry{
+		URL:      req.URL,
+		Path:     path,
+		MimeType: req.MimeType,
+		State:    webview.DownloadInProgress,
+		Started:  now,
+	})
+	if err != nil {
+		fmt.Printf("Failed to record download: %v\n", err)
+	}
+	m.active[req.ID] = &activeDownload{logID: entry.ID, path: path, reported: now}
+	m.mu.Unlock()
+
+	m.changed(entry)
+	return path, true
+}
+
+// DownloadChanged records the progress of a download, writing the log when it ends
+func (m *Manager) DownloadChanged(update webview.DownloadUpdate) {
+	m.mu.Lock()
+	download, ok := m.active[update.ID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+
+	// Progress is only reported now and then, state changes always
+	now := m.now()
+	finished := update.State != webview.DownloadInProgress
+	if !finished && now.Sub(download.reported) < progressInterval {
+		m.mu.Unlock()
+		return
+	}
+	download.reported = now
+	if finished {
+		delete(m.active, update.ID)
+	}
+	m.mu.Unlock()
+
+	entry, ok := m.log.Update(download.logID, func(e *Entry) {
+		e.State = update.State
+		e.Received = update.BytesReceived
+		e.Total = update.TotalBytes
+		if update.Path != "" {
+			e.Path = update.Path
+		}
+		if finished {
+			e.Finished = &now
+		}
+	})
+	if !ok {
+		return
+	}
+	if finished {
+		if err := m.log.Save(); err != nil {
+			fmt.Printf("Failed to record download: %v\n", err)
+		}
+	}
+	m.changed(entry)
+}
+
+// DownloadID returns the webview ID of a download in progress by its log ID
+func (m *Manager) DownloadID(logID int) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, download := range m.active {
+		if download.logID == logID {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// taken reports whether a path is used by a file or a download in progress, called with the mutex held
+func (m *Manager) taken(path string) bool {
+	for _, download := range m.active {
+		if download.path == path {
+			return true
+		}
+	}
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// changed reports a download to the callback
+func (m *Manager) changed(entry Entry) {
+	if m.onChange != nil {
+		m.onChange(entry)
+	}
+}
@@ -0,0 +1,156 @@
+package downloads
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kemalersin/hobaa/pkg/webview"
+)
+
+// newTestManager returns a manager with a clock the test moves and the changes it reported
+func newTestManager(t *testing.T) (*Manager, *time.Time, *[]Entry) {
+	t.Helper()
+	dir := t.TempDir()
+	log, err := OpenLog(filepath.Join(dir, "downloads", "github.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []Entry
+	m := NewManager(filepath.Join(dir, "Downloads"), log, func(e Entry) {
+		changes = append(changes, e)
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now, &changes
+}
+
+func TestManagerReportsProgress(t *testing.T) {
+	m, now, changes := newTestManager(t)
+	started := *now
+
+	path, ok := m.DownloadStarting(webview.DownloadRequest{ID: 7, URL: "https://example.com/report.pdf", MimeType: "application/pdf"})
+	if !ok || path != filepath.Join(m.Dir(), "report.pdf") {
+		t.Fatalf("DownloadStarting = %s, %v", path, ok)
+	}
+	if len(*changes) != 1 || (*changes)[0].State != webview.DownloadInProgress || !(*changes)[0].Started.Equal(started) {
+		t.Fatalf("start reported as %+v", *changes)
+	}
+	logID := (*changes)[0].ID
+	if id, ok := m.DownloadID(logID); !ok || id != 7 {
+		t.Errorf("DownloadID(%d) = %d, %v, want 7", logID, id, ok)
+	}
+
+	// Progress is reported at most every progressInterval
+	steps := []struct {
+		after    time.Duration
+		received int64
+		reported bool
+	}{
+		{progressInterval / 5, 100, false},
+		{progressInterval / 5, 200, false},
+		{progressInterval, 300, true},
+		{progressInterval - time.Millisecond, 400, false},
+		{time.Millisecond, 500, true},
+	}
+	for _, step := range steps {
+		*now = now.Add(step.after)
+		before := len(*changes)
+		m.DownloadChanged(webview.DownloadUpdate{ID: 7, State: webview.DownloadInProgress, BytesReceived: step.received, TotalBytes: 1000})
+		if reported := len(*changes) > before; reported != step.reported {
+			t.Errorf("progress to %d bytes reported = %v, want %v", step.received, reported, step.reported)
+		} else if reported && (*changes)[len(*changes)-1].Received != step.received {
+			t.Errorf("reported %d bytes, want %d", (*changes)[len(*changes)-1].Received, step.received)
+		}
+	}
+
+	// Completion is always reported and written with the time it finished
+	*now = now.Add(time.Millisecond)
+	finished := *now
+	before := len(*changes)
+	m.DownloadChanged(webview.DownloadUpdate{ID: 7, State: webview.DownloadCompleted, BytesReceived: 1000, TotalBytes: 1000})
+	if len(*changes) != before+1 {
+		t.Fatal("completion wasn't reported")
+	}
+	if _, ok := m.DownloadID(logID); ok {
+		t.Error("completed download is still in progress")
+	}
+
+	data, err := os.ReadFile(m.Log().path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ParseLog(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("log has %d downloads, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.State != webview.DownloadCompleted || e.Received != 1000 || e.Path != path || !e.Started.Equal(started) || e.Finished == nil || !e.Finished.Equal(finished) {
+		t.Errorf("log has %+v", e)
+	}
+
+	// Later updates of a finished or unknown download are ignored
+	m.DownloadChanged(webview.DownloadUpdate{ID: 7, State: webview.DownloadInterrupted})
+	m.DownloadChanged(webview.DownloadUpdate{ID: 8, State: webview.DownloadCompleted})
+	if len(*changes) != before+1 {
+		t.Errorf("ignored updates were reported: %+v", (*changes)[before+1:])
+	}
+}
+
+func TestManagerInterruptedDownload(t *testing.T) {
+	m, now, changes := newTestManager(t)
+	m.DownloadStarting(webview.DownloadRequest{ID: 1, URL: "https://example.com/a.zip"})
+
+	// State changes are reported right away, even within progressInterval, and keep the path the browser used
+	*now = now.Add(time.Millisecond)
+	renamed := filepath.Join(m.Dir(), "a (renamed).zip")
+	m.DownloadChanged(webview.DownloadUpdate{ID: 1, State: webview.DownloadInterrupted, BytesReceived: 10, Path: renamed})
+	last := (*changes)[len(*changes)-1]
+	if last.State != webview.DownloadInterrupted || last.Path != renamed || last.Finished == nil || !last.Finished.Equal(*now) {
+		t.Errorf("interruption reported as %+v", last)
+	}
+}
+
+func TestManagerUniquePaths(t *testing.T) {
+	m, _, _ := newTestManager(t)
+	if err := os.MkdirAll(m.Dir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(m.Dir(), "existing.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id            int
+		url           string
+		suggestedPath string
+		want          string
+	}{
+		{1, "https://example.com/report.pdf", "", "report.pdf"},
+
+		// Downloads in progress and existing files aren't overwritten
+		{2, "https://example.com/report.pdf", "", "report (1).pdf"},
+		{3, "https://example.com/other", `C:\Users\me\Downloads\report.pdf`, "report (2).pdf"},
+		{4, "https://example.com/existing.txt", "", "existing (1).txt"},
+
+		// Suggested names can't leave the download directory
+		{5, "https://example.com/", `..\..\evil.exe`, "evil.exe"},
+		{6, "https://example.com/", "", DefaultFileName},
+	}
+	for _, tt := range tests {
+		path, ok := m.DownloadStarting(webview.DownloadRequest{ID: tt.id, URL: tt.url, SuggestedPath: tt.suggestedPath})
+		if want := filepath.Join(m.Dir(), tt.want); !ok || path != want {
+			t.Errorf("download %d saved to %s, %v, want %s", tt.id, path, ok, want)
+		}
+	}
+
+	// The name of a finished download that wasn't written is free again
+	m.DownloadChanged(webview.DownloadUpdate{ID: 1, State: webview.DownloadInterrupted})
+	if path, _ := m.DownloadStarting(webview.DownloadRequest{ID: 7, URL: "https://example.com/report.pdf"}); path != filepath.Join(m.Dir(), "report.pdf") {
+		t.Errorf("download after an interrupted one saved to %s", path)
+	}
+}
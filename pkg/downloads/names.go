@@ -0,0 +1,133 @@
+// Package downloads saves the downloads of a site to its download directory and keeps a log of them.
+// Naming and the log work on plain paths and data, so they can be used without a webview.
+package downloads
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultFileName is used when a download has no usable name
+const DefaultFileName = "download"
+
+// maxFileNameLength keeps names well below the 255 characters Windows allows, leaving room for " (n)"
+const maxFileNameLength = 200
+
+// reservedNames are device names Windows doesn't allow as file names, with or without an extension
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// executableExtensions are file types Windows runs as programs or scripts when they are opened
+var executableExtensions = map[string]bool{
+	".appref-ms": true, ".bat": true, ".chm": true, ".cmd": true, ".com": true, ".cpl": true, ".exe": true,
+	".hta": true, ".inf": true, ".jar": true, ".js": true, ".jse": true, ".lnk": true, ".msc": true,
+	".msi": true, ".msix": true, ".msp": true, ".pif": true, ".ps1": true, ".reg": true, ".scr": true,
+	".settingcontent-ms": true, ".url": true, ".vb": true, ".vbe": true, ".vbs": true, ".website": true,
+	".ws": true, ".wsc": true, ".wsf": true, ".wsh": true,
+}
+
+// IsExecutable reports whether opening a file would run it as a program or script
+func IsExecutable(name string) bool {
+	ext := strings.ToLower(filepath.Ext(strings.TrimRight(baseName(name), ". ")))
+	return executableExtensions[ext]
+}
+
+// ResolveDir returns the download directory of a site.
+// Sites without a configured directory save to Hobaa\<site> in the Downloads folder, and relative
+// directories are taken relative to the Downloads folder.
+func ResolveDir(configured, downloadsDir, site string) string {
+	if configured == "" {
+		return filepath.Join(downloadsDir, "Hobaa", SanitizeFileName(site))
+	}
+	if filepath.IsAbs(configured) {
+		return filepath.Clean(configured)
+	}
+	return filepath.Join(downloadsDir, configured)
+}
+
+// FileName returns the name to save a download as, from the path suggested by the browser or its URL
+func FileName(suggestedPath, rawURL string) string {
+	if name := baseName(suggestedPath); name != "" {
+		return SanitizeFileName(name)
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		if name := path.Base(u.Path); name != "/" && name != "." {
+			return SanitizeFileName(name)
+		}
+	}
+	return DefaultFileName
+}
+
+// SanitizeFileName makes a name suggested by a site safe to create on Windows.
+// Path separators, reserved characters and device names are replaced, so names can't escape the directory.
+func SanitizeFileName(name string) string {
+	name = baseName(name)
+
+	// Replace characters Windows doesn't allow in names
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	// Windows drops trailing dots and spaces
+	name = strings.TrimLeft(name, " ")
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return DefaultFileName
+	}
+
+	// Device names are reserved whatever the extension
+	stem := name
+	if i := strings.IndexByte(stem, '.'); i >= 0 {
+		stem = stem[:i]
+	}
+	if reservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		name = "_" + name
+	}
+
+	// Shorten long names, keeping the extension
+	if utf8.RuneCountInString(name) > maxFileNameLength {
+		ext := filepath.Ext(name)
+		if utf8.RuneCountInString(ext) > maxFileNameLength/2 {
+			ext = ""
+		}
+		stemRunes := []rune(strings.TrimSuffix(name, ext))
+		name = strings.TrimRight(string(stemRunes[:maxFileNameLength-utf8.RuneCountInString(ext)]), ". ") + ext
+	}
+	return name
+}
+
+// UniquePath returns a path in dir for the file name that isn't taken.
+// Like browsers, it adds " (1)", " (2)" and so on before the extension until a free name is found.
+func UniquePath(dir, name string, taken func(path string) bool) string {
+	candidate := filepath.Join(dir, name)
+	if !taken(candidate) {
+		return candidate
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		candidate = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", stem, n, ext))
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// baseName returns the last element of a Windows or slash separated path
+func baseName(p string) string {
+	if i := strings.LastIndexAny(p, `/\`); i >= 0 {
+		p = p[i+1:]
+	}
+	return p
+}
@@ -0,0 +1,139 @@
+package downloads
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"Çalışma raporu.docx", "Çalışma raporu.docx"},
+
+		// Paths can't escape the directory
+		{`..\..\evil.exe`, "evil.exe"},
+		{"a/b/c.txt", "c.txt"},
+		{"dir/", DefaultFileName},
+
+		// Reserved characters
+		{`a<b>c:d"e|f?g*h.txt`, "a_b_c_d_e_f_g_h.txt"},
+		{"tab\tname\x7f.txt", "tab_name_.txt"},
+
+		// Device names, whatever the case and extension
+		{"CON", "_CON"},
+		{"con.txt", "_con.txt"},
+		{"Lpt1.tar.gz", "_Lpt1.tar.gz"},
+		{"nul .txt", "_nul .txt"},
+		{"CON...", "_CON"},
+		{"COM10.txt", "COM10.txt"},
+		{"console.txt", "console.txt"},
+
+		// Leading spaces, trailing dots and spaces
+		{"file.txt...", "file.txt"},
+		{"file. . ", "file"},
+		{"  spaced.txt", "spaced.txt"},
+		{"...", DefaultFileName},
+		{"", DefaultFileName},
+
+		// Long names are shortened, keeping the extension
+		{strings.Repeat("a", 300) + ".pdf", strings.Repeat("a", maxFileNameLength-4) + ".pdf"},
+		{strings.Repeat("ü", 300), strings.Repeat("ü", maxFileNameLength)},
+		{"a." + strings.Repeat("b", 250), "a." + strings.Repeat("b", maxFileNameLength-2)},
+		{strings.Repeat("a", 195) + "...." + strings.Repeat("b", 10) + ".txt", strings.Repeat("a", 195) + ".txt"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		suggested, url string
+		want           string
+	}{
+		{`C:\Users\me\Downloads\report.pdf`, "https://example.com/other.pdf", "report.pdf"},
+		{"", "https://example.com/files/a%20b.pdf?x=1", "a b.pdf"},
+		{"", "https://example.com/con", "_con"},
+		{"", "https://example.com/", DefaultFileName},
+		{"", "https://example.com", DefaultFileName},
+		{"", "::not a url", DefaultFileName},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.suggested, tt.url); got != tt.want {
+			t.Errorf("FileName(%q, %q) = %q, want %q", tt.suggested, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestResolveDir(t *testing.T) {
+	downloads := filepath.Join("home", "Downloads")
+	abs := t.TempDir()
+	tests := []struct {
+		configured, site string
+		want             string
+	}{
+		{"", "gmail", filepath.Join(downloads, "Hobaa", "gmail")},
+		{"", "../gmail", filepath.Join(downloads, "Hobaa", "gmail")},
+		{"Work", "gmail", filepath.Join(downloads, "Work")},
+		{abs + string(filepath.Separator), "gmail", abs},
+	}
+	for _, tt := range tests {
+		if got := ResolveDir(tt.configured, downloads, tt.site); got != tt.want {
+			t.Errorf("ResolveDir(%q, %q) = %q, want %q", tt.configured, tt.site, got, tt.want)
+		}
+	}
+}
+
+func TestUniquePath(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{"report.pdf", nil, "report.pdf"},
+		{"report.pdf", []string{"report.pdf"}, "report (1).pdf"},
+		{"report.pdf", []string{"report.pdf", "report (1).pdf"}, "report (2).pdf"},
+		{"report.pdf", []string{"report (1).pdf"}, "report.pdf"},
+		{"archive.tar.gz", []string{"archive.tar.gz"}, "archive.tar (1).gz"},
+		{"README", []string{"README"}, "README (1)"},
+	}
+	for _, tt := range tests {
+		taken := make(map[string]bool)
+		for _, name := range tt.taken {
+			taken[filepath.Join("dl", name)] = true
+		}
+		got := UniquePath("dl", tt.name, func(path string) bool { return taken[path] })
+		if want := filepath.Join("dl", tt.want); got != want {
+			t.Errorf("UniquePath(%q) with %v taken = %q, want %q", tt.name, tt.taken, got, want)
+		}
+	}
+}
+
+func TestIsExecutable(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"setup.exe", true},
+		{"SETUP.EXE", true},
+		{"run.bat.", true},
+		{"link.lnk ", true},
+		{`dir\app.hta`, true},
+		{"script.ps1", true},
+		{"report.pdf", false},
+		{"archive.exe.zip", false},
+		{"exe", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsExecutable(tt.name); got != tt.want {
+			t.Errorf("IsExecutable(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package downloads
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// eventFunction is the page function Go calls with changed downloads
+const eventFunction = "__hobaaDownloadEvent"
+
+// PanelScript returns the JavaScript of the download panel, which lists downloads through the
+// window.hobaa.downloads methods and opens when Go reports a new download
+func PanelScript() string {
+	return fmt.Sprintf(panelScript, eventFunction)
+}
+
+// EventScript returns the JavaScript reporting a new or changed download to the panel
+func EventScript(entry Entry) string {
+	entryJSON, _ := json.Marshal(entry)
+	return fmt.Sprintf("window.%s && window.%s(%s);", eventFunction, eventFunction, entryJSON)
+}
+
+// panelScript shows downloads in a small panel in the bottom right corner, kept in a shadow root
+// so page styles don't apply to it
+const panelScript = `
+(function() {
+	const api = window.hobaa && window.hobaa.downloads;
+	if (!api || window.top !== window) {
+		return;
+	}
+
+	const maxShown = 5;
+	let downloads = [];
+	let host = null;
+	let list = null;
+
+	function formatSize(bytes) {
+		if (bytes < 1024) {
+			return bytes + ' B';
+		}
+		const units = ['KB', 'MB', 'GB', 'TB'];
+		let size = bytes / 1024;
+		let unit = 0;
+		while (size >= 1024 && unit < units.length - 1) {
+			size /= 1024;
+			unit++;
+		}
+		return size.toFixed(size < 10 ? 1 : 0) + ' ' + units[unit];
+	}
+
+	function describe(d) {
+		if (d.state === 'completed') {
+			return formatSize(d.received);
+		}
+		if (d.state === 'interrupted') {
+			return 'Failed';
+		}
+		return d.total ? formatSize(d.received) + ' of ' + formatSize(d.total) : formatSize(d.received);
+	}
+
+	// Only clicks of the user act, not clicks dispatched by page scripts
+	function button(label, action) {
+		const b = document.createElement('button');
+		b.textContent = label;
+		b.addEventListener('click', event => {
+			event.stopPropagation();
+			if (event.isTrusted) {
+				action().catch(() => {});
+			}
+		});
+		return b;
+	}
+
+	function create() {
+		host = document.createElement('hobaa-downloads');
+		const root = host.attachShadow({mode: 'closed'});
+		root.innerHTML = ` + "`" + `
+			<style>
+				:host { all: initial; }
+				.panel { position: fixed; right: 16px; bottom: 16px; z-index: 2147483647; width: 320px;
+					background: #fff; color: #202124; border-radius: 8px; box-shadow: 0 4px 16px rgba(0,0,0,.25);
+					font: 13px 'Segoe UI', sans-serif; overflow: hidden; }
+				.header { display: flex; align-items: center; padding: 8px 12px; font-weight: 600; border-bottom: 1px solid #e0e0e0; }
+				.header span { flex: 1; }
+				.item { padding: 8px 12px; border-bottom: 1px solid #f0f0f0; }
+				.item.done { cursor: pointer; }
+				.item.done:hover { background: #f5f5f5; }
+				.name { white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+				.info { display: flex; align-items: center; gap: 8px; color: #5f6368; font-size: 12px; margin-top: 2px; }
+				.info span { flex: 1; }
+				.bar { height: 3px; background: #e0e0e0; margin-top: 4px; border-radius: 2px; overflow: hidden; }
+				.bar div { height: 100%%; background: #1a73e8; }
+				button { border: none; background: none; color: #1a73e8; cursor: pointer; font: inherit; padding: 0; }
+				.close { color: #5f6368; font-size: 16px; }
+			</style>
+			<div class="panel">
+				<div class="header"><span>Downloads</span></div>
+				<div class="list"></div>
+			</div>
+		` + "`" + `;
+		const header = root.querySelector('.header');
+		header.appendChild(button('Clear', () => api.clear().then(() => {
+			downloads = downloads.filter(d => d.state === 'in_progress');
+			render();
+		})));
+		const close = button('×', () => Promise.resolve(hide()));
+		close.className = 'close';
+		close.style.marginLeft = '12px';
+		header.appendChild(close);
+		list = root.querySelector('.list');
+		document.documentElement.appendChild(host);
+	}
+
+	function hide() {
+		if (host) {
+			host.remove();
+			host = null;
+		}
+	}
+
+	function render() {
+		if (!host) {
+			return;
+		}
+		if (downloads.length === 0) {
+			hide();
+			return;
+		}
+		list.replaceChildren(...downloads.slice(0, maxShown).map(d => {
+			const item = document.createElement('div');
+			item.className = 'item' + (d.state === 'completed' ? ' done' : '');
+			const name = document.createElement('div');
+			name.className = 'name';
+			name.textContent = d.path.split(/[\\/]/).pop();
+			name.title = d.path;
+			const info = document.createElement('div');
+			info.className = 'info';
+			const status = document.createElement('span');
+			status.textContent = describe(d);
+			info.appendChild(status);
+			if (d.state === 'in_progress') {
+				info.appendChild(button('Cancel', () => api.cancel(d.id)));
+			} else if (d.state === 'completed') {
+				info.appendChild(button('Show in folder', () => api.showInFolder(d.id)));
+				item.addEventListener('click', event => event.isTrusted && api.open(d.id).catch(() => {}));
+			}
+			item.append(name, info);
+			if (d.state === 'in_progress' && d.total) {
+				const bar = document.createElement('div');
+				bar.className = 'bar';
+				const fill = document.createElement('div');
+				fill.style.width = Math.min(100, d.received / d.total * 100) + '%%';
+				bar.appendChild(fill);
+				item.appendChild(bar);
+			}
+			return item;
+		}));
+	}
+
+	// Show the panel with the latest downloads when one starts or changes
+	Object.defineProperty(window, %q, {
+		value: entry => {
+			const i = downloads.findIndex(d => d.id === entry.id);
+			if (i >= 0) {
+				downloads[i] = entry;
+			} else {
+				downloads.unshift(entry);
+			}
+			if (!host) {
+				create();
+				api.list().then(all => {
+					const latest = new Map(downloads.map(d => [d.id, d]));
+					downloads = all.map(d => latest.get(d.id) || d);
+					render();
+				}, () => {});
+			}
+			render();
+		},
+	});
+})();
+`
@@ -6,10 +6,11 @@ import (
 
 // Required definitions for Windows API functions
 var (
-	user32                 = syscall.NewLazyDLL("user32.dll")
-	setProcessDPIAware     = user32.NewProc("SetProcessDPIAware")
-	shcore                 = syscall.NewLazyDLL("shcore.dll")
-	setProcessDpiAwareness = shcore.NewProc("SetProcessDpiAwareness")
+	user32                        = syscall.NewLazyDLL("user32.dll")
+	setProcessDPIAware            = user32.NewProc("SetProcessDPIAware")
+	setProcessDpiAwarenessContext = user32.NewProc("SetProcessDpiAwarenessContext")
+	shcore                        = syscall.NewLazyDLL("shcore.dll")
+	setProcessDpiAwareness        = shcore.NewProc("SetProcessDpiAwareness")
 )
 
 // DPI awareness levels
@@ -19,11 +20,28 @@ const (
 	PROCESS_PER_MONITOR_DPI_AWARE = 2
 )
 
-// SetProcessDpiAwareness enables high DPI support for the application
+// DPI awareness contexts are pseudo handles with negative values
+const (
+	DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2 = -4
+)
+
+// SetProcessDpiAwareness enables per-monitor high DPI support for the application.
+// The manifest already requests it, so these calls only matter when the manifest is missing.
 func SetProcessDpiAwareness() {
+	// For Windows 10 1703 and later
+	if setProcessDpiAwarenessContext.Find() == nil {
+		context := DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2
+		if ret, _, _ := setProcessDpiAwarenessContext.Call(uintptr(context)); ret != 0 {
+			return
+		}
+	}
+
 	// For Windows 8.1
-	_, _, _ = setProcessDpiAwareness.Call(PROCESS_SYSTEM_DPI_AWARE) // Use system DPI
-	
+	if setProcessDpiAwareness.Find() == nil {
+		_, _, _ = setProcessDpiAwareness.Call(PROCESS_PER_MONITOR_DPI_AWARE)
+		return
+	}
+
 	// Fallback method for Windows Vista and later
 	_, _, _ = setProcessDPIAware.Call()
-} 
\ No newline at end of file
+}
@@ -0,0 +1,53 @@
+package geometry
+
+// DefaultDPI is the DPI of a monitor at 100% scaling, where logical and physical pixels are equal
+const DefaultDPI = 96
+
+// ToLogical converts a length in physical pixels at the given DPI to DPI-independent pixels
+func ToLogical(value, dpi int) int {
+	return Scale(value, dpi, DefaultDPI)
+}
+
+// ToPhysical converts a length in DPI-independent pixels to physical pixels at the given DPI
+func ToPhysical(value, dpi int) int {
+	return Scale(value, DefaultDPI, dpi)
+}
+
+// Scale converts a length between two DPIs, rounding to the nearest pixel.
+// An unknown DPI of zero or less is treated as DefaultDPI.
+func Scale(value, fromDPI, toDPI int) int {
+	fromDPI, toDPI = normalizeDPI(fromDPI), normalizeDPI(toDPI)
+	if fromDPI == toDPI {
+		return value
+	}
+
+	// Round half away from zero
+	scaled := value * toDPI
+	if scaled < 0 {
+		return -((-scaled + fromDPI/2) / fromDPI)
+	}
+	return (scaled + fromDPI/2) / fromDPI
+}
+
+// ScaleSize returns the rectangle with its size converted between two DPIs.
+// The position is kept since screen coordinates are the same for all DPIs.
+func ScaleSize(r Rect, fromDPI, toDPI int) Rect {
+	r.Width = Scale(r.Width, fromDPI, toDPI)
+	r.Height = Scale(r.Height, fromDPI, toDPI)
+	return r
+}
+
+// ToLogicalSize returns the placement with its size in DPI-independent pixels
+func (p Placement) ToLogicalSize() Placement {
+	p.Bounds = ScaleSize(p.Bounds, p.DPI, DefaultDPI)
+	p.DPI = DefaultDPI
+	return p
+}
+
+// normalizeDPI replaces an unknown DPI with DefaultDPI
+func normalizeDPI(dpi int) int {
+	if dpi <= 0 {
+		return DefaultDPI
+	}
+	return dpi
+}
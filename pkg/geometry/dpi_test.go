@@ -0,0 +1,73 @@
+package geometry
+
+import "testing"
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		value, from, to int
+		want            int
+	}{
+		{800, 96, 96, 800},
+		{800, 96, 144, 1200},
+		{1200, 144, 96, 800},
+		{1001, 144, 96, 667}, // 667.33 rounds down
+		{1000, 144, 96, 667}, // 666.67 rounds up
+		{3, 192, 96, 2},      // 1.5 rounds away from zero
+		{-3, 192, 96, -2},
+		{-100, 96, 120, -125},
+		{800, 0, 144, 1200}, // Unknown DPIs are 96
+		{800, 144, -1, 533},
+		{0, 96, 144, 0},
+	}
+	for _, tt := range tests {
+		if got := Scale(tt.value, tt.from, tt.to); got != tt.want {
+			t.Errorf("Scale(%d, %d, %d) = %d, want %d", tt.value, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestLogicalPhysical(t *testing.T) {
+	tests := []struct {
+		physical, dpi, logical int
+	}{
+		{1920, 96, 1920},
+		{1920, 144, 1280},
+		{2400, 240, 960},
+		{1500, 120, 1200},
+		{1280, 0, 1280},
+	}
+	for _, tt := range tests {
+		if got := ToLogical(tt.physical, tt.dpi); got != tt.logical {
+			t.Errorf("ToLogical(%d, %d) = %d, want %d", tt.physical, tt.dpi, got, tt.logical)
+		}
+		if got := ToPhysical(tt.logical, tt.dpi); got != tt.physical {
+			t.Errorf("ToPhysical(%d, %d) = %d, want %d", tt.logical, tt.dpi, got, tt.physical)
+		}
+	}
+}
+
+func TestRoundTripStaysWithinAPixel(t *testing.T) {
+	// Moving a window between monitors must not make it grow or shrink over time
+	for _, dpi := range []int{96, 120, 144, 168, 192, 240, 288} {
+		for logical := 200; logical <= 4000; logical += 37 {
+			back := ToLogical(ToPhysical(logical, dpi), dpi)
+			if back != logical {
+				t.Errorf("%d logical pixels at %d DPI come back as %d", logical, dpi, back)
+			}
+		}
+	}
+}
+
+func TestScaleSize(t *testing.T) {
+	r := Rect{X: -1500, Y: 200, Width: 800, Height: 600}
+	want := Rect{X: -1500, Y: 200, Width: 1200, Height: 900}
+	if got := ScaleSize(r, 96, 144); got != want {
+		t.Errorf("ScaleSize = %+v, want %+v, positions are screen coordinates", got, want)
+	}
+
+	p := Placement{Bounds: Rect{X: 10, Y: 20, Width: 1200, Height: 900}, DPI: 144, Maximized: true}
+	wantPlacement := Placement{Bounds: Rect{X: 10, Y: 20, Width: 800, Height: 600}, DPI: DefaultDPI, Maximized: true}
+	if got := p.ToLogicalSize(); got != wantPlacement {
+		t.Errorf("ToLogicalSize = %+v, want %+v", got, wantPlacement)
+	}
+}
@@ -0,0 +1,165 @@
+// Package geometry provides window placement calculations independent of the windowing system
+package geometry
+
+// Rect represents a rectangle in screen coordinates
+type Rect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Right returns the X coordinate just past the right edge
+func (r Rect) Right() int {
+	return r.X + r.Width
+}
+
+// Bottom returns the Y coordinate just past the bottom edge
+func (r Rect) Bottom() int {
+	return r.Y + r.Height
+}
+
+// Empty reports whether the rectangle has no area
+func (r Rect) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// Intersect returns the overlapping part of two rectangles
+func (r Rect) Intersect(other Rect) Rect {
+	x0, y0 := max(r.X, other.X), max(r.Y, other.Y)
+	x1, y1 := min(r.Right(), other.Right()), min(r.Bottom(), other.Bottom())
+	if x1 <= x0 || y1 <= y0 {
+		return Rect{}
+	}
+	return Rect{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+// Area returns the area of the rectangle
+func (r Rect) Area() int {
+	if r.Empty() {
+		return 0
+	}
+	return r.Width * r.Height
+}
+
+// Monitor describes a display attached to the system
+type Monitor struct {
+	ID       string // Stable device name, e.g. \\.\DISPLAY2
+	Bounds   Rect   // Full area of the monitor
+	WorkArea Rect   // Area not covered by the taskbar and docked bars
+	DPI      int    // Effective DPI, 0 if unknown
+	Primary  bool
+}
+
+// Placement describes the position and state of a window.
+// Positions are in screen coordinates, while the size is in pixels at DPI.
+type Placement struct {
+	Bounds    Rect   // Restored bounds, also kept while the window is maximized or minimized
+	Monitor   string // ID of the monitor the window is on
+	DPI       int    // DPI the size is measured in, 0 if unknown
+	Maximized bool
+	Minimized bool
+}
+
+// Clamp returns the placement moved so the window is fully visible on one of the monitors.
+// A window on a monitor that is no longer attached moves to the monitor it overlaps most,
+// or is centered on the primary monitor if it isn't visible at all.
+// The size is converted to the DPI of the chosen monitor when both DPIs are known.
+// A minimized window is restored normally, or maximized if it was maximized before.
+func Clamp(p Placement, monitors []Monitor) Placement {
+	// Starting minimized would leave the window hidden in the taskbar
+	p.Minimized = false
+
+	if len(monitors) == 0 {
+		return p
+	}
+
+	// Find the monitor to place the window on
+	monitor, found := FindMonitor(monitors, p.Monitor)
+	if !found {
+		monitor, found = monitorForRect(monitors, p.Bounds)
+	}
+	if !found {
+		monitor, _ = PrimaryMonitor(monitors)
+	}
+	p.Monitor = monitor.ID
+
+	// Convert the size to the DPI of the monitor
+	if monitor.DPI > 0 {
+		p.Bounds = ScaleSize(p.Bounds, p.DPI, monitor.DPI)
+		p.DPI = monitor.DPI
+	}
+
+	// Center a window that isn't visible on any monitor
+	if !found {
+		p.Bounds = centerIn(clampRect(p.Bounds, monitor.WorkArea), monitor.WorkArea)
+	}
+
+	p.Bounds = clampRect(p.Bounds, monitor.WorkArea)
+	return p
+}
+
+// FindMonitor returns the monitor with the given ID
+func FindMonitor(monitors []Monitor, id string) (Monitor, bool) {
+	if id == "" {
+		return Monitor{}, false
+	}
+	for _, m := range monitors {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return Monitor{}, false
+}
+
+// monitorForRect returns the monitor with the largest overlap with the rectangle
+func monitorForRect(monitors []Monitor, r Rect) (Monitor, bool) {
+	best, bestArea := Monitor{}, 0
+	for _, m := range monitors {
+		if area := r.Intersect(m.Bounds).Area(); area > bestArea {
+			best, bestArea = m, area
+		}
+	}
+	return best, bestArea > 0
+}
+
+// PrimaryMonitor returns the primary monitor, or the first one if none is marked primary.
+// The second result is false if there are no monitors.
+func PrimaryMonitor(monitors []Monitor) (Monitor, bool) {
+	for _, m := range monitors {
+		if m.Primary {
+			return m, true
+		}
+	}
+	if len(monitors) == 0 {
+		return Monitor{}, false
+	}
+	return monitors[0], true
+}
+
+// centerIn returns the rectangle centered in the area
+func centerIn(r, area Rect) Rect {
+	r.X = area.X + (area.Width-r.Width)/2
+	r.Y = area.Y + (area.Height-r.Height)/2
+	return r
+}
+
+// clampRect shrinks the rectangle to fit the area and moves it inside
+func clampRect(r, area Rect) Rect {
+	if area.Empty() {
+		return r
+	}
+
+	// Fit the size into the area
+	if r.Width <= 0 || r.Width > area.Width {
+		r.Width = area.Width
+	}
+	if r.Height <= 0 || r.Height > area.Height {
+		r.Height = area.Height
+	}
+
+	// Move the rectangle inside the area
+	r.X = min(max(r.X, area.X), area.Right()-r.Width)
+	r.Y = min(max(r.Y, area.Y), area.Bottom()-r.Height)
+	return r
+}
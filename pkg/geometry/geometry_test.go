@@ -0,0 +1,119 @@
+package geometry
+
+import "testing"
+
+// Two monitors side by side: a 100% primary with a taskbar and a 150% monitor on its right
+var testMonitors = []Monitor{
+	{
+		ID:       `\\.\DISPLAY1`,
+		Bounds:   Rect{X: 0, Y: 0, Width: 1920, Height: 1080},
+		WorkArea: Rect{X: 0, Y: 0, Width: 1920, Height: 1040},
+		DPI:      96,
+		Primary:  true,
+	},
+	{
+		ID:       `\\.\DISPLAY2`,
+		Bounds:   Rect{X: 1920, Y: 0, Width: 2560, Height: 1440},
+		WorkArea: Rect{X: 1920, Y: 0, Width: 2560, Height: 1440},
+		DPI:      144,
+	},
+}
+
+func TestClamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       Placement
+		monitors []Monitor
+		want     Placement
+	}{
+		{
+			name: "visible window is kept",
+			in:   Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+			want: Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+		},
+		{
+			name: "window below the taskbar moves up",
+			in:   Placement{Bounds: Rect{X: 100, Y: 700, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+			want: Placement{Bounds: Rect{X: 100, Y: 440, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+		},
+		{
+			name: "window larger than the work area shrinks",
+			in:   Placement{Bounds: Rect{X: -50, Y: -50, Width: 2500, Height: 1200}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+			want: Placement{Bounds: Rect{X: 0, Y: 0, Width: 1920, Height: 1040}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+		},
+		{
+			name: "size is converted to the monitor DPI",
+			in:   Placement{Bounds: Rect{X: 2000, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY2`, DPI: 96},
+			want: Placement{Bounds: Rect{X: 2000, Y: 100, Width: 1200, Height: 900}, Monitor: `\\.\DISPLAY2`, DPI: 144},
+		},
+		{
+			name: "unknown monitor falls back to the overlapping one",
+			in:   Placement{Bounds: Rect{X: 1800, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY9`, DPI: 96},
+			want: Placement{Bounds: Rect{X: 1920, Y: 100, Width: 1200, Height: 900}, Monitor: `\\.\DISPLAY2`, DPI: 144},
+		},
+		{
+			name: "window on a disconnected monitor is centered on the primary",
+			in:   Placement{Bounds: Rect{X: -2000, Y: 300, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY3`, DPI: 96},
+			want: Placement{Bounds: Rect{X: 560, Y: 220, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+		},
+		{
+			name: "maximized state is kept",
+			in:   Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96, Maximized: true},
+			want: Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96, Maximized: true},
+		},
+		{
+			name: "minimized window is restored normally",
+			in:   Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96, Minimized: true},
+			want: Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96},
+		},
+		{
+			name: "minimized maximized window is restored maximized",
+			in:   Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96, Minimized: true, Maximized: true},
+			want: Placement{Bounds: Rect{X: 100, Y: 100, Width: 800, Height: 600}, Monitor: `\\.\DISPLAY1`, DPI: 96, Maximized: true},
+		},
+		{
+			name:     "no monitors",
+			in:       Placement{Bounds: Rect{X: -5000, Y: 0, Width: 800, Height: 600}, Minimized: true},
+			monitors: []Monitor{},
+			want:     Placement{Bounds: Rect{X: -5000, Y: 0, Width: 800, Height: 600}},
+		},
+	}
+	for _, tt := range tests {
+		monitors := tt.monitors
+		if monitors == nil {
+			monitors = testMonitors
+		}
+		if got := Clamp(tt.in, monitors); got != tt.want {
+			t.Errorf("%s: Clamp = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrimaryMonitor(t *testing.T) {
+	if m, ok := PrimaryMonitor(testMonitors); !ok || m.ID != `\\.\DISPLAY1` {
+		t.Errorf("PrimaryMonitor = %v, %v", m.ID, ok)
+	}
+	if m, ok := PrimaryMonitor(testMonitors[1:]); !ok || m.ID != `\\.\DISPLAY2` {
+		t.Errorf("PrimaryMonitor without a primary = %v, %v", m.ID, ok)
+	}
+	if _, ok := PrimaryMonitor(nil); ok {
+		t.Error("PrimaryMonitor found a monitor in an empty list")
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	a := Rect{X: 0, Y: 0, Width: 100, Height: 100}
+	tests := []struct {
+		b    Rect
+		want Rect
+	}{
+		{Rect{X: 50, Y: 50, Width: 100, Height: 100}, Rect{X: 50, Y: 50, Width: 50, Height: 50}},
+		{Rect{X: 100, Y: 0, Width: 10, Height: 10}, Rect{}},
+		{Rect{X: -10, Y: 10, Width: 200, Height: 20}, Rect{X: 0, Y: 10, Width: 100, Height: 20}},
+	}
+	for _, tt := range tests {
+		if got := a.Intersect(tt.b); got != tt.want {
+			t.Errorf("Intersect(%+v) = %+v, want %+v", tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package instance makes sure only one instance of a site app runs at a time.
+// The first instance listens on a named pipe on Windows or a unix socket elsewhere,
+// and later launches forward their arguments to it and exit.
+package instance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyRunning is returned by Acquire when another instance holds the name
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// acceptRetryDelay is how long to wait after a failed accept
+const acceptRetryDelay = 100 * time.Millisecond
+
+// Handler handles a message from a new launch
+type Handler func(m Message) error
+
+// listener accepts connections from new launches
+type listener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// Instance is the running instance for a name
+type Instance struct {
+	listener listener
+	closeMu  sync.Mutex
+	closed   bool
+}
+
+// Acquire makes this process the running instance for the name, such as a site and profile.
+// It returns ErrAlreadyRunning if another process already is.
+func Acquire(name string) (*Instance, error) {
+	l, err := listen(instanceID(name))
+	if err != nil {
+		return nil, err
+	}
+	return &Instance{listener: l}, nil
+}
+
+// Serve handles messages until the instance is closed.
+// Each connection is handled on its own goroutine.
+func (i *Instance) Serve(handler Handler) {
+	for {
+		conn, err := i.listener.Accept()
+		if err != nil {
+			if i.isClosed() {
+				return
+			}
+			time.Sleep(acceptRetryDelay)
+			continue
+		}
+		go handleConn(conn, handler)
+	}
+}
+
+// Close stops accepting messages and releases the name
+func (i *Instance) Close() error {
+	i.closeMu.Lock()
+	if i.closed {
+		i.closeMu.Unlock()
+		return nil
+	}
+	i.closed = true
+	i.closeMu.Unlock()
+
+	return i.listener.Close()
+}
+
+// isClosed reports whether Close was called
+func (i *Instance) isClosed() bool {
+	i.closeMu.Lock()
+	defer i.closeMu.Unlock()
+	return i.closed
+}
+
+// Send delivers a message to the running instance for the name and waits for it to be handled
+func Send(name string, m Message) error {
+	conn, err := dial(instanceID(name))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := WriteMessage(conn, m); err != nil {
+		return err
+	}
+	return ReadReply(conn)
+}
+
+// handleConn reads a message, passes it to the handler and replies with the result
+func handleConn(conn io.ReadWriteCloser, handler Handler) {
+	defer conn.Close()
+
+	reply := Reply{Version: ProtocolVersion, OK: true}
+	m, err := ReadMessage(conn)
+	if err == nil {
+		err = handler(m)
+	}
+	if err != nil {
+		reply.OK = false
+		reply.Error = err.Error()
+	}
+	WriteMessage(conn, reply)
+}
+
+// instanceID returns a name for the pipe or socket that is unique per user and safe to use in paths
+func instanceID(name string) string {
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(username) + "\x00" + strings.ToLower(name)))
+	return "hobaa-" + hex.EncodeToString(sum[:8])
+}
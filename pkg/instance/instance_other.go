@@ -0,0 +1,60 @@
+//go:build !windows
+
+package instance
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// unixListener accepts connections on a unix socket
+type unixListener struct {
+	net.Listener
+}
+
+// Accept waits for the next connection
+func (l unixListener) Accept() (io.ReadWriteCloser, error) {
+	return l.Listener.Accept()
+}
+
+// listen listens on the unix socket for the instance
+func listen(id string) (listener, error) {
+	path := socketPath(id)
+	l, err := net.Listen("unix", path)
+	if err == nil {
+		return unixListener{l}, nil
+	}
+
+	// The socket exists, check whether an instance is listening on it
+	if conn, dialErr := net.Dial("unix", path); dialErr == nil {
+		conn.Close()
+		return nil, ErrAlreadyRunning
+	}
+
+	// Remove the socket of an instance that didn't exit cleanly and try again
+	if removeErr := os.Remove(path); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+		return nil, err
+	}
+	l, err = net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return unixListener{l}, nil
+}
+
+// dial connects to the unix socket of the running instance
+func dial(id string) (io.ReadWriteCloser, error) {
+	return net.Dial("unix", socketPath(id))
+}
+
+// socketPath returns the path of the unix socket for the instance
+func socketPath(id string) string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, id+".sock")
+}
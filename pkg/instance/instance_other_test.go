@@ -0,0 +1,148 @@
+//go:build !windows
+
+package instance
+
+import (
+	"errors"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// acquire makes the test the running instance for the name, with sockets in a temporary directory
+func acquire(t *testing.T, name string) *Instance {
+	t.Helper()
+	inst, err := Acquire(name)
+	if err != nil {
+		t.Fatalf("Acquire(%q): %v", name, err)
+	}
+	t.Cleanup(func() { inst.Close() })
+	return inst
+}
+
+func TestSingleInstance(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	inst := acquire(t, "github")
+
+	messages := make(chan Message, 1)
+	go inst.Serve(func(m Message) error {
+		messages <- m
+		return nil
+	})
+
+	// A second launch finds the running instance and forwards its arguments
+	if _, err := Acquire("github"); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second Acquire error = %v, want ErrAlreadyRunning", err)
+	}
+	if _, err := Acquire("GitHub"); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("names differing in case got separate instances: %v", err)
+	}
+	sent := NewActivateMessage([]string{"hobaa://navigate?url=https%3A%2F%2Fgithub.com%2Fpulls"}, "/home/me")
+	if err := Send("github", sent); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case got := <-messages:
+		if !reflect.DeepEqual(got, sent) {
+			t.Errorf("handler got %+v, want %+v", got, sent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler wasn't called")
+	}
+
+	// Other names are separate instances
+	acquire(t, "github@work")
+}
+
+func TestSendReportsHandlerErrors(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	inst := acquire(t, "gmail")
+	go inst.Serve(func(m Message) error {
+		return errors.New("profile is being migrated")
+	})
+
+	err := Send("gmail", NewActivateMessage(nil, ""))
+	if err == nil || err.Error() != "profile is being migrated" {
+		t.Errorf("Send error = %v, want the handler error", err)
+	}
+}
+
+func TestServeRejectsNewerVersions(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	inst := acquire(t, "gmail")
+	called := make(chan struct{}, 1)
+	go inst.Serve(func(m Message) error {
+		called <- struct{}{}
+		return nil
+	})
+
+	m := NewActivateMessage(nil, "")
+	m.Version = ProtocolVersion + 1
+	err := Send("gmail", m)
+	if err == nil || !strings.Contains(err.Error(), ErrUnsupportedVersion.Error()) {
+		t.Errorf("Send error = %v, want an unsupported version", err)
+	}
+	select {
+	case <-called:
+		t.Error("handler was called for a message of a newer version")
+	default:
+	}
+}
+
+func TestSendWithoutInstance(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := Send("github", NewActivateMessage(nil, "")); err == nil {
+		t.Error("Send succeeded without a running instance")
+	}
+}
+
+func TestAcquireAfterClose(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	inst, err := Acquire("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		inst.Serve(func(Message) error { return nil })
+		close(done)
+	}()
+
+	if err := inst.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after Close")
+	}
+	acquire(t, "github")
+}
+
+func TestAcquireRemovesStaleSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	// Leave a socket behind as a crashed instance would
+	path := socketPath(instanceID("github"))
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("stale socket wasn't left behind: %v", err)
+	}
+
+	inst := acquire(t, "github")
+	go inst.Serve(func(Message) error { return nil })
+	if err := Send("github", NewActivateMessage(nil, "")); err != nil {
+		t.Errorf("Send to the new instance: %v", err)
+	}
+}
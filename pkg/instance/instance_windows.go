@@ -0,0 +1,114 @@
+package instance
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/kemalersin/hobaa/pkg/winapi"
+)
+
+// dialTimeout is how long to wait in milliseconds for the running instance to accept a connection
+const dialTimeout = 5000
+
+// pipeListener accepts connections on a named pipe.
+// A free pipe instance always exists, so no other process can create the pipe between connections.
+type pipeListener struct {
+	name      string
+	mu        sync.Mutex
+	handle    syscall.Handle
+	accepting bool
+	closed    bool
+}
+
+// listen creates the named pipe for the instance
+func listen(id string) (listener, error) {
+	name := pipeName(id)
+	handle, err := winapi.CreateNamedPipe(name, true)
+	if err != nil {
+		if errors.Is(err, winapi.ERROR_ACCESS_DENIED) || errors.Is(err, winapi.ERROR_PIPE_BUSY) {
+			return nil, ErrAlreadyRunning
+		}
+		return nil, err
+	}
+	return &pipeListener{name: name, handle: handle}, nil
+}
+
+// Accept waits for the next connection
+func (l *pipeListener) Accept() (io.ReadWriteCloser, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, errors.New("listener is closed")
+	}
+	handle := l.handle
+	l.accepting = true
+	l.mu.Unlock()
+
+	err := winapi.ConnectNamedPipe(handle)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.accepting = false
+	if l.closed {
+		syscall.CloseHandle(handle)
+		return nil, errors.New("listener is closed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the instance for the next connection before handing this one out
+	next, err := winapi.CreateNamedPipe(l.name, false)
+	if err != nil {
+		syscall.CloseHandle(handle)
+		return nil, err
+	}
+	l.handle = next
+	return os.NewFile(uintptr(handle), l.name), nil
+}
+
+// Close closes the free pipe instance, which releases the pipe name
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	handle, accepting := l.handle, l.accepting
+	l.mu.Unlock()
+
+	// Connect once to wake up a pending Accept, which closes the handle
+	if accepting {
+		if conn, err := dialPipe(l.name); err == nil {
+			conn.Close()
+		}
+		return nil
+	}
+	return syscall.CloseHandle(handle)
+}
+
+// dial connects to the named pipe of the running instance
+func dial(id string) (io.ReadWriteCloser, error) {
+	return dialPipe(pipeName(id))
+}
+
+// dialPipe connects to a named pipe
+func dialPipe(name string) (io.ReadWriteCloser, error) {
+	// Allow the running instance to bring its window to the front
+	winapi.AllowSetForegroundWindow()
+
+	handle, err := winapi.OpenNamedPipe(name, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), name), nil
+}
+
+// pipeName returns the name of the named pipe for the instance
+func pipeName(id string) string {
+	return `\\.\pipe\` + id
+}
@@ -0,0 +1,111 @@
+package instance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the version of the message format.
+// Fields may be added without a new version since unknown fields are ignored;
+// the version changes only when existing fields change meaning.
+const ProtocolVersion = 1
+
+// MaxMessageSize is the largest encoded message accepted
+const MaxMessageSize = 64 * 1024
+
+// Commands sent to the running instance
+const (
+	// CommandActivate focuses the running instance and passes the arguments of a new launch
+	CommandActivate = "activate"
+)
+
+// ErrUnsupportedVersion is returned for messages of a newer protocol version
+var ErrUnsupportedVersion = errors.New("unsupported instance protocol version")
+
+// Message is sent by a new launch to the running instance.
+// Messages are encoded as a single line of JSON.
+type Message struct {
+	Version int      `json:"version"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	WorkDir string   `json:"work_dir,omitempty"`
+}
+
+// Reply is sent back by the running instance after handling a message
+type Reply struct {
+	Version int    `json:"version"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewActivateMessage creates a message activating the running instance with the arguments of a new launch
+func NewActivateMessage(args []string, workDir string) Message {
+	return Message{
+		Version: ProtocolVersion,
+		Command: CommandActivate,
+		Args:    args,
+		WorkDir: workDir,
+	}
+}
+
+// WriteMessage writes a message or reply as a line of JSON
+func WriteMessage(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(data) >= MaxMessageSize {
+		return fmt.Errorf("message is too large")
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadMessage reads and validates a message
+func ReadMessage(r io.Reader) (Message, error) {
+	var m Message
+	if err := readLine(r, &m); err != nil {
+		return Message{}, err
+	}
+	if m.Version < 1 || m.Version > ProtocolVersion {
+		return Message{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, m.Version)
+	}
+	if m.Command == "" {
+		return Message{}, fmt.Errorf("message has no command")
+	}
+	return m, nil
+}
+
+// ReadReply reads a reply and returns the error it reports
+func ReadReply(r io.Reader) error {
+	var reply Reply
+	if err := readLine(r, &reply); err != nil {
+		return err
+	}
+	if !reply.OK {
+		if reply.Error == "" {
+			return fmt.Errorf("running instance rejected the message")
+		}
+		return errors.New(reply.Error)
+	}
+	return nil
+}
+
+// readLine reads a line of JSON into v
+func readLine(r io.Reader, v interface{}) error {
+	reader := bufio.NewReaderSize(io.LimitReader(r, MaxMessageSize), 4096)
+	line, err := reader.ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("connection closed before a complete message was received")
+		}
+		return err
+	}
+	if err := json.Unmarshal(line, v); err != nil {
+		return fmt.Errorf("invalid message: %v", err)
+	}
+	return nil
+}
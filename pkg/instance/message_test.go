@@ -0,0 +1,132 @@
+package instance
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	m := NewActivateMessage([]string{"--profile", "work", "hobaa://open?url=https%3A%2F%2Fgithub.com"}, `C:\Users\me`)
+
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if data := buf.String(); !strings.HasSuffix(data, "\n") || strings.Count(data, "\n") != 1 {
+		t.Errorf("message is not a single line: %q", data)
+	}
+
+	got, err := ReadMessage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("ReadMessage = %+v, want %+v", got, m)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Message
+		wantErr string
+	}{
+		{
+			name:  "activate",
+			input: `{"version":1,"command":"activate","args":["a","b"],"work_dir":"/tmp"}` + "\n",
+			want:  Message{Version: 1, Command: "activate", Args: []string{"a", "b"}, WorkDir: "/tmp"},
+		},
+		{
+			name:  "unknown fields are ignored",
+			input: `{"version":1,"command":"activate","window":{"x":1},"priority":"high"}` + "\n",
+			want:  Message{Version: 1, Command: "activate"},
+		},
+		{
+			name:  "unknown commands are left to the handler",
+			input: `{"version":1,"command":"reload"}` + "\n",
+			want:  Message{Version: 1, Command: "reload"},
+		},
+		{
+			name:  "last line without a newline",
+			input: `{"version":1,"command":"activate"}`,
+			want:  Message{Version: 1, Command: "activate"},
+		},
+		{
+			name:  "only the first line is read",
+			input: `{"version":1,"command":"activate"}` + "\n" + `{"version":1,"command":"other"}` + "\n",
+			want:  Message{Version: 1, Command: "activate"},
+		},
+		{name: "newer version", input: `{"version":2,"command":"activate"}` + "\n", wantErr: "unsupported"},
+		{name: "missing version", input: `{"command":"activate"}` + "\n", wantErr: "unsupported"},
+		{name: "missing command", input: `{"version":1}` + "\n", wantErr: "no command"},
+		{name: "invalid JSON", input: "activate github\n", wantErr: "invalid message"},
+		{name: "wrong type", input: `{"version":"1","command":"activate"}` + "\n", wantErr: "invalid message"},
+		{name: "empty", input: "", wantErr: "connection closed"},
+		{name: "too large", input: `{"version":1,"command":"activate","args":["` + strings.Repeat("a", MaxMessageSize) + `"]}` + "\n", wantErr: "invalid message"},
+	}
+	for _, tt := range tests {
+		got, err := ReadMessage(strings.NewReader(tt.input))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: ReadMessage error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ReadMessage: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ReadMessage = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadMessageNewerVersion(t *testing.T) {
+	_, err := ReadMessage(strings.NewReader(`{"version":2,"command":"activate"}` + "\n"))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("ReadMessage error = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`{"version":1,"ok":true}` + "\n", ""},
+		{`{"version":1,"ok":true,"window":"focused"}` + "\n", ""},
+		{`{"version":1,"ok":false,"error":"profile not found"}` + "\n", "profile not found"},
+		{`{"version":1,"ok":false}` + "\n", "rejected"},
+		{`{"version":1}` + "\n", "rejected"},
+		{"", "connection closed"},
+		{"ok\n", "invalid message"},
+	}
+	for _, tt := range tests {
+		err := ReadReply(strings.NewReader(tt.input))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ReadReply(%q): %v", tt.input, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ReadReply(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteMessageTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewActivateMessage([]string{strings.Repeat("a", MaxMessageSize)}, "")
+	if err := WriteMessage(&buf, m); err == nil {
+		t.Error("WriteMessage accepted a message larger than MaxMessageSize")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteMessage wrote %d bytes of a rejected message", buf.Len())
+	}
+}
@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Fake is a Notifier that keeps notifications in memory.
+// It is used where there is no desktop, and lets clicks and permission answers be simulated.
+type Fake struct {
+	// Allow is the answer to permission requests
+	Allow bool
+
+	mu       sync.Mutex
+	shown    []Notification
+	onClick  map[string]func()
+	requests []string
+}
+
+// Show records the notification
+func (f *Fake) Show(n Notification, onClick func()) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.onClick == nil {
+		f.onClick = map[string]func(){}
+	}
+	f.shown = append(f.shown, n)
+	f.onClick[n.ID] = onClick
+	return nil
+}
+
+// Hide removes the notification from the shown notifications
+func (f *Fake) Hide(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, n := range f.shown {
+		if n.ID == id {
+			f.shown = append(f.shown[:i], f.shown[i+1:]...)
+			break
+		}
+	}
+	delete(f.onClick, id)
+	return nil
+}
+
+// RequestPermission records the request and answers with Allow
+func (f *Fake) RequestPermission(title string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, title)
+	return f.Allow, nil
+}
+
+// Shown returns the notifications that are still shown
+func (f *Fake) Shown() []Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Notification(nil), f.shown...)
+}
+
+// Requests returns the titles of the sites that asked for permission
+func (f *Fake) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.requests...)
+}
+
+// Click simulates the user clicking a shown notification
+func (f *Fake) Click(id string) error {
+	f.mu.Lock()
+	onClick, ok := f.onClick[id]
+	f.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("notification %q is not shown", id)
+	}
+	if onClick != nil {
+		onClick()
+	}
+	return nil
+}
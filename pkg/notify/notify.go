@@ -0,0 +1,163 @@
+// Package notify shows the notifications of web pages as native desktop notifications.
+// Pages use the standard Notification API, which a polyfill forwards to a Manager.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNotAllowed is returned when a site shows a notification without permission
+var ErrNotAllowed = errors.New("notifications are not allowed for this site")
+
+// Notification is a notification shown by a page
+type Notification struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// Notifier shows notifications on the desktop
+type Notifier interface {
+	// Show displays a notification and calls onClick when the user clicks it
+	Show(n Notification, onClick func()) error
+
+	// Hide removes a notification if it is still shown
+	Hide(id string) error
+
+	// RequestPermission asks the user whether the site with the given title may show notifications
+	RequestPermission(title string) (bool, error)
+}
+
+// PermissionStore keeps the notification decision of each site
+type PermissionStore interface {
+	NotificationPermission(site string) Permission
+	SetNotificationPermission(site string, p Permission) error
+}
+
+// Manager handles the notifications of a site
+type Manager struct {
+	notifier    Notifier
+	permissions PermissionStore
+	site        string
+	title       string
+	dispatch    func(f func())
+	onClick     func(id string)
+
+	mu        sync.Mutex
+	prompting bool
+	shown     map[string]string // Tag to ID of the notification showing it
+}
+
+// NewManager creates a manager for the site, which is called title in the permission prompt.
+// dispatch runs a function on the UI thread of the window owning the prompt, after the current event is handled.
+// onClick is called with the ID of a notification the user clicked.
+func NewManager(notifier Notifier, permissions PermissionStore, site, title string, dispatch func(f func()), onClick func(id string)) *Manager {
+	return &Manager{
+		notifier:    notifier,
+		permissions: permissions,
+		site:        site,
+		title:       title,
+		dispatch:    dispatch,
+		onClick:     onClick,
+		shown:       map[string]string{},
+	}
+}
+
+// Permission returns the stored decision of the site
+func (m *Manager) Permission() Permission {
+	return m.permissions.NotificationPermission(m.site)
+}
+
+// RequestPermission returns the decision of the site, or asks the user if there is none yet.
+// The prompt is modal, so it is shown through dispatch instead of inside the calling event handler,
+// and RequestPermission returns PermissionDefault right away. done is then called on the UI thread
+// with the answer, which is PermissionDefault if the prompt failed.
+// Only one prompt is shown at a time; requests made while it is open return PermissionDefault without calling done.
+func (m *Manager) RequestPermission(done func(p Permission, err error)) Permission {
+	if p := m.Permission(); p != PermissionDefault {
+		return p
+	}
+
+	m.mu.Lock()
+	if m.prompting {
+		m.mu.Unlock()
+		return PermissionDefault
+	}
+	m.prompting = true
+	m.mu.Unlock()
+
+	m.dispatch(func() {
+		p, err := m.prompt()
+		m.mu.Lock()
+		m.prompting = false
+		m.mu.Unlock()
+		if done != nil {
+			done(p, err)
+		}
+	})
+	return PermissionDefault
+}
+
+// prompt asks the user unless the site got a decision in the meantime, and stores the answer
+func (m *Manager) prompt() (Permission, error) {
+	if p := m.Permission(); p != PermissionDefault {
+		return p, nil
+	}
+	allowed, err := m.notifier.RequestPermission(m.title)
+	if err != nil {
+		return PermissionDefault, fmt.Errorf("failed to ask for permission: %v", err)
+	}
+	p := PermissionDenied
+	if allowed {
+		p = PermissionGranted
+	}
+	if err := m.permissions.SetNotificationPermission(m.site, p); err != nil {
+		return p, fmt.Errorf("failed to save permission: %v", err)
+	}
+	return p, nil
+}
+
+// Show displays a notification if the site has permission.
+// A notification with the same tag as a shown one replaces it, as in browsers.
+func (m *Manager) Show(n Notification) error {
+	if m.Permission() != PermissionGranted {
+		return ErrNotAllowed
+	}
+	if n.ID == "" || n.Title == "" {
+		return fmt.Errorf("notification id and title are required")
+	}
+
+	// Replace the notification with the same tag
+	m.mu.Lock()
+	previous := ""
+	if n.Tag != "" {
+		previous = m.shown[n.Tag]
+		m.shown[n.Tag] = n.ID
+	}
+	m.mu.Unlock()
+	if previous != "" {
+		m.notifier.Hide(previous)
+	}
+
+	id := n.ID
+	return m.notifier.Show(n, func() {
+		if m.onClick != nil {
+			m.onClick(id)
+		}
+	})
+}
+
+// Close removes a notification shown by the page
+func (m *Manager) Close(id string) error {
+	m.mu.Lock()
+	for tag, shown := range m.shown {
+		if shown == id {
+			delete(m.shown, tag)
+		}
+	}
+	m.mu.Unlock()
+	return m.notifier.Hide(id)
+}
@@ -0,0 +1,268 @@
+package notify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// memoryPermissions keeps permissions in memory
+type memoryPermissions struct {
+	values map[string]Permission
+	err    error
+}
+
+func (s *memoryPermissions) NotificationPermission(site string) Permission {
+	if p, ok := s.values[site]; ok {
+		return p
+	}
+	return PermissionDefault
+}
+
+func (s *memoryPermissions) SetNotificationPermission(site string, p Permission) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.values == nil {
+		s.values = map[string]Permission{}
+	}
+	s.values[site] = p
+	return nil
+}
+
+// uiQueue collects dispatched functions until the test runs them, as the UI thread does after an event
+type uiQueue struct {
+	pending []func()
+}
+
+func (q *uiQueue) dispatch(f func()) {
+	q.pending = append(q.pending, f)
+}
+
+func (q *uiQueue) run() {
+	for len(q.pending) > 0 {
+		f := q.pending[0]
+		q.pending = q.pending[1:]
+		f()
+	}
+}
+
+// answers records the permissions passed to the done function of RequestPermission
+type answers []Permission
+
+func (a *answers) done(p Permission, err error) {
+	*a = append(*a, p)
+}
+
+func TestRequestPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   Permission
+		allow    bool
+		want     Permission // Returned by RequestPermission
+		answer   []Permission
+		prompted bool
+	}{
+		{name: "allowed", stored: PermissionDefault, allow: true, want: PermissionDefault, answer: []Permission{PermissionGranted}, prompted: true},
+		{name: "denied", stored: PermissionDefault, allow: false, want: PermissionDefault, answer: []Permission{PermissionDenied}, prompted: true},
+		{name: "already granted", stored: PermissionGranted, allow: false, want: PermissionGranted},
+		{name: "already denied", stored: PermissionDenied, allow: true, want: PermissionDenied},
+	}
+	for _, tt := range tests {
+		notifier := &Fake{Allow: tt.allow}
+		store := &memoryPermissions{values: map[string]Permission{"gmail": tt.stored}}
+		var queue uiQueue
+		m := NewManager(notifier, store, "gmail", "Gmail", queue.dispatch, nil)
+
+		var got answers
+		if p := m.RequestPermission(got.done); p != tt.want {
+			t.Errorf("%s: RequestPermission = %s, want %s", tt.name, p, tt.want)
+		}
+		if len(notifier.Requests()) != 0 {
+			t.Errorf("%s: prompt shown inside the calling handler", tt.name)
+		}
+
+		queue.run()
+		if prompted := len(notifier.Requests()) == 1; prompted != tt.prompted {
+			t.Errorf("%s: prompted %v, want %v", tt.name, notifier.Requests(), tt.prompted)
+		}
+		if !reflect.DeepEqual([]Permission(got), tt.answer) {
+			t.Errorf("%s: answered %v, want %v", tt.name, got, tt.answer)
+		}
+		if tt.prompted && store.values["gmail"] != tt.answer[0] {
+			t.Errorf("%s: stored %s, want %s", tt.name, store.values["gmail"], tt.answer[0])
+		}
+	}
+}
+
+func TestRequestPermissionPerSite(t *testing.T) {
+	notifier := &Fake{Allow: true}
+	store := &memoryPermissions{}
+	var queue uiQueue
+	gmail := NewManager(notifier, store, "gmail", "Gmail", queue.dispatch, nil)
+	slack := NewManager(notifier, store, "slack", "Slack", queue.dispatch, nil)
+
+	gmail.RequestPermission(nil)
+	queue.run()
+	if gmail.Permission() != PermissionGranted {
+		t.Errorf("gmail permission = %s, want granted", gmail.Permission())
+	}
+	if slack.Permission() != PermissionDefault {
+		t.Errorf("slack got the permission of gmail: %s", slack.Permission())
+	}
+	if err := slack.Show(Notification{ID: "1", Title: "New message"}); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("slack showed a notification without permission: %v", err)
+	}
+
+	// Each site is asked with its own title
+	notifier.Allow = false
+	slack.RequestPermission(nil)
+	queue.run()
+	if want := []string{"Gmail", "Slack"}; !reflect.DeepEqual(notifier.Requests(), want) {
+		t.Errorf("prompts %v, want %v", notifier.Requests(), want)
+	}
+	if gmail.Permission() != PermissionGranted || slack.Permission() != PermissionDenied {
+		t.Errorf("permissions gmail=%s slack=%s, want granted and denied", gmail.Permission(), slack.Permission())
+	}
+}
+
+func TestRequestPermissionPromptsOnce(t *testing.T) {
+	notifier := &Fake{Allow: true}
+	store := &memoryPermissions{}
+	var queue uiQueue
+	m := NewManager(notifier, store, "gmail", "Gmail", queue.dispatch, nil)
+
+	// Requests made while the prompt is pending don't open another one
+	var first, second answers
+	m.RequestPermission(first.done)
+	if p := m.RequestPermission(second.done); p != PermissionDefault {
+		t.Errorf("second request = %s, want default", p)
+	}
+	queue.run()
+	if len(notifier.Requests()) != 1 {
+		t.Errorf("prompted %d times, want once", len(notifier.Requests()))
+	}
+	if !reflect.DeepEqual([]Permission(first), []Permission{PermissionGranted}) || len(second) != 0 {
+		t.Errorf("answers first=%v second=%v", first, second)
+	}
+
+	// The decision is kept after the prompt closed
+	if p := m.RequestPermission(nil); p != PermissionGranted || len(queue.pending) != 0 {
+		t.Errorf("request after the decision = %s with %d prompts queued", p, len(queue.pending))
+	}
+}
+
+func TestRequestPermissionDecidedMeanwhile(t *testing.T) {
+	// Another window of the site answered before the queued prompt ran
+	notifier := &Fake{Allow: true}
+	store := &memoryPermissions{}
+	var queue uiQueue
+	m := NewManager(notifier, store, "gmail", "Gmail", queue.dispatch, nil)
+
+	var got answers
+	m.RequestPermission(got.done)
+	store.SetNotificationPermission("gmail", PermissionDenied)
+	queue.run()
+	if len(notifier.Requests()) != 0 {
+		t.Error("prompted although the site already had a decision")
+	}
+	if !reflect.DeepEqual([]Permission(got), []Permission{PermissionDenied}) {
+		t.Errorf("answered %v, want denied", got)
+	}
+}
+
+func TestRequestPermissionSaveError(t *testing.T) {
+	notifier := &Fake{Allow: true}
+	store := &memoryPermissions{err: errors.New("disk full")}
+	var queue uiQueue
+	m := NewManager(notifier, store, "gmail", "Gmail", queue.dispatch, nil)
+
+	var gotErr error
+	m.RequestPermission(func(p Permission, err error) { gotErr = err })
+	queue.run()
+	if gotErr == nil {
+		t.Error("save error wasn't reported")
+	}
+
+	// A failed prompt doesn't block later ones
+	store.err = nil
+	m.RequestPermission(nil)
+	queue.run()
+	if len(notifier.Requests()) != 2 || m.Permission() != PermissionGranted {
+		t.Errorf("second prompt: %v, %s", notifier.Requests(), m.Permission())
+	}
+}
+
+func TestShow(t *testing.T) {
+	notifier := &Fake{}
+	store := &memoryPermissions{values: map[string]Permission{"gmail": PermissionGranted}}
+	var clicked []string
+	m := NewManager(notifier, store, "gmail", "Gmail", func(f func()) { f() }, func(id string) {
+		clicked = append(clicked, id)
+	})
+
+	if err := m.Show(Notification{ID: "1", Title: "Mail", Tag: "inbox"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Show(Notification{ID: "2", Title: "Chat"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A notification with the same tag replaces the shown one
+	if err := m.Show(Notification{ID: "3", Title: "More mail", Tag: "inbox"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(notifier.Shown()); !reflect.DeepEqual(got, []string{"2", "3"}) {
+		t.Errorf("shown %v, want [2 3]", got)
+	}
+
+	if err := notifier.Click("3"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(clicked, []string{"3"}) {
+		t.Errorf("clicked %v, want [3]", clicked)
+	}
+
+	if err := m.Close("2"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(notifier.Shown()); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Errorf("shown after close %v, want [3]", got)
+	}
+
+	for _, n := range []Notification{{Title: "No ID"}, {ID: "4"}} {
+		if err := m.Show(n); err == nil {
+			t.Errorf("Show(%+v) succeeded", n)
+		}
+	}
+}
+
+func TestParsePermission(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Permission
+		wantErr bool
+	}{
+		{"", PermissionDefault, false},
+		{"default", PermissionDefault, false},
+		{"granted", PermissionGranted, false},
+		{"denied", PermissionDenied, false},
+		{"Granted", PermissionDefault, true},
+		{"yes", PermissionDefault, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePermission(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParsePermission(%q) = %s, %v, want %s", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+// ids returns the IDs of notifications
+func ids(notifications []Notification) []string {
+	var result []string
+	for _, n := range notifications {
+		result = append(result, n.ID)
+	}
+	return result
+}
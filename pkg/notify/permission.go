@@ -0,0 +1,25 @@
+package notify
+
+import "fmt"
+
+// Permission is the decision of the user on showing notifications, as in the web Notification API
+type Permission string
+
+// Permission values
+const (
+	PermissionDefault Permission = "default" // The user hasn't decided yet
+	PermissionGranted Permission = "granted"
+	PermissionDenied  Permission = "denied"
+)
+
+// ParsePermission parses a permission value, treating an empty value as the default
+func ParsePermission(value string) (Permission, error) {
+	switch p := Permission(value); p {
+	case "", PermissionDefault:
+		return PermissionDefault, nil
+	case PermissionGranted, PermissionDenied:
+		return p, nil
+	default:
+		return PermissionDefault, fmt.Errorf("invalid permission %q", value)
+	}
+}
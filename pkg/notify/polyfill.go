@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// eventFunction is the page function Go calls with notification events
+const eventFunction = "__hobaaNotificationEvent"
+
+// PolyfillScript returns the JavaScript replacing window.Notification with notifications shown by Go
+// through the window.hobaa.notifications methods. permission is the decision known when the page loads.
+func PolyfillScript(permission Permission) string {
+	return fmt.Sprintf(polyfillScript, permission, eventFunction)
+}
+
+// EventScript returns the JavaScript delivering an event such as "click" to a notification of the page
+func EventScript(id, event string) string {
+	idJSON, _ := json.Marshal(id)
+	eventJSON, _ := json.Marshal(event)
+	return fmt.Sprintf("window.%s && window.%s(%s, %s);", eventFunction, eventFunction, idJSON, eventJSON)
+}
+
+// PermissionScript returns the JavaScript delivering the answer of a permission prompt to the page
+func PermissionScript(p Permission) string {
+	pJSON, _ := json.Marshal(p)
+	return fmt.Sprintf("window.%s && window.%s(null, 'permission', %s);", eventFunction, eventFunction, pJSON)
+}
+
+// polyfillScript implements the Notification API on top of window.hobaa.notifications
+const polyfillScript = `
+(function() {
+	const api = window.hobaa && window.hobaa.notifications;
+	if (!api) {
+		return;
+	}
+
+	let permission = %q;
+	const shown = new Map();
+	let waiting = [];
+	let answers = 0;
+	const prefix = Date.now().toString(36) + '-';
+	let nextID = 1;
+
+	// The decision may have changed in another window since the script was added
+	api.permission().then(p => { permission = p; }, () => {});
+
+	class HobaaNotification extends EventTarget {
+		#id;
+
+		constructor(title, options = {}) {
+			super();
+			if (arguments.length === 0) {
+				throw new TypeError("Failed to construct 'Notification': 1 argument required, but only 0 present.");
+			}
+			options = options || {};
+			this.title = String(title);
+			this.body = options.body === undefined ? '' : String(options.body);
+			this.tag = options.tag === undefined ? '' : String(options.tag);
+			this.icon = options.icon === undefined ? '' : String(options.icon);
+			this.data = options.data === undefined ? null : options.data;
+			this.onclick = this.onshow = this.onerror = this.onclose = null;
+
+			this.#id = prefix + nextID++;
+			shown.set(this.#id, this);
+			api.show(this.#id, this.title, {body: this.body, tag: this.tag}).then(
+				() => this._dispatch('show'),
+				() => {
+					shown.delete(this.#id);
+					this._dispatch('error');
+				});
+		}
+
+		close() {
+			if (shown.delete(this.#id)) {
+				api.close(this.#id).catch(() => {});
+				this._dispatch('close');
+			}
+		}
+
+		_dispatch(type) {
+			const event = new Event(type, {cancelable: type === 'click'});
+			const handler = this['on' + type];
+			if (typeof handler === 'function') {
+				handler.call(this, event);
+			}
+			this.dispatchEvent(event);
+		}
+
+		static get permission() {
+			return permission;
+		}
+
+		static requestPermission(callback) {
+			// Go shows the prompt after the call returns and reports the answer as a permission event,
+			// which may arrive before the call resolves
+			const answered = answers;
+			return api.requestPermission().then(p => {
+				if (p !== 'default') {
+					return p;
+				}
+				if (answers !== answered) {
+					return permission;
+				}
+				return new Promise(resolve => waiting.push(resolve));
+			}).then(p => {
+				permission = p;
+				if (typeof callback === 'function') {
+					callback(p);
+				}
+				return p;
+			});
+		}
+	}
+	Object.defineProperty(HobaaNotification, 'name', {value: 'Notification'});
+
+	// Events reported by Go, such as clicks on the desktop notification and the answer of a permission prompt
+	Object.defineProperty(window, %q, {
+		value: (id, type, value) => {
+			if (type === 'permission') {
+				permission = value;
+				answers++;
+				const resolvers = waiting;
+				waiting = [];
+				resolvers.forEach(resolve => resolve(value));
+				return;
+			}
+			const notification = shown.get(id);
+			if (!notification) {
+				return;
+			}
+			if (type === 'close') {
+				notification.close();
+			} else {
+				notification._dispatch(type);
+			}
+		},
+	});
+
+	Object.defineProperty(window, 'Notification', {value: HobaaNotification, writable: true, configurable: true});
+})();
+`
@@ -0,0 +1,55 @@
+// Package pagetitle builds window titles from the titles of pages.
+// Sites describe the window title with a text/template, and updates are throttled since pages
+// such as chat apps may change their title many times a second.
+package pagetitle
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest window title rendered, in characters
+const MaxLength = 256
+
+// Data is passed to title templates
+type Data struct {
+	PageTitle string // Title of the page, document.title
+	SiteTitle string // Title of the site in sites.json
+	Profile   string // Name of the profile, empty for the default profile
+}
+
+// Template renders window titles
+type Template struct {
+	tmpl *template.Template
+}
+
+// Parse parses a title template such as "{{.PageTitle}} — {{.SiteTitle}}".
+// Templates using unknown fields are rejected here rather than when a page changes its title.
+func Parse(text string) (*Template, error) {
+	tmpl, err := template.New("title_template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title template: %v", err)
+	}
+	t := &Template{tmpl: tmpl}
+	if _, err := t.Render(Data{PageTitle: "Page", SiteTitle: "Site", Profile: "profile"}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render renders the title, collapsing whitespace and shortening it to MaxLength characters
+func (t *Template) Render(data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render title template: %v", err)
+	}
+
+	title := strings.Join(strings.Fields(buf.String()), " ")
+	if utf8.RuneCountInString(title) > MaxLength {
+		title = string([]rune(title)[:MaxLength-1]) + "…"
+	}
+	return title, nil
+}
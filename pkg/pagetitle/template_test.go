@@ -0,0 +1,91 @@
+package pagetitle
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"{{.PageTitle",
+		"{{.Unknown}}",
+		"{{.PageTitle.Length}}",
+		"{{template \"missing\"}}",
+		"{{.PageTitle | upper}}",
+		"{{if}}{{end}}",
+	} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) succeeded", text)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		template string
+		data     Data
+		want     string
+	}{
+		{"{{.PageTitle}} — {{.SiteTitle}}", Data{PageTitle: "Inbox (3)", SiteTitle: "Gmail"}, "Inbox (3) — Gmail"},
+		{"{{.SiteTitle}}", Data{PageTitle: "Inbox", SiteTitle: "Gmail"}, "Gmail"},
+		{"Fixed title", Data{PageTitle: "Inbox"}, "Fixed title"},
+		{"", Data{PageTitle: "Inbox"}, ""},
+		{"{{.SiteTitle}}{{with .Profile}} ({{.}}){{end}}", Data{SiteTitle: "Gmail", Profile: "work"}, "Gmail (work)"},
+		{"{{.SiteTitle}}{{with .Profile}} ({{.}}){{end}}", Data{SiteTitle: "Gmail"}, "Gmail"},
+		{"{{if .PageTitle}}{{.PageTitle}} - {{end}}{{.SiteTitle}}", Data{SiteTitle: "Slack"}, "Slack"},
+		{"{{printf \"%.5s\" .PageTitle}}", Data{PageTitle: "Notifications"}, "Notif"},
+
+		// Whitespace of the page title and the template is collapsed
+		{"{{.PageTitle}}  -  {{.SiteTitle}}", Data{PageTitle: "  Inbox\n\t(3) ", SiteTitle: "Gmail"}, "Inbox (3) - Gmail"},
+		{"{{.PageTitle}} - {{.SiteTitle}}", Data{PageTitle: "", SiteTitle: "Gmail"}, "- Gmail"},
+
+		// Page titles are text, not templates or HTML
+		{"{{.PageTitle}}", Data{PageTitle: "{{.SiteTitle}}", SiteTitle: "Gmail"}, "{{.SiteTitle}}"},
+		{"{{.PageTitle}}", Data{PageTitle: `<b>Bold</b> & "quoted"`}, `<b>Bold</b> & "quoted"`},
+		{"{{.PageTitle}}", Data{PageTitle: "Çalışma — 日本語 🎉"}, "Çalışma — 日本語 🎉"},
+		{"{{.PageTitle}}", Data{PageTitle: "%s %d %v"}, "%s %d %v"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.template)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.template, err)
+			continue
+		}
+		got, err := tmpl.Render(tt.data)
+		if err != nil {
+			t.Errorf("Render(%q, %+v): %v", tt.template, tt.data, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Render(%q, %+v) = %q, want %q", tt.template, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestRenderShortensLongTitles(t *testing.T) {
+	tmpl, err := Parse("{{.PageTitle}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{strings.Repeat("a", MaxLength), strings.Repeat("a", MaxLength)},
+		{strings.Repeat("a", MaxLength+1), strings.Repeat("a", MaxLength-1) + "…"},
+		{strings.Repeat("ü", 1000), strings.Repeat("ü", MaxLength-1) + "…"},
+	}
+	for _, tt := range tests {
+		got, err := tmpl.Render(Data{PageTitle: tt.title})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("title of %d characters rendered as %d characters", utf8.RuneCountInString(tt.title), utf8.RuneCountInString(got))
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("shortened title is not valid UTF-8: %q", got)
+		}
+	}
+}
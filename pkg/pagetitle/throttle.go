@@ -0,0 +1,105 @@
+package pagetitle
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle passes values to a function at most once per interval.
+// Values arriving too early are held back and only the latest one is passed on when the interval ends,
+// so the last title of a burst is never lost. Values equal to the last one passed on are dropped.
+type Throttle struct {
+	interval time.Duration
+	fn       func(string)
+
+	// Clock, replaced in tests
+	now       func() time.Time
+	afterFunc func(time.Duration, func())
+
+	mu        sync.Mutex
+	last      time.Time // When fn was last called
+	current   string    // Value fn was last called with
+	called    bool
+	pending   string
+	scheduled bool
+	stopped   bool
+}
+
+// NewThrottle creates a throttle calling fn at most once per interval
+func NewThrottle(interval time.Duration, fn func(string)) *Throttle {
+	return &Throttle{
+		interval: interval,
+		fn:       fn,
+		now:      time.Now,
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// Update passes the value on right away if the interval has passed, otherwise when it ends
+func (t *Throttle) Update(value string) {
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+
+	// A value is already waiting for the interval to end, so replace it
+	if t.scheduled {
+		t.pending = value
+		t.mu.Unlock()
+		return
+	}
+	if t.called && value == t.current {
+		t.mu.Unlock()
+		return
+	}
+
+	// Wait for the rest of the interval if fn was called recently
+	now := t.now()
+	if wait := t.interval - now.Sub(t.last); t.called && wait > 0 {
+		t.pending = value
+		t.scheduled = true
+		t.mu.Unlock()
+		t.afterFunc(wait, t.flush)
+		return
+	}
+
+	t.record(value, now)
+	t.mu.Unlock()
+	t.fn(value)
+}
+
+// Stop drops pending values and ignores later updates
+func (t *Throttle) Stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.scheduled = false
+	t.mu.Unlock()
+}
+
+// flush passes on the value held back until the end of the interval
+func (t *Throttle) flush() {
+	t.mu.Lock()
+	if t.stopped || !t.scheduled {
+		t.mu.Unlock()
+		return
+	}
+	t.scheduled = false
+	value := t.pending
+	if value == t.current {
+		t.mu.Unlock()
+		return
+	}
+	t.record(value, t.now())
+	t.mu.Unlock()
+	t.fn(value)
+}
+
+// record remembers the value passed on to fn, called with the mutex held
+func (t *Throttle) record(value string, now time.Time) {
+	t.last = now
+	t.current = value
+	t.called = true
+}
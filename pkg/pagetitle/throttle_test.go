@@ -0,0 +1,163 @@
+package pagetitle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for Throttle whose timers fire when the test advances it
+type fakeClock struct {
+	now    time.Time
+	timers []fakeTimer
+}
+
+// fakeTimer is a function waiting for the fake clock to reach a time
+type fakeTimer struct {
+	at time.Time
+	fn func()
+}
+
+func (c *fakeClock) afterFunc(d time.Duration, fn func()) {
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), fn: fn})
+}
+
+// advance moves the clock forward, firing the timers that are due in order
+func (c *fakeClock) advance(d time.Duration) {
+	end := c.now.Add(d)
+	for {
+		next := -1
+		for i, timer := range c.timers {
+			if !timer.at.After(end) && (next < 0 || timer.at.Before(c.timers[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		timer := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		c.now = timer.at
+		timer.fn()
+	}
+	c.now = end
+}
+
+// newTestThrottle returns a throttle using a fake clock and the values it passed on
+func newTestThrottle(interval time.Duration) (*Throttle, *fakeClock, *[]string) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	var got []string
+	t := NewThrottle(interval, func(value string) {
+		got = append(got, value)
+	})
+	t.now = func() time.Time { return clock.now }
+	t.afterFunc = clock.afterFunc
+	return t, clock, &got
+}
+
+func TestThrottle(t *testing.T) {
+	// Each step updates the value after waiting, "" only advances the clock
+	type step struct {
+		wait  time.Duration
+		value string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		want  []string
+	}{
+		{
+			name:  "first value passes right away",
+			steps: []step{{0, "a"}},
+			want:  []string{"a"},
+		},
+		{
+			name:  "values after the interval pass right away",
+			steps: []step{{0, "a"}, {time.Second, "b"}, {2 * time.Second, "c"}},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "burst delivers its last value when the interval ends",
+			steps: []step{{0, "a"}, {100 * time.Millisecond, "b"}, {100 * time.Millisecond, "c"}, {100 * time.Millisecond, "d"}, {time.Second, ""}},
+			want:  []string{"a", "d"},
+		},
+		{
+			name:  "held back value waits for the rest of the interval",
+			steps: []step{{0, "a"}, {900 * time.Millisecond, "b"}, {50 * time.Millisecond, ""}},
+			want:  []string{"a"},
+		},
+		{
+			name:  "unchanged values are dropped",
+			steps: []step{{0, "a"}, {2 * time.Second, "a"}, {2 * time.Second, "b"}, {2 * time.Second, "b"}},
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "burst returning to the current value passes nothing",
+			steps: []step{{0, "a"}, {100 * time.Millisecond, "b"}, {100 * time.Millisecond, "a"}, {time.Second, ""}},
+			want:  []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		throttle, clock, got := newTestThrottle(time.Second)
+		for _, s := range tt.steps {
+			clock.advance(s.wait)
+			if s.value != "" {
+				throttle.Update(s.value)
+			}
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: passed on %v, want %v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestThrottleTiming(t *testing.T) {
+	throttle, clock, got := newTestThrottle(time.Second)
+	throttle.Update("a")
+	clock.advance(300 * time.Millisecond)
+	throttle.Update("b")
+
+	// b is delivered exactly one interval after a, not one interval after b arrived
+	clock.advance(699 * time.Millisecond)
+	if len(*got) != 1 {
+		t.Fatalf("b passed on early: %v", *got)
+	}
+	clock.advance(time.Millisecond)
+	if !reflect.DeepEqual(*got, []string{"a", "b"}) {
+		t.Fatalf("passed on %v after the interval", *got)
+	}
+
+	// The interval starts again when b was passed on
+	clock.advance(500 * time.Millisecond)
+	throttle.Update("c")
+	clock.advance(499 * time.Millisecond)
+	if len(*got) != 2 {
+		t.Fatalf("c passed on early: %v", *got)
+	}
+	clock.advance(time.Millisecond)
+	if !reflect.DeepEqual(*got, []string{"a", "b", "c"}) {
+		t.Errorf("passed on %v, want [a b c]", *got)
+	}
+}
+
+func TestThrottleEmptyValues(t *testing.T) {
+	throttle, clock, got := newTestThrottle(time.Second)
+	throttle.Update("")
+	clock.advance(2 * time.Second)
+	throttle.Update("")
+	if !reflect.DeepEqual(*got, []string{""}) {
+		t.Errorf("passed on %q, want a single empty title", *got)
+	}
+}
+
+func TestThrottleStop(t *testing.T) {
+	throttle, clock, got := newTestThrottle(time.Second)
+	throttle.Update("a")
+	throttle.Update("b")
+	throttle.Stop()
+	clock.advance(2 * time.Second)
+	throttle.Update("c")
+	if !reflect.DeepEqual(*got, []string{"a"}) {
+		t.Errorf("passed on %v after Stop, want [a]", *got)
+	}
+}
@@ -0,0 +1,113 @@
+package platform
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+
+	"github.com/kemalersin/hobaa/pkg/notify"
+	"github.com/kemalersin/hobaa/pkg/winapi"
+)
+
+// Notification area icon of the window showing notifications
+const (
+	trayIconID          = 1
+	trayCallbackMessage = winapi.WM_APP + 1
+	trayIconSize        = 32
+)
+
+// trayNotifier shows notifications from a notification area icon, which Windows 10 and later show as toasts.
+// Windows shows one notification per icon at a time, so a new notification replaces the previous one.
+type trayNotifier struct {
+	hwnd syscall.Handle
+	icon uintptr
+	tip  string
+
+	mu      sync.Mutex
+	added   bool
+	current string // ID of the shown notification
+	onClick func()
+}
+
+// NewNotifier creates a notifier showing notifications for a native window.
+// It must be called on the thread that owns the window.
+func (windowsPlatform) NewNotifier(window uintptr, iconPath, title string) (notify.Notifier, error) {
+	n := &trayNotifier{
+		hwnd: syscall.Handle(window),
+		icon: winapi.LoadIcon(iconPath, trayIconSize),
+		tip:  title,
+	}
+	if err := winapi.SubclassWindow(n.hwnd, n.handleMessage); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Show shows the notification from the notification area icon, adding the icon first if needed
+func (n *trayNotifier) Show(notification notify.Notification, onClick func()) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.added {
+		if err := winapi.AddTrayIcon(n.hwnd, trayIconID, trayCallbackMessage, n.icon, n.tip); err != nil {
+			return fmt.Errorf("failed to add notification icon: %v", err)
+		}
+		n.added = true
+	}
+
+	// Notifications without text are not shown, so the title becomes the text
+	title, text := notification.Title, notification.Body
+	if text == "" {
+		title, text = "", notification.Title
+	}
+	if err := winapi.ShowTrayBalloon(n.hwnd, trayIconID, title, text, n.icon); err != nil {
+		return fmt.Errorf("failed to show notification: %v", err)
+	}
+	n.current = notification.ID
+	n.onClick = onClick
+	return nil
+}
+
+// Hide removes the notification if it is the one shown
+func (n *trayNotifier) Hide(id string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.added || n.current != id {
+		return nil
+	}
+	n.current, n.onClick = "", nil
+	return winapi.HideTrayBalloon(n.hwnd, trayIconID)
+}
+
+// RequestPermission asks the user with a message box owned by the window
+func (n *trayNotifier) RequestPermission(title string) (bool, error) {
+	return winapi.AskYesNo(n.hwnd, title, fmt.Sprintf("Allow %s to show notifications?", title)), nil
+}
+
+// handleMessage handles clicks on the icon and its notifications, and removes the icon with the window
+func (n *trayNotifier) handleMessage(hwnd syscall.Handle, msg uint32, wParam, lParam uintptr) {
+	switch msg {
+	case trayCallbackMessage:
+		// The low word of lParam is the event with NOTIFYICON_VERSION_4
+		switch uint32(lParam & 0xFFFF) {
+		case winapi.NIN_BALLOONUSERCLICK:
+			n.mu.Lock()
+			onClick := n.onClick
+			n.current, n.onClick = "", nil
+			n.mu.Unlock()
+			if onClick != nil {
+				onClick()
+			}
+		case winapi.WM_LBUTTONUP:
+			winapi.FocusWindow(hwnd)
+		}
+	case winapi.WM_DESTROY:
+		n.mu.Lock()
+		if n.added {
+			winapi.DeleteTrayIcon(hwnd, trayIconID)
+			n.added = false
+		}
+		n.mu.Unlock()
+	}
+}
@@ -0,0 +1,110 @@
+// Package platform provides an abstraction over operating system specific functionality
+package platform
+
+import (
+	"os/exec"
+
+	"github.com/kemalersin/hobaa/pkg/geometry"
+	"github.com/kemalersin/hobaa/pkg/notify"
+	"github.com/kemalersin/hobaa/pkg/webview"
+)
+
+// Platform provides access to operating system specific functionality
+type Platform interface {
+	// SetDpiAwareness enables high DPI support for the process
+	SetDpiAwareness()
+
+	// SetWindowIcon sets the icon of a native window
+	SetWindowIcon(window uintptr, iconPath string)
+
+	// GetWindowSize returns the size of a native window
+	GetWindowSize(window uintptr) (int, int, error)
+
+	// GetWindowPlacement returns the restored bounds and state of a native window
+	GetWindowPlacement(window uintptr) (geometry.Placement, error)
+
+	// SetWindowPlacement moves a native window and restores its maximized or minimized state
+	SetWindowPlacement(window uintptr, placement geometry.Placement) error
+
+	// WatchWindow returns a channel receiving the move, size and state changes of a native window.
+	// The channel is closed when the window is destroyed.
+	WatchWindow(window uintptr) (<-chan WindowEvent, error)
+
+	// Monitors returns the attached monitors
+	Monitors() ([]geometry.Monitor, error)
+
+	// FocusWindow restores a minimized native window and brings it to the front
+	FocusWindow(window uintptr)
+
+	// NewNotifier creates a notifier showing the notifications of a native window as desktop notifications
+	NewNotifier(window uintptr, iconPath, title string) (notify.Notifier, error)
+
+	// FlashWindow flashes the taskbar button of a native window until the user switches to it
+	FlashWindow(window uintptr)
+
+	// ClearIconCache refreshes the shell icon cache
+	ClearIconCache()
+
+	// OpenBrowser opens a URL in the default browser
+	OpenBrowser(url string) error
+
+	// OpenFile opens a file with its associated application
+	OpenFile(path string) error
+
+	// ShowInFolder opens the folder of a file with the file selected
+	ShowInFolder(path string) error
+
+	// Confirm asks the user a yes or no question in a modal dialog owned by a native window, or by no window if it is 0.
+	// It reports whether the user answered yes.
+	Confirm(window uintptr, title, text string) bool
+
+	// RegisterProtocol makes links with the URL scheme start command for the current user.
+	// The command receives the link in place of %1.
+	RegisterProtocol(scheme, command, icon string) error
+
+	// UnregisterProtocol removes the URL scheme registered for the current user
+	UnregisterProtocol(scheme string) error
+
+	// Command creates a command that runs without a visible window
+	Command(name string, args ...string) *exec.Cmd
+
+	// NewWebView creates a webview window
+	NewWebView(options webview.WindowOptions) *webview.WebView
+}
+
+// WindowEventType identifies what changed about a window
+type WindowEventType int
+
+// Window event types
+const (
+	WindowMoved         WindowEventType = iota // The window moved
+	WindowResized                              // The window was resized, maximized, minimized or restored
+	WindowMoveSizeEnded                        // The user finished moving or resizing the window
+)
+
+// WindowEvent describes a change of a native window
+type WindowEvent struct {
+	Type      WindowEventType
+	Placement geometry.Placement
+
+	// Dragging is true while the user is still moving or resizing the window
+	Dragging bool
+}
+
+// windowEventBuffer is the number of window events kept for slow receivers
+const windowEventBuffer = 16
+
+// sendWindowEvent sends an event without blocking the window thread, dropping the oldest event if the buffer is full
+func sendWindowEvent(events chan WindowEvent, event WindowEvent) {
+	for {
+		select {
+		case events <- event:
+			return
+		default:
+		}
+		select {
+		case <-events:
+		default:
+		}
+	}
+}
@@ -0,0 +1,105 @@
+//go:build !windows
+
+package platform
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/kemalersin/hobaa/pkg/geometry"
+	"github.com/kemalersin/hobaa/pkg/notify"
+	"github.com/kemalersin/hobaa/pkg/webview"
+)
+
+// Headless implements Platform without a windowing system
+type Headless struct{}
+
+// Default returns the platform for the current operating system
+func Default() Platform {
+	return &Headless{}
+}
+
+// SetDpiAwareness does nothing on a headless platform
+func (*Headless) SetDpiAwareness() {}
+
+// SetWindowIcon does nothing on a headless platform
+func (*Headless) SetWindowIcon(window uintptr, iconPath string) {}
+
+// GetWindowSize always fails since there are no native windows
+func (*Headless) GetWindowSize(window uintptr) (int, int, error) {
+	return 0, 0, errors.ErrUnsupported
+}
+
+// GetWindowPlacement always fails since there are no native windows
+func (*Headless) GetWindowPlacement(window uintptr) (geometry.Placement, error) {
+	return geometry.Placement{}, errors.ErrUnsupported
+}
+
+// SetWindowPlacement does nothing on a headless platform
+func (*Headless) SetWindowPlacement(window uintptr, placement geometry.Placement) error {
+	return nil
+}
+
+// WatchWindow always fails since there are no native windows
+func (*Headless) WatchWindow(window uintptr) (<-chan WindowEvent, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// Monitors returns no monitors since there is no display
+func (*Headless) Monitors() ([]geometry.Monitor, error) {
+	return nil, nil
+}
+
+// FocusWindow does nothing on a headless platform
+func (*Headless) FocusWindow(window uintptr) {}
+
+// NewNotifier returns a notifier keeping notifications in memory since there is no desktop
+func (*Headless) NewNotifier(window uintptr, iconPath, title string) (notify.Notifier, error) {
+	return &notify.Fake{}, nil
+}
+
+// FlashWindow does nothing on a headless platform
+func (*Headless) FlashWindow(window uintptr) {}
+
+// ClearIconCache does nothing on a headless platform
+func (*Headless) ClearIconCache() {}
+
+// OpenBrowser always fails since there is no browser to open
+func (*Headless) OpenBrowser(url string) error {
+	return errors.ErrUnsupported
+}
+
+// OpenFile always fails since there is no shell to open files with
+func (*Headless) OpenFile(path string) error {
+	return errors.ErrUnsupported
+}
+
+// ShowInFolder always fails since there is no shell to show folders in
+func (*Headless) ShowInFolder(path string) error {
+	return errors.ErrUnsupported
+}
+
+// Confirm answers no since there is no user to ask
+func (*Headless) Confirm(window uintptr, title, text string) bool {
+	return false
+}
+
+// RegisterProtocol always fails since there is no shell to register with
+func (*Headless) RegisterProtocol(scheme, command, icon string) error {
+	return errors.ErrUnsupported
+}
+
+// UnregisterProtocol always fails since there is no shell to register with
+func (*Headless) UnregisterProtocol(scheme string) error {
+	return errors.ErrUnsupported
+}
+
+// Command creates a command for the given program
+func (*Headless) Command(name string, args ...string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
+
+// NewWebView creates a headless webview
+func (*Headless) NewWebView(options webview.WindowOptions) *webview.WebView {
+	return webview.New(options)
+}
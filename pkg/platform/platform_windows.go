@@ -0,0 +1,157 @@
+package platform
+
+import (
+	"os/exec"
+	"syscall"
+
+	"github.com/kemalersin/hobaa/pkg/dpi"
+	"github.com/kemalersin/hobaa/pkg/geometry"
+	"github.com/kemalersin/hobaa/pkg/webview"
+	"github.com/kemalersin/hobaa/pkg/winapi"
+)
+
+// windowsPlatform implements Platform using the Windows API and WebView2
+type windowsPlatform struct{}
+
+// Default returns the platform for the current operating system
+func Default() Platform {
+	return windowsPlatform{}
+}
+
+// SetDpiAwareness enables high DPI support for the process
+func (windowsPlatform) SetDpiAwareness() {
+	dpi.SetProcessDpiAwareness()
+}
+
+// SetWindowIcon sets the icon of a native window
+func (windowsPlatform) SetWindowIcon(window uintptr, iconPath string) {
+	winapi.SetWindowIcon(window, iconPath)
+}
+
+// GetWindowSize returns the size of a native window
+func (windowsPlatform) GetWindowSize(window uintptr) (int, int, error) {
+	return winapi.GetWindowSize(syscall.Handle(window))
+}
+
+// GetWindowPlacement returns the restored bounds and state of a native window
+func (windowsPlatform) GetWindowPlacement(window uintptr) (geometry.Placement, error) {
+	return winapi.GetWindowPlacement(syscall.Handle(window))
+}
+
+// SetWindowPlacement moves a native window and restores its maximized or minimized state
+func (windowsPlatform) SetWindowPlacement(window uintptr, placement geometry.Placement) error {
+	return winapi.SetWindowPlacement(syscall.Handle(window), placement)
+}
+
+// WatchWindow returns a channel receiving the move, size and state changes of a native window.
+// It must be called on the thread that owns the window.
+func (windowsPlatform) WatchWindow(window uintptr) (<-chan WindowEvent, error) {
+	events := make(chan WindowEvent, windowEventBuffer)
+
+	// The handler runs on the window thread, so this state needs no locking
+	dragging, closed := false, false
+	err := winapi.SubclassWindow(syscall.Handle(window), func(hwnd syscall.Handle, msg uint32, wParam, lParam uintptr) {
+		if closed {
+			return
+		}
+
+		var eventType WindowEventType
+		switch msg {
+		case winapi.WM_ENTERSIZEMOVE:
+			dragging = true
+			return
+		case winapi.WM_EXITSIZEMOVE:
+			dragging = false
+			eventType = WindowMoveSizeEnded
+		case winapi.WM_MOVE:
+			eventType = WindowMoved
+		case winapi.WM_SIZE:
+			eventType = WindowResized
+		case winapi.WM_DESTROY:
+			closed = true
+			close(events)
+			return
+		default:
+			return
+		}
+
+		placement, err := winapi.GetWindowPlacement(hwnd)
+		if err != nil {
+			return
+		}
+		sendWindowEvent(events, WindowEvent{
+			Type:      eventType,
+			Placement: placement,
+			Dragging:  dragging,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Monitors returns the attached monitors
+func (windowsPlatform) Monitors() ([]geometry.Monitor, error) {
+	return winapi.GetMonitors()
+}
+
+// FocusWindow restores a minimized native window and brings it to the front
+func (windowsPlatform) FocusWindow(window uintptr) {
+	winapi.FocusWindow(syscall.Handle(window))
+}
+
+// FlashWindow flashes the taskbar button of a native window until the user switches to it
+func (windowsPlatform) FlashWindow(window uintptr) {
+	winapi.FlashWindow(syscall.Handle(window))
+}
+
+// ClearIconCache refreshes the shell icon cache
+func (windowsPlatform) ClearIconCache() {
+	winapi.ClearIconCache()
+}
+
+// OpenBrowser opens a URL in the default browser
+func (windowsPlatform) OpenBrowser(url string) error {
+	return winapi.ShellOpen(url)
+}
+
+// OpenFile opens a file with its associated application
+func (windowsPlatform) OpenFile(path string) error {
+	return winapi.ShellOpen(path)
+}
+
+// ShowInFolder opens an Explorer window with the file selected
+func (windowsPlatform) ShowInFolder(path string) error {
+	return winapi.ShowInFolder(path)
+}
+
+// Confirm shows a Yes/No message box owned by the window
+func (windowsPlatform) Confirm(window uintptr, title, text string) bool {
+	return winapi.AskYesNo(syscall.Handle(window), title, text)
+}
+
+// RegisterProtocol makes links with the URL scheme start command for the current user
+func (windowsPlatform) RegisterProtocol(scheme, command, icon string) error {
+	return winapi.RegisterURLProtocol(scheme, command, icon)
+}
+
+// UnregisterProtocol removes the URL scheme registered for the current user
+func (windowsPlatform) UnregisterProtocol(scheme string) error {
+	return winapi.UnregisterURLProtocol(scheme)
+}
+
+// Command creates a command that runs without a visible window
+func (windowsPlatform) Command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+	return cmd
+}
+
+// NewWebView creates a WebView2 window
+func (windowsPlatform) NewWebView(options webview.WindowOptions) *webview.WebView {
+	return webview.New(options)
+}
@@ -0,0 +1,204 @@
+package resedit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// Resource types and layout constants for icons
+const (
+	rtIcon          = 3
+	rtGroupIcon     = 14
+	icoHeaderSize   = 6
+	icoEntrySize    = 16
+	groupEntrySize  = 14
+	icoTypeIcon     = 1
+	defaultLanguage = 0x0409 // en-US
+)
+
+// Image represents a single image of an ICO file
+type Image struct {
+	Width      uint8 // 0 means 256 pixels
+	Height     uint8 // 0 means 256 pixels
+	ColorCount uint8
+	Planes     uint16
+	BitCount   uint16
+	Data       []byte // PNG or BMP data without the file header
+}
+
+// ParseICO parses the images of an ICO file
+func ParseICO(data []byte) ([]Image, error) {
+	le := binary.LittleEndian
+
+	// Check ICO header
+	if len(data) < icoHeaderSize || le.Uint16(data) != 0 || le.Uint16(data[2:]) != icoTypeIcon {
+		return nil, fmt.Errorf("not a valid ICO file")
+	}
+	count := int(le.Uint16(data[4:]))
+	if count == 0 || icoHeaderSize+count*icoEntrySize > len(data) {
+		return nil, fmt.Errorf("ICO file has no images or is truncated")
+	}
+
+	// Read image entries
+	images := make([]Image, 0, count)
+	for i := 0; i < count; i++ {
+		entry := data[icoHeaderSize+i*icoEntrySize:]
+		size := uint64(le.Uint32(entry[8:]))
+		offset := uint64(le.Uint32(entry[12:]))
+		if offset+size > uint64(len(data)) {
+			return nil, fmt.Errorf("ICO image %d is truncated", i)
+		}
+
+		images = append(images, Image{
+			Width:      entry[0],
+			Height:     entry[1],
+			ColorCount: entry[2],
+			Planes:     le.Uint16(entry[4:]),
+			BitCount:   le.Uint16(entry[6:]),
+			Data:       data[offset : offset+size],
+		})
+	}
+
+	return images, nil
+}
+
+// SetIcon returns a copy of the PE file with its main icon group replaced by the images
+func SetIcon(exe []byte, images []Image) ([]byte, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no icon images provided")
+	}
+
+	f, err := parsePE(exe)
+	if err != nil {
+		return nil, err
+	}
+	root, err := f.readResources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resources: %v", err)
+	}
+
+	groups := root.subdirectory(rtGroupIcon)
+	icons := root.subdirectory(rtIcon)
+	groups.sortEntries()
+
+	// Use the first icon group, which Windows shows as the application icon
+	var group *resourceEntry
+	if len(groups.entries) > 0 && groups.entries[0].dir != nil {
+		group = groups.entries[0]
+	} else {
+		group = &resourceEntry{id: 1, dir: &resourceDirectory{}}
+		groups.entries = append(groups.entries, group)
+	}
+
+	// Remove icons referenced by the old group
+	for _, lang := range group.dir.entries {
+		if lang.data == nil {
+			continue
+		}
+		for _, id := range groupIconIDs(lang.data.data) {
+			icons.remove(uint32(id))
+		}
+	}
+
+	// Keep the language of the old group
+	language := uint32(defaultLanguage)
+	if len(group.dir.entries) > 0 {
+		language = group.dir.entries[0].id
+	}
+
+	// Add the new icons with unused IDs
+	ids := make([]uint16, len(images))
+	next := uint32(1)
+	for i, image := range images {
+		for icons.find(next) != nil {
+			next++
+		}
+		if next > 0xFFFF {
+			return nil, fmt.Errorf("no free icon resource IDs")
+		}
+		ids[i] = uint16(next)
+		icons.entries = append(icons.entries, &resourceEntry{
+			id: next,
+			dir: &resourceDirectory{entries: []*resourceEntry{{
+				id:   language,
+				data: &resourceData{data: image.Data},
+			}}},
+		})
+	}
+
+	// Replace the group data for every language
+	groupData := buildGroupIcon(images, ids)
+	if len(group.dir.entries) == 0 {
+		group.dir.entries = []*resourceEntry{{id: language}}
+	}
+	for _, lang := range group.dir.entries {
+		lang.dir = nil
+		lang.data = &resourceData{data: groupData}
+	}
+
+	return f.writeResources(root)
+}
+
+// SetIconFromFile replaces the icon of the PE file at exePath with the ICO file at iconPath
+func SetIconFromFile(exePath, iconPath string) error {
+	ico, err := os.ReadFile(iconPath)
+	if err != nil {
+		return err
+	}
+	images, err := ParseICO(ico)
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.ReadFile(exePath)
+	if err != nil {
+		return err
+	}
+	out, err := SetIcon(exe, images)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(exePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(exePath, out, info.Mode())
+}
+
+// groupIconIDs returns the icon IDs referenced by a GRPICONDIR resource
+func groupIconIDs(data []byte) []uint16 {
+	le := binary.LittleEndian
+	if len(data) < icoHeaderSize {
+		return nil
+	}
+
+	var ids []uint16
+	count := int(le.Uint16(data[4:]))
+	for i := 0; i < count && icoHeaderSize+(i+1)*groupEntrySize <= len(data); i++ {
+		entry := data[icoHeaderSize+i*groupEntrySize:]
+		ids = append(ids, le.Uint16(entry[12:]))
+	}
+	return ids
+}
+
+// buildGroupIcon builds a GRPICONDIR resource for the images
+func buildGroupIcon(images []Image, ids []uint16) []byte {
+	le := binary.LittleEndian
+
+	out := make([]byte, icoHeaderSize+len(images)*groupEntrySize)
+	le.PutUint16(out[2:], icoTypeIcon)
+	le.PutUint16(out[4:], uint16(len(images)))
+	for i, image := range images {
+		entry := out[icoHeaderSize+i*groupEntrySize:]
+		entry[0] = image.Width
+		entry[1] = image.Height
+		entry[2] = image.ColorCount
+		le.PutUint16(entry[4:], image.Planes)
+		le.PutUint16(entry[6:], image.BitCount)
+		le.PutUint32(entry[8:], uint32(len(image.Data)))
+		le.PutUint16(entry[12:], ids[i])
+	}
+	return out
+}
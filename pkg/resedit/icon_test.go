@@ -0,0 +1,336 @@
+package resedit
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"testing"
+)
+
+// Layout of the test executable built by minimalPE
+const (
+	testOptOffset     = 0x58
+	testOptSize       = 240 // PE32+ with 16 data directories
+	testSectionOffset = testOptOffset + testOptSize
+	testHeadersSize   = 0x200
+	testTextSize      = 0x200
+)
+
+// minimalPE builds a PE32+ executable with a single .text section and no resources
+func minimalPE(withChecksum bool) []byte {
+	le := binary.LittleEndian
+	data := make([]byte, testHeadersSize+testTextSize)
+
+	// DOS header pointing at the PE header
+	le.PutUint16(data, dosSignature)
+	le.PutUint32(data[0x3C:], 0x40)
+
+	// PE signature and COFF header
+	le.PutUint32(data[0x40:], peSignature)
+	coff := data[0x44:]
+	le.PutUint16(coff, 0x8664) // AMD64
+	le.PutUint16(coff[2:], 1)
+	le.PutUint16(coff[16:], testOptSize)
+	le.PutUint16(coff[18:], 0x22) // Executable, large address aware
+
+	// Optional header
+	opt := data[testOptOffset:]
+	le.PutUint16(opt, pe32PlusMagic)
+	le.PutUint32(opt[16:], 0x1000)      // AddressOfEntryPoint
+	le.PutUint64(opt[24:], 0x140000000) // ImageBase
+	le.PutUint32(opt[32:], 0x1000)      // SectionAlignment
+	le.PutUint32(opt[36:], 0x200)       // FileAlignment
+	le.PutUint16(opt[48:], 6)           // MajorSubsystemVersion
+	le.PutUint32(opt[56:], 0x2000)      // SizeOfImage
+	le.PutUint32(opt[60:], testHeadersSize)
+	le.PutUint16(opt[68:], 2) // Windows GUI
+	le.PutUint32(opt[108:], 16)
+
+	// .text section
+	text := data[testSectionOffset:]
+	copy(text, ".text")
+	le.PutUint32(text[8:], 0x10)
+	le.PutUint32(text[12:], 0x1000)
+	le.PutUint32(text[16:], testTextSize)
+	le.PutUint32(text[20:], testHeadersSize)
+	le.PutUint32(text[36:], 0x60000020)
+	data[testHeadersSize] = 0xC3 // ret
+
+	if withChecksum {
+		le.PutUint32(opt[64:], checksum(data, testOptOffset+64))
+	}
+	return data
+}
+
+// referenceChecksum computes the PE checksum as documented for CheckSumMappedFile
+func referenceChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 2 {
+		if i == testOptOffset+64 || i == testOptOffset+66 {
+			continue
+		}
+		word := uint32(data[i])
+		if i+1 < len(data) {
+			word |= uint32(data[i+1]) << 8
+		}
+		sum += word
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return (sum & 0xffff) + uint32(len(data))
+}
+
+// testImages returns icon images with recognizable data
+func testImages(sizes ...uint8) []Image {
+	var images []Image
+	for _, size := range sizes {
+		images = append(images, Image{
+			Width:    size,
+			Height:   size,
+			Planes:   1,
+			BitCount: 32,
+			Data:     bytes.Repeat([]byte{size, 0xAB}, 50+int(size)),
+		})
+	}
+	return images
+}
+
+// checkPE validates the headers of an edited executable with debug/pe
+func checkPE(t *testing.T, exe []byte) *pe.File {
+	t.Helper()
+	le := binary.LittleEndian
+
+	f, err := pe.NewFile(bytes.NewReader(exe))
+	if err != nil {
+		t.Fatalf("debug/pe rejected the output: %v", err)
+	}
+	opt, ok := f.OptionalHeader.(*pe.OptionalHeader64)
+	if !ok {
+		t.Fatalf("optional header is %T, want PE32+", f.OptionalHeader)
+	}
+
+	// Sections must be aligned, inside the file and inside the image
+	for _, s := range f.Sections {
+		if s.Offset%opt.FileAlignment != 0 || s.Size%opt.FileAlignment != 0 {
+			t.Errorf("section %s raw data %#x+%#x is not file aligned", s.Name, s.Offset, s.Size)
+		}
+		if s.VirtualAddress%opt.SectionAlignment != 0 {
+			t.Errorf("section %s address %#x is not section aligned", s.Name, s.VirtualAddress)
+		}
+		if int(s.Offset+s.Size) > len(exe) {
+			t.Errorf("section %s ends at %#x past the file size %#x", s.Name, s.Offset+s.Size, len(exe))
+		}
+		if s.VirtualAddress+s.VirtualSize > opt.SizeOfImage {
+			t.Errorf("section %s ends at %#x past SizeOfImage %#x", s.Name, s.VirtualAddress+s.VirtualSize, opt.SizeOfImage)
+		}
+	}
+	if opt.SizeOfImage%opt.SectionAlignment != 0 {
+		t.Errorf("SizeOfImage %#x is not section aligned", opt.SizeOfImage)
+	}
+
+	// The resource directory must cover the .rsrc section data
+	rsrc := f.Section(".rsrc")
+	if rsrc == nil {
+		t.Fatal("no .rsrc section")
+	}
+	dir := opt.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_RESOURCE]
+	if dir.VirtualAddress != rsrc.VirtualAddress || dir.Size != rsrc.VirtualSize {
+		t.Errorf("resource directory is %#x+%#x, want %#x+%#x", dir.VirtualAddress, dir.Size, rsrc.VirtualAddress, rsrc.VirtualSize)
+	}
+
+	// The checksum must be kept valid
+	if sum := le.Uint32(exe[testOptOffset+64:]); sum != 0 && sum != referenceChecksum(exe) {
+		t.Errorf("checksum is %#x, want %#x", sum, referenceChecksum(exe))
+	}
+	return f
+}
+
+// readIcons returns the images of the first icon group of an executable, looked up through RT_ICON
+func readIcons(t *testing.T, exe []byte) ([]Image, []uint16) {
+	t.Helper()
+	f, err := parsePE(exe)
+	if err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	root, err := f.readResources()
+	if err != nil {
+		t.Fatalf("failed to read output resources: %v", err)
+	}
+
+	groups, icons := root.find(rtGroupIcon), root.find(rtIcon)
+	if groups == nil || groups.dir == nil || len(groups.dir.entries) == 0 || icons == nil || icons.dir == nil {
+		t.Fatal("output has no icon resources")
+	}
+	group := groups.dir.entries[0].dir.entries[0].data.data
+
+	le := binary.LittleEndian
+	var images []Image
+	ids := groupIconIDs(group)
+	for i, id := range ids {
+		entry := group[icoHeaderSize+i*groupEntrySize:]
+		icon := icons.dir.find(uint32(id))
+		if icon == nil || icon.dir == nil || len(icon.dir.entries) != 1 {
+			t.Fatalf("group references missing icon %d", id)
+		}
+		data := icon.dir.entries[0].data.data
+		if size := le.Uint32(entry[8:]); size != uint32(len(data)) {
+			t.Errorf("group entry %d has size %d, icon %d has %d bytes", i, size, id, len(data))
+		}
+		images = append(images, Image{
+			Width:    entry[0],
+			Height:   entry[1],
+			Planes:   le.Uint16(entry[4:]),
+			BitCount: le.Uint16(entry[6:]),
+			Data:     data,
+		})
+	}
+	if n := len(icons.dir.entries); n != len(ids) {
+		t.Errorf("output has %d RT_ICON entries, want %d", n, len(ids))
+	}
+	return images, ids
+}
+
+func TestSetIcon(t *testing.T) {
+	for _, withChecksum := range []bool{false, true} {
+		exe := minimalPE(withChecksum)
+
+		// The first edit appends a resource section
+		first := testImages(16, 32, 0)
+		out, err := SetIcon(exe, first)
+		if err != nil {
+			t.Fatalf("SetIcon: %v", err)
+		}
+		if f := checkPE(t, out); len(f.Sections) != 2 {
+			t.Fatalf("got %d sections, want 2", len(f.Sections))
+		}
+		if !bytes.Equal(out[testHeadersSize:testHeadersSize+testTextSize], exe[testHeadersSize:]) {
+			t.Error(".text data changed")
+		}
+		images, _ := readIcons(t, out)
+		compareImages(t, images, first)
+
+		// The second edit rewrites the resource section in place and drops the old icons
+		second := testImages(48, 24)
+		out, err = SetIcon(out, second)
+		if err != nil {
+			t.Fatalf("second SetIcon: %v", err)
+		}
+		if f := checkPE(t, out); len(f.Sections) != 2 {
+			t.Fatalf("got %d sections after the second edit, want 2", len(f.Sections))
+		}
+		images, ids := readIcons(t, out)
+		compareImages(t, images, second)
+		if ids[0] != 1 || ids[1] != 2 {
+			t.Errorf("icon IDs are %v, want the freed IDs [1 2]", ids)
+		}
+	}
+}
+
+func TestSetIconKeepsOtherResources(t *testing.T) {
+	out, err := SetIcon(minimalPE(true), testImages(32))
+	if err != nil {
+		t.Fatalf("SetIcon: %v", err)
+	}
+
+	// Add a named version resource next to the icons
+	f, err := parsePE(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := f.readResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.subdirectory(16).entries = append(root.subdirectory(16).entries, &resourceEntry{
+		name: "VERSION",
+		dir:  &resourceDirectory{entries: []*resourceEntry{{id: defaultLanguage, data: &resourceData{data: []byte("version data"), codePage: 1200}}}},
+	})
+	if out, err = f.writeResources(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = SetIcon(out, testImages(64))
+	if err != nil {
+		t.Fatalf("SetIcon: %v", err)
+	}
+	checkPE(t, out)
+	f, err = parsePE(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err = f.readResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := root.find(16)
+	if version == nil || len(version.dir.entries) != 1 || version.dir.entries[0].name != "VERSION" {
+		t.Fatal("version resource was lost")
+	}
+	leaf := version.dir.entries[0].dir.entries[0].data
+	if string(leaf.data) != "version data" || leaf.codePage != 1200 {
+		t.Errorf("version resource is %q with code page %d", leaf.data, leaf.codePage)
+	}
+}
+
+func TestSetIconErrors(t *testing.T) {
+	truncated := minimalPE(false)[:0x100]
+	tests := []struct {
+		name   string
+		exe    []byte
+		images []Image
+	}{
+		{"no images", minimalPE(false), nil},
+		{"not a PE", []byte("MZ not really an executable, just some text"), testImages(16)},
+		{"truncated", truncated, testImages(16)},
+	}
+	for _, tt := range tests {
+		if _, err := SetIcon(tt.exe, tt.images); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestParseICO(t *testing.T) {
+	le := binary.LittleEndian
+	images := testImages(16, 0)
+
+	// Build an ICO file from the images
+	ico := make([]byte, icoHeaderSize+len(images)*icoEntrySize)
+	le.PutUint16(ico[2:], icoTypeIcon)
+	le.PutUint16(ico[4:], uint16(len(images)))
+	for i, image := range images {
+		entry := ico[icoHeaderSize+i*icoEntrySize:]
+		entry[0], entry[1] = image.Width, image.Height
+		le.PutUint16(entry[4:], image.Planes)
+		le.PutUint16(entry[6:], image.BitCount)
+		le.PutUint32(entry[8:], uint32(len(image.Data)))
+		le.PutUint32(entry[12:], uint32(len(ico)))
+		ico = append(ico, image.Data...)
+	}
+
+	parsed, err := ParseICO(ico)
+	if err != nil {
+		t.Fatalf("ParseICO: %v", err)
+	}
+	compareImages(t, parsed, images)
+
+	for _, bad := range [][]byte{nil, ico[:4], ico[:icoHeaderSize+icoEntrySize], []byte("\x89PNG\r\n\x1a\n")} {
+		if _, err := ParseICO(bad); err == nil {
+			t.Errorf("ParseICO(%q) succeeded", bad)
+		}
+	}
+}
+
+// compareImages checks that icon images survived an edit
+func compareImages(t *testing.T, got, want []Image) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d images, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Width != w.Width || g.Height != w.Height || g.Planes != w.Planes || g.BitCount != w.BitCount || !bytes.Equal(g.Data, w.Data) {
+			t.Errorf("image %d is %dx%d %d-bit with %d bytes, want %dx%d %d-bit with %d bytes",
+				i, g.Width, g.Height, g.BitCount, len(g.Data), w.Width, w.Height, w.BitCount, len(w.Data))
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package resedit edits the resources of Windows PE executables
+package resedit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// PE format constants
+const (
+	dosSignature      = 0x5A4D     // "MZ"
+	peSignature       = 0x00004550 // "PE\0\0"
+	pe32Magic         = 0x10b
+	pe32PlusMagic     = 0x20b
+	coffHeaderSize    = 20
+	sectionSize       = 40
+	dataDirectorySize = 8
+
+	// Data directory indexes
+	dirResource = 2
+	dirSecurity = 4
+
+	// Characteristics of a resource section
+	rsrcCharacteristics = 0x40000040 // IMAGE_SCN_CNT_INITIALIZED_DATA | IMAGE_SCN_MEM_READ
+)
+
+// ErrNotPE is returned when the data is not a valid PE file
+var ErrNotPE = errors.New("not a valid PE file")
+
+// section represents a PE section header
+type section struct {
+	name             string
+	virtualSize      uint32
+	virtualAddress   uint32
+	sizeOfRawData    uint32
+	pointerToRawData uint32
+	headerOffset     int
+}
+
+// peFile holds the parsed headers of a PE file
+type peFile struct {
+	data             []byte
+	coffOffset       int
+	optOffset        int
+	dirOffset        int
+	numDirs          int
+	sectionOffset    int
+	sectionAlignment uint32
+	fileAlignment    uint32
+	sizeOfHeaders    uint32
+	sections         []section
+}
+
+// parsePE parses the headers of a PE file
+func parsePE(data []byte) (*peFile, error) {
+	le := binary.LittleEndian
+
+	// Check DOS header
+	if len(data) < 0x40 || le.Uint16(data) != dosSignature {
+		return nil, ErrNotPE
+	}
+
+	// Check PE signature
+	peOffset := int(le.Uint32(data[0x3C:]))
+	if peOffset < 0 || peOffset+4+coffHeaderSize > len(data) || le.Uint32(data[peOffset:]) != peSignature {
+		return nil, ErrNotPE
+	}
+
+	f := &peFile{
+		data:       data,
+		coffOffset: peOffset + 4,
+	}
+	f.optOffset = f.coffOffset + coffHeaderSize
+
+	numSections := int(le.Uint16(data[f.coffOffset+2:]))
+	optSize := int(le.Uint16(data[f.coffOffset+16:]))
+	if f.optOffset+optSize > len(data) || optSize < 96 {
+		return nil, ErrNotPE
+	}
+
+	// Get data directory location based on the optional header format
+	switch le.Uint16(data[f.optOffset:]) {
+	case pe32Magic:
+		f.numDirs = int(le.Uint32(data[f.optOffset+92:]))
+		f.dirOffset = f.optOffset + 96
+	case pe32PlusMagic:
+		if optSize < 112 {
+			return nil, ErrNotPE
+		}
+		f.numDirs = int(le.Uint32(data[f.optOffset+108:]))
+		f.dirOffset = f.optOffset + 112
+	default:
+		return nil, fmt.Errorf("unsupported optional header magic")
+	}
+	if f.dirOffset+f.numDirs*dataDirectorySize > f.optOffset+optSize {
+		return nil, ErrNotPE
+	}
+
+	f.sectionAlignment = le.Uint32(data[f.optOffset+32:])
+	f.fileAlignment = le.Uint32(data[f.optOffset+36:])
+	f.sizeOfHeaders = le.Uint32(data[f.optOffset+60:])
+	if f.sectionAlignment == 0 || f.fileAlignment == 0 {
+		return nil, ErrNotPE
+	}
+
+	// Parse section table
+	f.sectionOffset = f.optOffset + optSize
+	if numSections == 0 || f.sectionOffset+numSections*sectionSize > len(data) {
+		return nil, ErrNotPE
+	}
+	for i := 0; i < numSections; i++ {
+		offset := f.sectionOffset + i*sectionSize
+		name := data[offset : offset+8]
+		for n, c := range name {
+			if c == 0 {
+				name = name[:n]
+				break
+			}
+		}
+		f.sections = append(f.sections, section{
+			name:             string(name),
+			virtualSize:      le.Uint32(data[offset+8:]),
+			virtualAddress:   le.Uint32(data[offset+12:]),
+			sizeOfRawData:    le.Uint32(data[offset+16:]),
+			pointerToRawData: le.Uint32(data[offset+20:]),
+			headerOffset:     offset,
+		})
+	}
+
+	return f, nil
+}
+
+// dataDirectory returns the RVA and size of a data directory
+func (f *peFile) dataDirectory(index int) (uint32, uint32) {
+	if index >= f.numDirs {
+		return 0, 0
+	}
+	offset := f.dirOffset + index*dataDirectorySize
+	return binary.LittleEndian.Uint32(f.data[offset:]), binary.LittleEndian.Uint32(f.data[offset+4:])
+}
+
+// setDataDirectory sets the RVA and size of a data directory in the given header copy
+func (f *peFile) setDataDirectory(out []byte, index int, rva, size uint32) {
+	offset := f.dirOffset + index*dataDirectorySize
+	binary.LittleEndian.PutUint32(out[offset:], rva)
+	binary.LittleEndian.PutUint32(out[offset+4:], size)
+}
+
+// sectionForRVA returns the index of the section containing the RVA
+func (f *peFile) sectionForRVA(rva uint32) int {
+	for i, s := range f.sections {
+		size := s.virtualSize
+		if size < s.sizeOfRawData {
+			size = s.sizeOfRawData
+		}
+		if rva >= s.virtualAddress && rva < s.virtualAddress+size {
+			return i
+		}
+	}
+	return -1
+}
+
+// readRVA returns size bytes of the file starting at the RVA
+func (f *peFile) readRVA(rva, size uint32) ([]byte, error) {
+	i := f.sectionForRVA(rva)
+	if i < 0 {
+		return nil, fmt.Errorf("RVA %#x is outside of all sections", rva)
+	}
+	s := f.sections[i]
+	offset := uint64(s.pointerToRawData) + uint64(rva-s.virtualAddress)
+	if offset+uint64(size) > uint64(s.pointerToRawData)+uint64(s.sizeOfRawData) || offset+uint64(size) > uint64(len(f.data)) {
+		return nil, fmt.Errorf("RVA %#x with size %d is outside of section data", rva, size)
+	}
+	return f.data[offset : offset+uint64(size)], nil
+}
+
+// readResources parses the resource directory tree
+func (f *peFile) readResources() (*resourceDirectory, error) {
+	rva, size := f.dataDirectory(dirResource)
+	if rva == 0 || size == 0 {
+		return &resourceDirectory{}, nil
+	}
+
+	// Read the whole section so directory offsets can be resolved
+	i := f.sectionForRVA(rva)
+	if i < 0 {
+		return nil, fmt.Errorf("resource directory is outside of all sections")
+	}
+	s := f.sections[i]
+	base := rva - s.virtualAddress
+	start := uint64(s.pointerToRawData) + uint64(base)
+	end := uint64(s.pointerToRawData) + uint64(s.sizeOfRawData)
+	if end > uint64(len(f.data)) || start >= end {
+		return nil, fmt.Errorf("resource section is truncated")
+	}
+
+	return parseResourceDirectory(f.data[start:end], f.readRVA)
+}
+
+// writeResources returns a copy of the file with its resources replaced by the tree
+func (f *peFile) writeResources(root *resourceDirectory) ([]byte, error) {
+	le := binary.LittleEndian
+
+	// Find the end of section data and the last section in memory
+	rawEnd := uint32(0)
+	last := -1
+	for i, s := range f.sections {
+		if end := s.pointerToRawData + s.sizeOfRawData; s.sizeOfRawData > 0 && end > rawEnd {
+			rawEnd = end
+		}
+		if last < 0 || s.virtualAddress > f.sections[last].virtualAddress {
+			last = i
+		}
+	}
+	if int(rawEnd) > len(f.data) {
+		return nil, fmt.Errorf("section data is truncated")
+	}
+
+	// Reuse the existing resource section if it is the last one in the file and in memory
+	rsrcRVA, _ := f.dataDirectory(dirResource)
+	target := -1
+	if rsrcRVA != 0 {
+		if i := f.sectionForRVA(rsrcRVA); i == last && f.sections[i].virtualAddress == rsrcRVA &&
+			f.sections[i].pointerToRawData+f.sections[i].sizeOfRawData == rawEnd {
+			target = i
+		}
+	}
+
+	var out []byte
+	var rva, rawPointer uint32
+	var headerOffset int
+	if target >= 0 {
+		s := f.sections[target]
+		rva, rawPointer, headerOffset = s.virtualAddress, s.pointerToRawData, s.headerOffset
+		out = append(out, f.data[:rawPointer]...)
+	} else {
+		// Append a new section, which requires room for another section header
+		headerOffset = f.sectionOffset + len(f.sections)*sectionSize
+		if uint32(headerOffset+sectionSize) > f.sizeOfHeaders {
+			return nil, fmt.Errorf("no room for a new section header")
+		}
+		for _, s := range f.sections {
+			if s.sizeOfRawData > 0 && uint32(headerOffset+sectionSize) > s.pointerToRawData {
+				return nil, fmt.Errorf("no room for a new section header")
+			}
+		}
+		lastSection := f.sections[last]
+		rva = alignUp(lastSection.virtualAddress+lastSection.virtualSize, f.sectionAlignment)
+		rawPointer = alignUp(rawEnd, f.fileAlignment)
+		out = append(out, f.data[:rawEnd]...)
+		out = append(out, make([]byte, rawPointer-rawEnd)...)
+		le.PutUint16(out[f.coffOffset+2:], uint16(len(f.sections)+1))
+		copy(out[headerOffset:], ".rsrc\x00\x00\x00")
+		le.PutUint32(out[headerOffset+36:], rsrcCharacteristics)
+	}
+
+	// Serialize resources for their new location
+	data := root.serialize(rva)
+	rawSize := alignUp(uint32(len(data)), f.fileAlignment)
+	out = append(out, data...)
+	out = append(out, make([]byte, rawSize-uint32(len(data)))...)
+
+	// Keep overlay data such as the COFF symbol table
+	oldEnd := rawEnd
+	if target >= 0 {
+		oldEnd = f.sections[target].pointerToRawData + f.sections[target].sizeOfRawData
+	}
+	out = append(out, f.data[oldEnd:]...)
+	delta := int64(rawPointer+rawSize) - int64(oldEnd)
+
+	// Update section header
+	le.PutUint32(out[headerOffset+8:], uint32(len(data)))
+	le.PutUint32(out[headerOffset+12:], rva)
+	le.PutUint32(out[headerOffset+16:], rawSize)
+	le.PutUint32(out[headerOffset+20:], rawPointer)
+
+	// Update optional header
+	sizeOfImage := le.Uint32(f.data[f.optOffset+56:])
+	if end := alignUp(rva+uint32(len(data)), f.sectionAlignment); end > sizeOfImage || target >= 0 {
+		le.PutUint32(out[f.optOffset+56:], end)
+	}
+	f.setDataDirectory(out, dirResource, rva, uint32(len(data)))
+
+	// Move the symbol table pointer along with the overlay
+	if symbols := le.Uint32(f.data[f.coffOffset+8:]); symbols >= oldEnd && symbols != 0 {
+		le.PutUint32(out[f.coffOffset+8:], uint32(int64(symbols)+delta))
+	}
+
+	// A signature no longer matches the modified file, so drop it
+	if _, size := f.dataDirectory(dirSecurity); size != 0 {
+		f.setDataDirectory(out, dirSecurity, 0, 0)
+	}
+
+	// Update checksum if the original file had one
+	if le.Uint32(f.data[f.optOffset+64:]) != 0 {
+		le.PutUint32(out[f.optOffset+64:], checksum(out, f.optOffset+64))
+	}
+
+	return out, nil
+}
+
+// checksum computes the PE image checksum, skipping the checksum field itself
+func checksum(data []byte, checksumOffset int) uint32 {
+	var sum uint64
+	for i := 0; i < len(data); i += 2 {
+		if i == checksumOffset || i == checksumOffset+2 {
+			continue
+		}
+		word := uint64(data[i])
+		if i+1 < len(data) {
+			word |= uint64(data[i+1]) << 8
+		}
+		sum += word
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	sum = (sum & 0xffff) + (sum >> 16)
+	return uint32(sum) + uint32(len(data))
+}
+
+// alignUp rounds value up to a multiple of alignment
+func alignUp(value, alignment uint32) uint32 {
+	return (value + alignment - 1) / alignment * alignment
+}
@@ -0,0 +1,277 @@
+package resedit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Resource tree constants
+const (
+	directoryHeaderSize = 16
+	directoryEntrySize  = 8
+	dataEntrySize       = 16
+	highBit             = 0x80000000
+	maxDirectoryDepth   = 3
+)
+
+// resourceDirectory represents a directory in the resource tree
+type resourceDirectory struct {
+	characteristics uint32
+	timeDateStamp   uint32
+	majorVersion    uint16
+	minorVersion    uint16
+	entries         []*resourceEntry
+}
+
+// resourceEntry represents an entry of a resource directory
+type resourceEntry struct {
+	name string // Set for named entries
+	id   uint32 // Set for ID entries
+	dir  *resourceDirectory
+	data *resourceData
+}
+
+// resourceData represents the data of a resource leaf
+type resourceData struct {
+	data     []byte
+	codePage uint32
+}
+
+// parseResourceDirectory parses a resource tree from the resource section data
+func parseResourceDirectory(section []byte, readRVA func(rva, size uint32) ([]byte, error)) (*resourceDirectory, error) {
+	return parseDirectoryAt(section, 0, 0, readRVA)
+}
+
+// parseDirectoryAt parses the directory at the given offset of the resource section
+func parseDirectoryAt(section []byte, offset uint32, depth int, readRVA func(rva, size uint32) ([]byte, error)) (*resourceDirectory, error) {
+	le := binary.LittleEndian
+	if depth >= maxDirectoryDepth || uint64(offset)+directoryHeaderSize > uint64(len(section)) {
+		return nil, fmt.Errorf("invalid resource directory at %#x", offset)
+	}
+
+	dir := &resourceDirectory{
+		characteristics: le.Uint32(section[offset:]),
+		timeDateStamp:   le.Uint32(section[offset+4:]),
+		majorVersion:    le.Uint16(section[offset+8:]),
+		minorVersion:    le.Uint16(section[offset+10:]),
+	}
+	count := uint64(le.Uint16(section[offset+12:])) + uint64(le.Uint16(section[offset+14:]))
+	if uint64(offset)+directoryHeaderSize+count*directoryEntrySize > uint64(len(section)) {
+		return nil, fmt.Errorf("resource directory at %#x is truncated", offset)
+	}
+
+	for i := uint64(0); i < count; i++ {
+		entryOffset := uint64(offset) + directoryHeaderSize + i*directoryEntrySize
+		nameField := le.Uint32(section[entryOffset:])
+		dataField := le.Uint32(section[entryOffset+4:])
+
+		// Read entry name or ID
+		entry := &resourceEntry{}
+		if nameField&highBit != 0 {
+			name, err := readName(section, nameField&^highBit)
+			if err != nil {
+				return nil, err
+			}
+			entry.name = name
+		} else {
+			entry.id = nameField
+		}
+
+		// Read subdirectory or data
+		if dataField&highBit != 0 {
+			sub, err := parseDirectoryAt(section, dataField&^highBit, depth+1, readRVA)
+			if err != nil {
+				return nil, err
+			}
+			entry.dir = sub
+		} else {
+			if uint64(dataField)+dataEntrySize > uint64(len(section)) {
+				return nil, fmt.Errorf("invalid resource data entry at %#x", dataField)
+			}
+			data, err := readRVA(le.Uint32(section[dataField:]), le.Uint32(section[dataField+4:]))
+			if err != nil {
+				return nil, err
+			}
+			entry.data = &resourceData{
+				data:     append([]byte(nil), data...),
+				codePage: le.Uint32(section[dataField+8:]),
+			}
+		}
+
+		dir.entries = append(dir.entries, entry)
+	}
+
+	return dir, nil
+}
+
+// readName reads a length-prefixed UTF-16 resource name
+func readName(section []byte, offset uint32) (string, error) {
+	if uint64(offset)+2 > uint64(len(section)) {
+		return "", fmt.Errorf("invalid resource name at %#x", offset)
+	}
+	length := uint64(binary.LittleEndian.Uint16(section[offset:]))
+	if uint64(offset)+2+length*2 > uint64(len(section)) {
+		return "", fmt.Errorf("resource name at %#x is truncated", offset)
+	}
+
+	chars := make([]uint16, length)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(section[uint64(offset)+2+uint64(i)*2:])
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+// find returns the entry with the given ID
+func (d *resourceDirectory) find(id uint32) *resourceEntry {
+	for _, entry := range d.entries {
+		if entry.name == "" && entry.id == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+// subdirectory returns the subdirectory with the given ID, creating it if needed
+func (d *resourceDirectory) subdirectory(id uint32) *resourceDirectory {
+	if entry := d.find(id); entry != nil && entry.dir != nil {
+		return entry.dir
+	}
+	sub := &resourceDirectory{}
+	d.remove(id)
+	d.entries = append(d.entries, &resourceEntry{id: id, dir: sub})
+	return sub
+}
+
+// remove removes the entry with the given ID
+func (d *resourceDirectory) remove(id uint32) {
+	entries := d.entries[:0]
+	for _, entry := range d.entries {
+		if entry.name != "" || entry.id != id {
+			entries = append(entries, entry)
+		}
+	}
+	d.entries = entries
+}
+
+// sortEntries orders entries as required by the PE format: named entries first, then IDs
+func (d *resourceDirectory) sortEntries() {
+	sort.SliceStable(d.entries, func(i, j int) bool {
+		a, b := d.entries[i], d.entries[j]
+		if (a.name != "") != (b.name != "") {
+			return a.name != ""
+		}
+		if a.name != "" {
+			return strings.ToUpper(a.name) < strings.ToUpper(b.name)
+		}
+		return a.id < b.id
+	})
+}
+
+// serialize lays out the resource tree for a section located at the given RVA
+func (d *resourceDirectory) serialize(rva uint32) []byte {
+	le := binary.LittleEndian
+
+	// Collect directories breadth-first, along with names and data leaves
+	var dirs []*resourceDirectory
+	var names []string
+	var leaves []*resourceData
+	dirOffsets := map[*resourceDirectory]uint32{}
+	nameOffsets := map[string]uint32{}
+	leafOffsets := map[*resourceData]uint32{}
+
+	size := uint32(0)
+	queue := []*resourceDirectory{d}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		dir.sortEntries()
+		dirs = append(dirs, dir)
+		dirOffsets[dir] = size
+		size += directoryHeaderSize + uint32(len(dir.entries))*directoryEntrySize
+		for _, entry := range dir.entries {
+			if entry.name != "" {
+				if _, ok := nameOffsets[entry.name]; !ok {
+					nameOffsets[entry.name] = 0
+					names = append(names, entry.name)
+				}
+			}
+			if entry.dir != nil {
+				queue = append(queue, entry.dir)
+			} else if entry.data != nil {
+				leaves = append(leaves, entry.data)
+			}
+		}
+	}
+
+	// Place names after directories
+	for _, name := range names {
+		nameOffsets[name] = size
+		size += 2 + uint32(len(utf16.Encode([]rune(name))))*2
+	}
+
+	// Place data entries and then the data itself
+	size = alignUp(size, 4)
+	for _, leaf := range leaves {
+		leafOffsets[leaf] = size
+		size += dataEntrySize
+	}
+	dataOffsets := make([]uint32, len(leaves))
+	for i, leaf := range leaves {
+		size = alignUp(size, 8)
+		dataOffsets[i] = size
+		size += uint32(len(leaf.data))
+	}
+
+	// Write everything out
+	out := make([]byte, size)
+	for _, dir := range dirs {
+		offset := dirOffsets[dir]
+		le.PutUint32(out[offset:], dir.characteristics)
+		le.PutUint32(out[offset+4:], dir.timeDateStamp)
+		le.PutUint16(out[offset+8:], dir.majorVersion)
+		le.PutUint16(out[offset+10:], dir.minorVersion)
+
+		named := 0
+		for _, entry := range dir.entries {
+			if entry.name != "" {
+				named++
+			}
+		}
+		le.PutUint16(out[offset+12:], uint16(named))
+		le.PutUint16(out[offset+14:], uint16(len(dir.entries)-named))
+
+		for i, entry := range dir.entries {
+			entryOffset := offset + directoryHeaderSize + uint32(i)*directoryEntrySize
+			if entry.name != "" {
+				le.PutUint32(out[entryOffset:], nameOffsets[entry.name]|highBit)
+			} else {
+				le.PutUint32(out[entryOffset:], entry.id)
+			}
+			if entry.dir != nil {
+				le.PutUint32(out[entryOffset+4:], dirOffsets[entry.dir]|highBit)
+			} else if entry.data != nil {
+				le.PutUint32(out[entryOffset+4:], leafOffsets[entry.data])
+			}
+		}
+	}
+	for _, name := range names {
+		chars := utf16.Encode([]rune(name))
+		offset := nameOffsets[name]
+		le.PutUint16(out[offset:], uint16(len(chars)))
+		for i, c := range chars {
+			le.PutUint16(out[offset+2+uint32(i)*2:], c)
+		}
+	}
+	for i, leaf := range leaves {
+		offset := leafOffsets[leaf]
+		le.PutUint32(out[offset:], rva+dataOffsets[i])
+		le.PutUint32(out[offset+4:], uint32(len(leaf.data)))
+		le.PutUint32(out[offset+8:], leaf.codePage)
+		copy(out[dataOffsets[i]:], leaf.data)
+	}
+
+	return out
+}
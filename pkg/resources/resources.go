@@ -14,6 +14,9 @@ import (
 // embeddedFiles holds the embedded resources
 var embeddedFiles embed.FS
 
+// embeddedSitesPath is the path of the bundled sites.json catalog
+const embeddedSitesPath = "sites.json"
+
 // SetEmbeddedFiles sets the embedded files from main package
 func SetEmbeddedFiles(files embed.FS) {
 	embeddedFiles = files
@@ -91,17 +94,7 @@ func CopyEmbeddedFile(embeddedPath, targetPath string) error {
 	}
 
 	// Sync to ensure the file is written
-	err = destFile.Sync()
-	if err != nil {
-		return err
-	}
-
-	// Set executable permission for rcedit.exe
-	if filepath.Base(embeddedPath) == "rcedit.exe" {
-		return os.Chmod(targetPath, 0755) // rwxr-xr-x
-	}
-
-	return nil
+	return destFile.Sync()
 }
 
 // CopyDefaultIcon copies the default icon to the specified path
@@ -109,14 +102,14 @@ func CopyDefaultIcon(execDir, targetPath string) error {
 	return CopyEmbeddedFile("resources/default.ico", targetPath)
 }
 
-// CopyRcedit copies the rcedit.exe to the specified path
-func CopyRcedit(execDir, targetPath string) error {
-	return CopyEmbeddedFile("resources/rcedit.exe", targetPath)
-}
-
 // CopySitesJson copies the sites.json file to the specified path
 func CopySitesJson(targetPath string) error {
-	return CopyEmbeddedFile("resources/sites.json", targetPath)
+	return CopyEmbeddedFile(embeddedSitesPath, targetPath)
+}
+
+// ReadSitesJson returns the contents of the bundled sites.json catalog
+func ReadSitesJson() ([]byte, error) {
+	return embeddedFiles.ReadFile(embeddedSitesPath)
 }
 
 // CopyAllIcons copies all icon files from the embedded resources to the target directory
@@ -0,0 +1,220 @@
+// Package scope decides which URLs belong to a site and which should open in the default browser
+package scope
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Pattern matches URLs by scheme, host and path.
+//
+// Patterns have the form [scheme://]host[/path]:
+//   - Without a scheme both http and https match.
+//   - A host starting with "*." matches the domain itself and all of its subdomains,
+//     and a host of "*" matches any host. A port must be given to match URLs with a port.
+//   - A path without "*" matches all paths starting with it, as web app manifest scopes do.
+//     In a path containing "*", each "*" matches any characters and the whole path must match.
+//
+// Examples: "github.com", "*.google.com", "https://example.com/app/", "gitlab.com/*/issues/*".
+type Pattern struct {
+	source string
+	host   string
+	re     *regexp.Regexp
+}
+
+// ParsePattern parses a scope pattern
+func ParsePattern(pattern string) (*Pattern, error) {
+	rest := strings.TrimSpace(pattern)
+	if rest == "" {
+		return nil, fmt.Errorf("empty scope pattern")
+	}
+
+	// Parse scheme
+	scheme := "https?"
+	if i := strings.Index(rest, "://"); i >= 0 {
+		switch s := strings.ToLower(rest[:i]); s {
+		case "http", "https":
+			scheme = s
+		default:
+			return nil, fmt.Errorf("unsupported scheme in scope pattern %q", pattern)
+		}
+		rest = rest[i+3:]
+	}
+
+	// Split host and path
+	host, path := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		host, path = rest[:i], rest[i:]
+	}
+	host = strings.ToLower(host)
+	if host == "" {
+		return nil, fmt.Errorf("missing host in scope pattern %q", pattern)
+	}
+
+	// Build the host expression
+	var hostExpr string
+	switch {
+	case host == "*":
+		hostExpr = `[^/]+`
+	case strings.HasPrefix(host, "*."):
+		if strings.Contains(host[2:], "*") {
+			return nil, fmt.Errorf("invalid wildcard in scope pattern %q", pattern)
+		}
+		hostExpr = `([^/]*\.)?` + regexp.QuoteMeta(host[2:])
+	case strings.Contains(host, "*"):
+		return nil, fmt.Errorf("invalid wildcard in scope pattern %q", pattern)
+	default:
+		hostExpr = regexp.QuoteMeta(host)
+	}
+
+	// Build the path expression
+	var pathExpr string
+	switch {
+	case path == "":
+		pathExpr = `(/.*)?`
+	case strings.Contains(path, "*"):
+		parts := strings.Split(path, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		pathExpr = strings.Join(parts, `.*`)
+	default:
+		pathExpr = regexp.QuoteMeta(path) + `.*`
+	}
+
+	re, err := regexp.Compile(`^` + scheme + `://` + hostExpr + pathExpr + `$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scope pattern %q: %v", pattern, err)
+	}
+
+	// Keep the host without wildcard for looking up sites by host
+	plainHost := strings.TrimPrefix(host, "*.")
+	if plainHost == "*" {
+		plainHost = ""
+	}
+	return &Pattern{source: pattern, host: plainHost, re: re}, nil
+}
+
+// String returns the pattern as written
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// Host returns the host of the pattern without a wildcard prefix and with its port, or "" if it matches any host
+func (p *Pattern) Host() string {
+	return p.host
+}
+
+// Regexp returns the regular expression matched against normalized URLs.
+// It uses a syntax shared by Go and JavaScript, so pages can check links without calling back.
+func (p *Pattern) Regexp() string {
+	return p.re.String()
+}
+
+// Match reports whether the URL matches the pattern
+func (p *Pattern) Match(u *url.URL) bool {
+	target, ok := Normalize(u)
+	return ok && p.re.MatchString(target)
+}
+
+// Matcher matches URLs against the patterns of a site's scope
+type Matcher struct {
+	patterns []*Pattern
+}
+
+// New creates a matcher for the patterns
+func New(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		p, err := ParsePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// ForSite creates a matcher for a site's scope, falling back to DefaultPatterns if it has none
+func ForSite(siteURL string, patterns []string) (*Matcher, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns(siteURL)
+	}
+	return New(patterns)
+}
+
+// DefaultPatterns returns the scope of a site without a configured scope:
+// the host of the site URL without "www." and all of its subdomains
+func DefaultPatterns(siteURL string) []string {
+	u, err := url.Parse(siteURL)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return []string{"*." + host}
+}
+
+// Patterns returns the parsed patterns
+func (m *Matcher) Patterns() []*Pattern {
+	return m.patterns
+}
+
+// Match reports whether the URL is within the scope
+func (m *Matcher) Match(u *url.URL) bool {
+	target, ok := Normalize(u)
+	if !ok {
+		return false
+	}
+	for _, p := range m.patterns {
+		if p.re.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchString reports whether the URL string is within the scope
+func (m *Matcher) MatchString(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && m.Match(u)
+}
+
+// Outside returns the URL if it is an http or https URL outside the scope, which should open in the default browser.
+// URLs with other schemes, such as about:blank or data: URLs, stay in the app.
+func (m *Matcher) Outside(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	if _, ok := Normalize(u); !ok || m.Match(u) {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// Normalize returns the scheme, host and path of an http or https URL in the form patterns are matched against.
+// The host is lowercased, default ports are removed and an empty path becomes "/", as browsers do.
+func Normalize(u *url.URL) (string, bool) {
+	scheme := strings.ToLower(u.Scheme)
+	if (scheme != "http" && scheme != "https") || u.Host == "" {
+		return "", false
+	}
+
+	host := strings.ToLower(u.Host)
+	if h, port, err := net.SplitHostPort(host); err == nil &&
+		((scheme == "http" && port == "80") || (scheme == "https" && port == "443")) {
+		host = h
+		if strings.Contains(h, ":") {
+			host = "[" + h + "]" // Keep brackets around IPv6 addresses
+		}
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return scheme + "://" + host + path, true
+}
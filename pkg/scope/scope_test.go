@@ -0,0 +1,250 @@
+package scope
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParsePatternErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"",
+		"   ",
+		"ftp://example.com",
+		"file:///c:/",
+		"https://",
+		"/path/only",
+		"git*.com",
+		"*.*.google.com",
+		"sub.*.google.com",
+	} {
+		if _, err := ParsePattern(pattern); err == nil {
+			t.Errorf("ParsePattern(%q) succeeded", pattern)
+		}
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"github.com", "https://github.com", true},
+		{"github.com", "http://github.com/kemalersin/hobaa", true},
+		{"github.com", "https://gist.github.com/", false},
+		{"github.com", "https://github.com.evil.com/", false},
+		{"github.com", "https://evilgithub.com/", false},
+		{"GitHub.com", "https://GITHUB.COM/Path", true},
+		{"github.com", "ftp://github.com/", false},
+		{"https://github.com", "http://github.com/", false},
+		{"http://example.com", "http://example.com/", true},
+
+		{"*.google.com", "https://google.com/", true},
+		{"*.google.com", "https://mail.google.com/mail/u/0/", true},
+		{"*.google.com", "https://a.b.google.com/", true},
+		{"*.google.com", "https://notgoogle.com/", false},
+		{"*.google.com", "https://google.com.evil.com/", false},
+		{"*", "https://anything.example/", true},
+		{"*", "mailto:someone@example.com", false},
+
+		// Ports
+		{"localhost:8080", "http://localhost:8080/app", true},
+		{"localhost:8080", "http://localhost/app", false},
+		{"localhost", "http://localhost:8080/", false},
+		{"example.com", "https://example.com:443/", true},
+		{"example.com", "http://example.com:80/", true},
+		{"example.com", "http://example.com:443/", false},
+		{"*.example.com:8443", "https://api.example.com:8443/", true},
+
+		// Path prefixes
+		{"https://example.com/app/", "https://example.com/app/", true},
+		{"https://example.com/app/", "https://example.com/app/settings", true},
+		{"https://example.com/app/", "https://example.com/app", false},
+		{"https://example.com/app/", "https://example.com/other", false},
+		{"example.com/app", "https://example.com/application", true},
+		{"example.com/a.b", "https://example.com/aXb", false},
+
+		// Path wildcards
+		{"gitlab.com/*/issues/*", "https://gitlab.com/group/project/issues/1", true},
+		{"gitlab.com/*/issues/*", "https://gitlab.com/group/project/merge_requests/1", false},
+		{"gitlab.com/*/issues", "https://gitlab.com/group/issues/1", false},
+		{"gitlab.com/*/issues", "https://gitlab.com/group/issues", true},
+
+		// Queries and fragments are ignored
+		{"example.com/search", "https://example.com/search?q=1#top", true},
+		{"example.com/a", "https://example.com/?next=/a", false},
+
+		// Escaped paths are matched as they are sent
+		{"example.com/a%20b", "https://example.com/a%20b/c", true},
+		{"example.com/a b", "https://example.com/a%20b", false},
+	}
+	for _, tt := range tests {
+		p, err := ParsePattern(tt.pattern)
+		if err != nil {
+			t.Errorf("ParsePattern(%q): %v", tt.pattern, err)
+			continue
+		}
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Match(u); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v (regexp %s)", tt.pattern, tt.url, got, tt.want, p.Regexp())
+		}
+	}
+}
+
+func TestPatternHost(t *testing.T) {
+	tests := []struct {
+		pattern, host string
+	}{
+		{"github.com", "github.com"},
+		{"*.Google.com", "google.com"},
+		{"https://example.com:8443/app/*", "example.com:8443"},
+		{"*", ""},
+		{"http://*/path", ""},
+	}
+	for _, tt := range tests {
+		p, err := ParsePattern(tt.pattern)
+		if err != nil {
+			t.Errorf("ParsePattern(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := p.Host(); got != tt.host {
+			t.Errorf("Host(%q) = %q, want %q", tt.pattern, got, tt.host)
+		}
+		if got := p.String(); got != tt.pattern {
+			t.Errorf("String() = %q, want %q", got, tt.pattern)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+		ok   bool
+	}{
+		{"https://GitHub.com", "https://github.com/", true},
+		{"HTTPS://github.com/Path?q=1#x", "https://github.com/Path", true},
+		{"https://example.com:443/a", "https://example.com/a", true},
+		{"http://example.com:80/a", "http://example.com/a", true},
+		{"http://example.com:443/a", "http://example.com:443/a", true},
+		{"https://example.com:8443", "https://example.com:8443/", true},
+		{"https://[::1]:443/", "https://[::1]/", true},
+		{"http://[2001:DB8::1]:8080/", "http://[2001:db8::1]:8080/", true},
+		{"https://[::1]/", "https://[::1]/", true},
+		{"https://example.com/a%2Fb", "https://example.com/a%2Fb", true},
+		{"about:blank", "", false},
+		{"data:text/html,hi", "", false},
+		{"mailto:a@example.com", "", false},
+		{"file:///C:/Users", "", false},
+		{"/relative/path", "", false},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := Normalize(u)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.url, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDefaultPatterns(t *testing.T) {
+	tests := []struct {
+		siteURL string
+		want    []string
+	}{
+		{"https://github.com", []string{"*.github.com"}},
+		{"https://www.youtube.com/feed", []string{"*.youtube.com"}},
+		{"https://Mail.Google.com/mail/", []string{"*.mail.google.com"}},
+		{"http://localhost:3000", []string{"*.localhost:3000"}},
+		{"not a url", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := DefaultPatterns(tt.siteURL); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DefaultPatterns(%q) = %v, want %v", tt.siteURL, got, tt.want)
+		}
+	}
+}
+
+func TestForSite(t *testing.T) {
+	// Without a scope the site host and its subdomains are in scope
+	m, err := ForSite("https://www.github.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rawURL, want := range map[string]bool{
+		"https://github.com/":           true,
+		"https://gist.github.com/":      true,
+		"https://www.github.com/login":  true,
+		"https://githubusercontent.com": false,
+	} {
+		if got := m.MatchString(rawURL); got != want {
+			t.Errorf("default scope matching %q = %v, want %v", rawURL, got, want)
+		}
+	}
+
+	// A configured scope replaces the default
+	m, err = ForSite("https://twitter.com", []string{"*.x.com", "*.twitter.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Patterns()) != 2 || !m.MatchString("https://x.com/home") || m.MatchString("https://t.co/abc") {
+		t.Errorf("configured scope %v matches the wrong URLs", m.Patterns())
+	}
+
+	if _, err := ForSite("https://example.com", []string{"example.com", "ftp://example.com"}); err == nil {
+		t.Error("ForSite accepted an invalid pattern")
+	}
+}
+
+func TestOutside(t *testing.T) {
+	m, err := New([]string{"*.google.com", "https://accounts.example.com/login/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url      string
+		external string
+		ok       bool
+	}{
+		{"https://mail.google.com/mail/u/0/", "", false},
+		{"https://accounts.example.com/login/sso", "", false},
+		{"https://accounts.example.com/logout", "https://accounts.example.com/logout", true},
+		{"http://evil.example/phish?u=1#x", "http://evil.example/phish?u=1#x", true},
+		{"https://google.com.evil.example/", "https://google.com.evil.example/", true},
+
+		// Other schemes stay in the app
+		{"about:blank", "", false},
+		{"data:text/html,<p>hi</p>", "", false},
+		{"blob:https://mail.google.com/1234", "", false},
+		{"javascript:void(0)", "", false},
+		{"mailto:someone@example.com", "", false},
+		{"%zz", "", false},
+	}
+	for _, tt := range tests {
+		external, ok := m.Outside(tt.url)
+		if external != tt.external || ok != tt.ok {
+			t.Errorf("Outside(%q) = %q, %v, want %q, %v", tt.url, external, ok, tt.external, tt.ok)
+		}
+	}
+}
+
+func TestMatcherMatchString(t *testing.T) {
+	m, err := New([]string{"github.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MatchString("://bad") || m.MatchString("") {
+		t.Error("invalid URLs are in scope")
+	}
+	if !m.MatchString("https://github.com/") {
+		t.Error("site URL is out of scope")
+	}
+}
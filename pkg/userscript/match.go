@@ -0,0 +1,135 @@
+package userscript
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Pattern matches URLs with a Greasemonkey style match pattern.
+//
+// Match patterns have the form scheme://host/path:
+//   - The scheme is http, https or "*" for both.
+//   - A host of "*" matches any host, and a host starting with "*." matches the domain and its subdomains.
+//     Ports are ignored.
+//   - The path is required, and each "*" in it matches any characters, including the query.
+//
+// "<all_urls>" matches every http and https URL. Exclude patterns may also be globs without a scheme,
+// such as "*youtube.com/shorts*", where "*" matches any characters of the whole URL.
+//
+// Examples: "*://*.youtube.com/*", "https://github.com/*/pulls".
+type Pattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// ParseMatch parses a match pattern
+func ParseMatch(pattern string) (*Pattern, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "<all_urls>" {
+		return compile(pattern, `^https?://.*$`)
+	}
+
+	// Parse scheme
+	i := strings.Index(pattern, "://")
+	if i < 0 {
+		return nil, fmt.Errorf("missing scheme in match pattern %q", pattern)
+	}
+	var scheme string
+	switch s := strings.ToLower(pattern[:i]); s {
+	case "*":
+		scheme = "https?"
+	case "http", "https":
+		scheme = s
+	default:
+		return nil, fmt.Errorf("unsupported scheme in match pattern %q", pattern)
+	}
+
+	// Split host and path
+	rest := pattern[i+3:]
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return nil, fmt.Errorf("missing path in match pattern %q", pattern)
+	}
+	host, path := strings.ToLower(rest[:slash]), rest[slash:]
+
+	// Build the host expression, allowing any port
+	var hostExpr string
+	switch {
+	case host == "*":
+		hostExpr = `[^/]+`
+	case strings.HasPrefix(host, "*.") && !strings.Contains(host[2:], "*"):
+		hostExpr = `([^/]*\.)?` + regexp.QuoteMeta(host[2:]) + `(:\d+)?`
+	case host == "" || strings.Contains(host, "*"):
+		return nil, fmt.Errorf("invalid host in match pattern %q", pattern)
+	default:
+		hostExpr = regexp.QuoteMeta(host) + `(:\d+)?`
+	}
+
+	return compile(pattern, `^`+scheme+`://`+hostExpr+globExpr(path)+`$`)
+}
+
+// ParseExclude parses an exclude pattern, which is a match pattern or a glob over the whole URL
+func ParseExclude(pattern string) (*Pattern, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "<all_urls>" || strings.Contains(pattern, "://") {
+		return ParseMatch(pattern)
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("empty exclude pattern")
+	}
+	return compile(pattern, `^`+globExpr(pattern)+`$`)
+}
+
+// String returns the pattern as written
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// Regexp returns the regular expression matched against URLs without their fragment.
+// It uses a syntax shared by Go and JavaScript, so pages can check their own URL.
+func (p *Pattern) Regexp() string {
+	return p.re.String()
+}
+
+// Match reports whether the URL matches the pattern
+func (p *Pattern) Match(u *url.URL) bool {
+	target, ok := Target(u)
+	return ok && p.re.MatchString(target)
+}
+
+// Target returns the form of an http or https URL patterns are matched against: the URL without its fragment
+func Target(u *url.URL) (string, bool) {
+	scheme := strings.ToLower(u.Scheme)
+	if (scheme != "http" && scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	target := *u
+	target.Scheme = scheme
+	target.Host = strings.ToLower(u.Host)
+	target.Fragment = ""
+	target.RawFragment = ""
+	if target.Path == "" {
+		target.Path = "/"
+	}
+	return target.String(), true
+}
+
+// globExpr converts a glob where "*" matches any characters into a regular expression
+func globExpr(glob string) string {
+	parts := strings.Split(glob, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return strings.Join(parts, `.*`)
+}
+
+// compile compiles the expression of a pattern
+func compile(source, expr string) (*Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", source, err)
+	}
+	return &Pattern{source: source, re: re}, nil
+}
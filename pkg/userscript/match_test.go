@@ -0,0 +1,149 @@
+package userscript
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// matchTests are URLs as a browser reports them in location.href, with whether each pattern matches them
+var matchTests = []struct {
+	pattern string
+	exclude bool // Parsed with ParseExclude
+	url     string
+	want    bool
+}{
+	// Any scheme, a domain and its subdomains
+	{"*://*.example.com/*", false, "https://example.com/", true},
+	{"*://*.example.com/*", false, "http://a.b.example.com/path?q=1", true},
+	{"*://*.example.com/*", false, "https://example.com:8443/app", true},
+	{"*://*.example.com/*", false, "http://sub.example.com:8080/", true},
+	{"*://*.example.com/*", false, "https://notexample.com/", false},
+	{"*://*.example.com/*", false, "https://example.com.evil.com/", false},
+	{"*://*.example.com/*", false, "https://evil.com/?next=https://a.example.com/", false},
+	{"*://*.example.com/*", false, "ftp://example.com/", false},
+
+	// Exact host, ports are ignored unless the pattern names one
+	{"https://github.com/*/pulls", false, "https://github.com/kemalersin/pulls", true},
+	{"https://github.com/*/pulls", false, "https://github.com:443/kemalersin/pulls", true},
+	{"https://github.com/*/pulls", false, "http://github.com/kemalersin/pulls", false},
+	{"https://github.com/*/pulls", false, "https://github.com/kemalersin/pulls?q=is%3Aopen", false},
+	{"https://github.com/*/pulls", false, "https://gist.github.com/kemalersin/pulls", false},
+	{"http://localhost:3000/*", false, "http://localhost:3000/app", true},
+	{"http://localhost:3000/*", false, "http://localhost/app", false},
+
+	// Paths are literal except for "*", fragments are ignored
+	{"https://example.com/", false, "https://example.com/", true},
+	{"https://example.com/", false, "https://example.com/#top", true},
+	{"https://example.com/", false, "https://example.com/?a=1", false},
+	{"https://example.com/a.b", false, "https://example.com/aXb", false},
+	{"https://example.com/caf%C3%A9/*", false, "https://example.com/caf%C3%A9/menu", true},
+
+	// Any host
+	{"*://*/*", false, "http://192.168.1.1:8080/admin", true},
+	{"*://*/*", false, "https://example.com/", true},
+
+	// Every http and https URL
+	{"<all_urls>", false, "https://example.com/#section", true},
+	{"<all_urls>", false, "http://localhost:3000/", true},
+	{"<all_urls>", false, "file:///C:/Users/me/page.html", false},
+	{"<all_urls>", false, "about:blank", false},
+	{"<all_urls>", true, "https://example.com/", true},
+
+	// Exclude globs over the whole URL
+	{"*youtube.com/shorts*", true, "https://www.youtube.com/shorts/abc", true},
+	{"*youtube.com/shorts*", true, "https://www.youtube.com/watch?v=abc", false},
+	{"*youtube.com/shorts*", true, "https://www.youtube.com/watch?next=youtube.com/shorts", true},
+	{"*youtube.com/shorts*", true, "https://www.youtube.com/watch?v=abc#youtube.com/shorts", false},
+	{"*example.com/a+b*", true, "https://example.com/a+b/c", true},
+	{"*example.com/a+b*", true, "https://example.com/aab/c", false},
+	{"https://example.com/admin/*", true, "https://example.com/admin/users", true},
+	{"https://example.com/admin/*", true, "https://example.com/home", false},
+}
+
+func TestMatch(t *testing.T) {
+	for _, tt := range matchTests {
+		p, err := parseTestPattern(tt.pattern, tt.exclude)
+		if err != nil {
+			t.Errorf("parse %q: %v", tt.pattern, err)
+			continue
+		}
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Match(u); got != tt.want {
+			t.Errorf("%q matches %s = %v, want %v", tt.pattern, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParseMatchErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"",
+		"example.com/*",
+		"ftp://example.com/*",
+		"file:///C:/*",
+		"https://example.com",
+		"https:///path",
+		"https://ex*ample.com/*",
+		"https://*.ex*.com/*",
+		"*://*.*/*",
+	} {
+		if _, err := ParseMatch(pattern); err == nil {
+			t.Errorf("ParseMatch(%q) succeeded", pattern)
+		}
+	}
+}
+
+func TestParseExcludeErrors(t *testing.T) {
+	for _, pattern := range []string{"", "   ", "ftp://example.com/*", "https://example.com"} {
+		if _, err := ParseExclude(pattern); err == nil {
+			t.Errorf("ParseExclude(%q) succeeded", pattern)
+		}
+	}
+}
+
+// jsOnlySyntax is regular expression syntax Go and JavaScript read differently or only one of them supports
+var jsOnlySyntax = []string{`(?`, `\A`, `\z`, `\Q`, `\E`, `\p`, `\P`, `[[:`, `\C`}
+
+// TestRegexpMatchesLikeJS checks that the expressions InitScript gives to pages agree with Pattern.Match.
+// Pages test location.href without its fragment, so the expression is checked against that form of the URL.
+func TestRegexpMatchesLikeJS(t *testing.T) {
+	for _, tt := range matchTests {
+		p, err := parseTestPattern(tt.pattern, tt.exclude)
+		if err != nil {
+			t.Errorf("parse %q: %v", tt.pattern, err)
+			continue
+		}
+
+		expr := p.Regexp()
+		if !strings.HasPrefix(expr, "^") || !strings.HasSuffix(expr, "$") {
+			t.Errorf("%q: expression %s isn't anchored", tt.pattern, expr)
+		}
+		for _, syntax := range jsOnlySyntax {
+			if strings.Contains(expr, syntax) {
+				t.Errorf("%q: expression %s uses %s, which JavaScript reads differently", tt.pattern, expr, syntax)
+			}
+		}
+
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		href, _, _ := strings.Cut(tt.url, "#")
+		inPage := (u.Scheme == "http" || u.Scheme == "https") && regexp.MustCompile(expr).MatchString(href)
+		if inPage != p.Match(u) {
+			t.Errorf("%q on %s: page matches %v, Match returns %v", tt.pattern, tt.url, inPage, p.Match(u))
+		}
+	}
+}
+
+// parseTestPattern parses a pattern of matchTests
+func parseTestPattern(pattern string, exclude bool) (*Pattern, error) {
+	if exclude {
+		return ParseExclude(pattern)
+	}
+	return ParseMatch(pattern)
+}
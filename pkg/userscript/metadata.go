@@ -0,0 +1,96 @@
+// Package userscript loads the user scripts and user styles of a site and wraps them for injection into pages.
+// Files use Greasemonkey style metadata to choose the pages they run on and when they run.
+package userscript
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// RunAt is when a script runs in a page
+type RunAt string
+
+// Run times
+const (
+	RunAtDocumentStart RunAt = "document-start" // Before the page's own scripts
+	RunAtDocumentEnd   RunAt = "document-end"   // When the DOM is loaded
+	RunAtDocumentIdle  RunAt = "document-idle"  // After the page and its resources are loaded
+)
+
+// Metadata is the metadata block of a user script or style
+type Metadata struct {
+	Name     string
+	Matches  []*Pattern
+	Excludes []*Pattern
+	RunAt    RunAt // Empty if not given
+}
+
+// Metadata block markers. Scripts put each line in a "//" comment, styles use a single "/* */" comment.
+const (
+	scriptStart = "==UserScript=="
+	scriptEnd   = "==/UserScript=="
+	styleStart  = "==UserStyle=="
+	styleEnd    = "==/UserStyle=="
+)
+
+// ParseMetadata parses the metadata block of a user script or style.
+// Sources without a block have empty metadata; unknown keys are ignored.
+func ParseMetadata(source string) (Metadata, error) {
+	var meta Metadata
+	inBlock := false
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Script lines are "//" comments, the style block opens with "/*" and closes with "*/"
+		line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		switch {
+		case !inBlock:
+			marker := strings.TrimSpace(strings.TrimPrefix(line, "/*"))
+			inBlock = marker == scriptStart || marker == styleStart
+			continue
+		case line == scriptEnd || strings.TrimSpace(strings.TrimSuffix(line, "*/")) == styleEnd:
+			return meta, nil
+		case !strings.HasPrefix(line, "@"):
+			continue
+		}
+
+		// Lines have the form "@key value"
+		key, value := line[1:], ""
+		if i := strings.IndexAny(key, " \t"); i >= 0 {
+			key, value = key[:i], strings.TrimSpace(key[i:])
+		}
+		switch key {
+		case "name":
+			meta.Name = value
+		case "match":
+			p, err := ParseMatch(value)
+			if err != nil {
+				return meta, err
+			}
+			meta.Matches = append(meta.Matches, p)
+		case "exclude", "exclude-match":
+			p, err := ParseExclude(value)
+			if err != nil {
+				return meta, err
+			}
+			meta.Excludes = append(meta.Excludes, p)
+		case "run-at":
+			switch r := RunAt(value); r {
+			case RunAtDocumentStart, RunAtDocumentEnd, RunAtDocumentIdle:
+				meta.RunAt = r
+			default:
+				return meta, fmt.Errorf("unsupported @run-at %q", value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return meta, err
+	}
+	if inBlock {
+		return meta, fmt.Errorf("metadata block is not closed")
+	}
+	return meta, nil
+}
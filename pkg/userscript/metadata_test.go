@@ -0,0 +1,119 @@
+package userscript
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		want     string
+		matches  []string
+		excludes []string
+		runAt    RunAt
+	}{
+		{
+			name: "script block",
+			source: `// ==UserScript==
+// @name         Dark mode
+// @match        *://*.example.com/*
+// @match        https://example.org/app/*
+// @exclude      *example.com/admin*
+// @exclude-match https://example.com/login
+// @run-at       document-start
+// @grant        none
+// ==/UserScript==
+
+document.body.classList.add('dark');`,
+			want:     "Dark mode",
+			matches:  []string{"*://*.example.com/*", "https://example.org/app/*"},
+			excludes: []string{"*example.com/admin*", "https://example.com/login"},
+			runAt:    RunAtDocumentStart,
+		},
+		{
+			name: "style block",
+			source: `/* ==UserStyle==
+@name        Compact
+@match       https://mail.google.com/*
+@run-at      document-idle
+==/UserStyle== */
+
+body { font-size: 12px; }`,
+			want:    "Compact",
+			matches: []string{"https://mail.google.com/*"},
+			runAt:   RunAtDocumentIdle,
+		},
+		{
+			name:   "tabs and indentation",
+			source: "  //\t==UserScript==\n\t//\t@name\tTabbed\n\t// ==/UserScript==",
+			want:   "Tabbed",
+		},
+		{
+			name:   "lines outside the block are ignored",
+			source: "// @name Before\n// ==UserScript==\n// @name Inside\n// ==/UserScript==\n// @name After\n// @run-at never",
+			want:   "Inside",
+		},
+		{
+			name:   "unknown keys and plain comments are ignored",
+			source: "// ==UserScript==\n// @namespace https://example.com\n// @version 1.0\n// A comment\n// ==/UserScript==",
+		},
+		{
+			name:   "no block",
+			source: "console.log('no metadata');",
+		},
+		{
+			name:   "empty source",
+			source: "",
+		},
+	}
+	for _, tt := range tests {
+		meta, err := ParseMetadata(tt.source)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if meta.Name != tt.want {
+			t.Errorf("%s: name %q, want %q", tt.name, meta.Name, tt.want)
+		}
+		if got := patternSources(meta.Matches); !reflect.DeepEqual(got, tt.matches) {
+			t.Errorf("%s: matches %q, want %q", tt.name, got, tt.matches)
+		}
+		if got := patternSources(meta.Excludes); !reflect.DeepEqual(got, tt.excludes) {
+			t.Errorf("%s: excludes %q, want %q", tt.name, got, tt.excludes)
+		}
+		if meta.RunAt != tt.runAt {
+			t.Errorf("%s: run at %q, want %q", tt.name, meta.RunAt, tt.runAt)
+		}
+	}
+}
+
+func TestParseMetadataErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"unclosed script block", "// ==UserScript==\n// @name Unclosed\nconsole.log(1);"},
+		{"unclosed style block", "/* ==UserStyle==\n@name Unclosed\n*/"},
+		{"invalid run-at", "// ==UserScript==\n// @run-at document-ready\n// ==/UserScript=="},
+		{"missing run-at", "// ==UserScript==\n// @run-at\n// ==/UserScript=="},
+		{"invalid match", "// ==UserScript==\n// @match ftp://example.com/*\n// ==/UserScript=="},
+		{"match without path", "// ==UserScript==\n// @match https://example.com\n// ==/UserScript=="},
+		{"empty exclude", "// ==UserScript==\n// @exclude\n// ==/UserScript=="},
+	}
+	for _, tt := range tests {
+		if _, err := ParseMetadata(tt.source); err == nil {
+			t.Errorf("%s: ParseMetadata succeeded", tt.name)
+		}
+	}
+}
+
+// patternSources returns the patterns as written, nil if there are none
+func patternSources(patterns []*Pattern) []string {
+	var sources []string
+	for _, p := range patterns {
+		sources = append(sources, p.String())
+	}
+	return sources
+}
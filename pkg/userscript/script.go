@@ -0,0 +1,210 @@
+package userscript
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File extensions of user scripts and styles
+const (
+	ScriptExt = ".user.js"
+	StyleExt  = ".user.css"
+)
+
+// maxFileSize is the largest user script or style loaded
+const maxFileSize = 1 << 20
+
+// Script is a user script or style of a site
+type Script struct {
+	File   string // File name in the scripts directory
+	Style  bool   // The file is CSS rather than JavaScript
+	Meta   Metadata
+	Source string
+}
+
+// Load reads the user scripts and styles in a directory, in file name order.
+// Files that can't be read or parsed are skipped and reported in the returned errors.
+func Load(dir string) ([]Script, []error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to read scripts directory: %v", err)}
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && (strings.HasSuffix(name, ScriptExt) || strings.HasSuffix(name, StyleExt)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var scripts []Script
+	var errs []error
+	for _, name := range names {
+		script, err := loadFile(filepath.Join(dir, name))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, errs
+}
+
+// loadFile reads and parses one user script or style
+func loadFile(path string) (Script, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Script{}, err
+	}
+	if info.Size() > maxFileSize {
+		return Script{}, fmt.Errorf("file is larger than %d bytes", maxFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Script{}, err
+	}
+	return Parse(filepath.Base(path), string(data))
+}
+
+// Parse parses the source of a user script or style, telling them apart by the file name
+func Parse(file, source string) (Script, error) {
+	meta, err := ParseMetadata(source)
+	if err != nil {
+		return Script{}, err
+	}
+	script := Script{File: file, Style: strings.HasSuffix(file, StyleExt), Meta: meta, Source: source}
+	if script.Meta.Name == "" {
+		script.Meta.Name = strings.TrimSuffix(strings.TrimSuffix(file, ScriptExt), StyleExt)
+	}
+	return script, nil
+}
+
+// RunAt returns when the script runs. Scripts default to document-end and styles to document-start,
+// so pages don't show without the style first.
+func (s Script) RunAt() RunAt {
+	switch {
+	case s.Meta.RunAt != "":
+		return s.Meta.RunAt
+	case s.Style:
+		return RunAtDocumentStart
+	default:
+		return RunAtDocumentEnd
+	}
+}
+
+// Match reports whether the script runs on the URL.
+// Scripts without @match run on every page of the site, except the ones matching an @exclude.
+func (s Script) Match(u *url.URL) bool {
+	if _, ok := Target(u); !ok {
+		return false
+	}
+	matched := len(s.Meta.Matches) == 0
+	for _, p := range s.Meta.Matches {
+		if p.Match(u) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	for _, p := range s.Meta.Excludes {
+		if p.Match(u) {
+			return false
+		}
+	}
+	return true
+}
+
+// InitScript returns JavaScript for WebView.Init that checks the page URL against the patterns of the script
+// and runs the script or adds the style at its run time
+func (s Script) InitScript() string {
+	matches := regexps(s.Meta.Matches)
+	excludes := regexps(s.Meta.Excludes)
+	name, _ := json.Marshal(s.Meta.Name)
+
+	var body string
+	if s.Style {
+		css, _ := json.Marshal(s.Source)
+		body = fmt.Sprintf(styleBody, css)
+	} else {
+		// The source is wrapped in a function so its variables stay out of the page's scope
+		body = "(function() {\n" + s.Source + "\n}).call(window);"
+	}
+	return fmt.Sprintf(initScript, matches, excludes, s.RunAt(), name, body)
+}
+
+// regexps returns the expressions of patterns as a JSON array
+func regexps(patterns []*Pattern) string {
+	sources := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		sources = append(sources, p.Regexp())
+	}
+	data, _ := json.Marshal(sources)
+	return string(data)
+}
+
+// initScript runs the body of a user script or style on matching pages at the requested time
+const initScript = `
+(function() {
+	const matches = %s.map(source => new RegExp(source));
+	const excludes = %s.map(source => new RegExp(source));
+	const runAt = %q;
+	const name = %s;
+
+	// Match the URL without its fragment, as userscript.Pattern does
+	if (location.protocol !== 'http:' && location.protocol !== 'https:') {
+		return;
+	}
+	const target = location.href.split('#')[0];
+	if ((matches.length > 0 && !matches.some(re => re.test(target))) || excludes.some(re => re.test(target))) {
+		return;
+	}
+
+	function run() {
+		try {
+%s
+		} catch (e) {
+			console.error('User script ' + name + ' failed:', e);
+		}
+	}
+
+	if (runAt === 'document-start') {
+		run();
+	} else if (runAt === 'document-end' && document.readyState === 'loading') {
+		document.addEventListener('DOMContentLoaded', run, {once: true});
+	} else if (runAt === 'document-idle' && document.readyState !== 'complete') {
+		window.addEventListener('load', run, {once: true});
+	} else {
+		run();
+	}
+})();
+`
+
+// styleBody adds a style element, waiting for the document element if the page has none yet
+const styleBody = `
+			const style = document.createElement('style');
+			style.setAttribute('data-hobaa-user-style', name);
+			style.textContent = %s;
+			const add = () => (document.head || document.documentElement).appendChild(style);
+			if (document.documentElement) {
+				add();
+			} else {
+				new MutationObserver((records, observer) => {
+					if (document.documentElement) {
+						observer.disconnect();
+						add();
+					}
+				}).observe(document, {childList: true});
+			}
+`
@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IconCandidate is an icon found while discovering the icons of a site
+type IconCandidate struct {
+	URL     string
+	Source  string // Link relation or "manifest"
+	Type    string // Declared MIME type
+	Size    int    // Largest declared size in pixels, 0 if unknown, -1 for "any"
+	Purpose string // Manifest icon purpose
+}
+
+// Default sizes assumed for icons that don't declare one
+const (
+	defaultAppleTouchIconSize = 180
+	defaultFaviconSize        = 32
+	preferredIconSize         = 256
+)
+
+var (
+	linkTagPattern   = regexp.MustCompile(`(?is)<(link|base)\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*("([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	iconSizePattern  = regexp.MustCompile(`(\d+)[xX](\d+)`)
+)
+
+// StartPage is what the start page of a site declares about it: its icons and web app manifest
+type StartPage struct {
+	URL         *url.URL        // Final URL after redirects
+	Icons       []IconCandidate // Ranked from best to worst
+	Manifest    *WebManifest    // nil if the page links no manifest or it can't be loaded
+	ManifestURL *url.URL
+}
+
+// FetchStartPage downloads a page once and collects its icons and web app manifest,
+// so creating a site doesn't fetch the page again for each of them
+func FetchStartPage(pageURL string) (*StartPage, error) {
+	// Download start page
+	page, finalURL, err := DownloadPage(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStartPage(string(page), finalURL, DownloadManifest), nil
+}
+
+// ParseStartPage parses the icon links of a downloaded page and loads the manifest it links to
+func ParseStartPage(document string, pageURL *url.URL, loadManifest func(manifestURL string) (*WebManifest, error)) *StartPage {
+	// Parse icon links
+	icons, manifestURL := ParseIconLinks(document, pageURL)
+	page := &StartPage{URL: pageURL, Icons: icons}
+
+	// Add icons from the manifest
+	if manifestURL != "" {
+		if manifest, err := loadManifest(manifestURL); err == nil {
+			page.Manifest = manifest
+			page.ManifestURL, _ = url.Parse(manifestURL)
+			page.Icons = append(page.Icons, manifest.IconCandidates(page.ManifestURL)...)
+		}
+	}
+
+	// Sort icons from best to worst
+	RankIcons(page.Icons)
+	return page
+}
+
+// BestIcon returns the URL of the best icon of the page that can be converted to ICO
+func (p *StartPage) BestIcon() (string, error) {
+	return bestIconURL(p.Icons)
+}
+
+// DiscoverIcons fetches a page and returns its icons ranked from best to worst.
+// Icons are collected from link elements and from the icons of the web app manifest.
+func DiscoverIcons(pageURL string) ([]IconCandidate, error) {
+	page, err := FetchStartPage(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return page.Icons, nil
+}
+
+// ParseIconLinks parses icon links from an HTML document and returns them with the manifest URL
+func ParseIconLinks(document string, base *url.URL) ([]IconCandidate, string) {
+	var icons []IconCandidate
+	manifestURL := ""
+
+	for _, tag := range linkTagPattern.FindAllStringSubmatch(document, -1) {
+		attrs := parseAttributes(tag[0])
+
+		// Use base element for resolving relative URLs
+		if strings.EqualFold(tag[1], "base") {
+			if href := resolveURL(base, attrs["href"]); href != "" {
+				base, _ = url.Parse(href)
+			}
+			continue
+		}
+
+		href := resolveURL(base, attrs["href"])
+		if href == "" {
+			continue
+		}
+
+		// Check link relations
+		for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+			switch rel {
+			case "manifest":
+				if manifestURL == "" {
+					manifestURL = href
+				}
+			case "icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon":
+				icon := IconCandidate{
+					URL:    href,
+					Source: rel,
+					Type:   attrs["type"],
+					Size:   parseIconSizes(attrs["sizes"]),
+				}
+				if icon.Size == 0 && strings.HasPrefix(rel, "apple-touch-icon") {
+					icon.Size = defaultAppleTouchIconSize
+				}
+				if rel == "mask-icon" && icon.Type == "" {
+					icon.Type = "image/svg+xml"
+				}
+				icons = append(icons, icon)
+			default:
+				continue
+			}
+			break
+		}
+	}
+
+	return icons, manifestURL
+}
+
+// RankIcons sorts icons from best to worst by declared size and format.
+// Icons in formats that can't be converted, such as SVG, come last.
+func RankIcons(icons []IconCandidate) {
+	sort.SliceStable(icons, func(i, j int) bool {
+		a, b := icons[i], icons[j]
+		fa, fb := formatScore(a), formatScore(b)
+		if (fa > 0) != (fb > 0) {
+			return fa > 0
+		}
+		if sa, sb := sizeScore(a), sizeScore(b); sa != sb {
+			return sa > sb
+		}
+		if fa != fb {
+			return fa > fb
+		}
+		return preferredPurpose(a) && !preferredPurpose(b)
+	})
+}
+
+// formatScore scores an icon by how well its format can be converted to ICO
+func formatScore(icon IconCandidate) int {
+	format := strings.ToLower(icon.Type)
+	if format == "" {
+		format = GetFileExtension(icon.URL)
+	}
+
+	switch {
+	case strings.Contains(format, "svg"):
+		return 0 // SVG can't be converted
+	case strings.Contains(format, "png"):
+		return 3
+	case strings.Contains(format, "icon") || strings.Contains(format, "ico"):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// sizeScore scores an icon by its declared size, preferring the size closest to 256 pixels
+func sizeScore(icon IconCandidate) int {
+	size := icon.Size
+	if size == 0 {
+		size = defaultFaviconSize
+	}
+	if size < 0 || size >= preferredIconSize {
+		if size < 0 {
+			return preferredIconSize // "any" is a scalable icon
+		}
+		return 2*preferredIconSize - size/preferredIconSize
+	}
+	return size
+}
+
+// preferredPurpose reports whether the icon can be shown without a mask
+func preferredPurpose(icon IconCandidate) bool {
+	purpose := strings.ToLower(icon.Purpose)
+	return purpose == "" || strings.Contains(purpose, "any")
+}
+
+// parseIconSizes returns the largest size of a sizes attribute
+func parseIconSizes(sizes string) int {
+	largest := 0
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		if size == "any" {
+			return -1
+		}
+		if match := iconSizePattern.FindStringSubmatch(size); match != nil {
+			width, _ := strconv.Atoi(match[1])
+			height, _ := strconv.Atoi(match[2])
+			if height > width {
+				width = height
+			}
+			if width > largest {
+				largest = width
+			}
+		}
+	}
+	return largest
+}
+
+// parseAttributes parses the attributes of an HTML tag
+func parseAttributes(tag string) map[string]string {
+	attrs := map[string]string{}
+	for _, match := range attributePattern.FindAllStringSubmatch(tag, -1) {
+		name := strings.ToLower(match[1])
+		if _, exists := attrs[name]; exists {
+			continue
+		}
+		attrs[name] = html.UnescapeString(match[3] + match[4] + match[5])
+	}
+	return attrs
+}
+
+// resolveURL resolves a possibly relative URL against a base URL
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+// bestIconURL returns the URL of the best convertible icon
+func bestIconURL(icons []IconCandidate) (string, error) {
+	for _, icon := range icons {
+		if formatScore(icon) > 0 {
+			return icon.URL, nil
+		}
+	}
+	return "", fmt.Errorf("no usable icon found")
+}
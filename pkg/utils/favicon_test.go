@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseIconSizes(t *testing.T) {
+	tests := []struct {
+		sizes string
+		want  int
+	}{
+		{"", 0},
+		{"32x32", 32},
+		{"16x16 32x32 192x192", 192},
+		{"192X192", 192},
+		{"any", -1},
+		{"16x16 any", -1},
+		{"48x96", 96},
+		{"large", 0},
+	}
+	for _, tt := range tests {
+		if got := parseIconSizes(tt.sizes); got != tt.want {
+			t.Errorf("parseIconSizes(%q) = %d, want %d", tt.sizes, got, tt.want)
+		}
+	}
+}
+
+func TestParseIconLinks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/app/index.html")
+	tests := []struct {
+		name         string
+		document     string
+		wantIcons    []IconCandidate
+		wantManifest string
+	}{
+		{
+			name:     "icon kinds",
+			document: `<link rel="icon" href="/favicon.png" type="image/png" sizes="32x32"><link rel=apple-touch-icon href=touch.png><link rel="mask-icon" href="mask.svg" color="#000">`,
+			wantIcons: []IconCandidate{
+				{URL: "https://example.com/favicon.png", Source: "icon", Type: "image/png", Size: 32},
+				{URL: "https://example.com/app/touch.png", Source: "apple-touch-icon", Size: defaultAppleTouchIconSize},
+				{URL: "https://example.com/app/mask.svg", Source: "mask-icon", Type: "image/svg+xml"},
+			},
+		},
+		{
+			name:     "shortcut icon and attribute order",
+			document: `<LINK HREF='https://cdn.example.net/i.ico' REL='shortcut icon'>`,
+			wantIcons: []IconCandidate{
+				{URL: "https://cdn.example.net/i.ico", Source: "icon"},
+			},
+		},
+		{
+			name:         "manifest and base element",
+			document:     `<base href="/static/"><link rel="manifest" href="site.webmanifest"><link rel="icon" href="a&amp;b.png">`,
+			wantIcons:    []IconCandidate{{URL: "https://example.com/static/a&b.png", Source: "icon"}},
+			wantManifest: "https://example.com/static/site.webmanifest",
+		},
+		{
+			name:     "ignored links",
+			document: `<link rel="stylesheet" href="a.css"><link rel="icon" href="data:image/png;base64,AAAA"><link rel="icon" href="javascript:alert(1)"><link rel="icon">`,
+		},
+	}
+	for _, tt := range tests {
+		icons, manifest := ParseIconLinks(tt.document, base)
+		if !reflect.DeepEqual(icons, tt.wantIcons) {
+			t.Errorf("%s: icons = %+v, want %+v", tt.name, icons, tt.wantIcons)
+		}
+		if manifest != tt.wantManifest {
+			t.Errorf("%s: manifest = %q, want %q", tt.name, manifest, tt.wantManifest)
+		}
+	}
+}
+
+func TestRankIcons(t *testing.T) {
+	icons := []IconCandidate{
+		{URL: "mask.svg", Type: "image/svg+xml", Size: -1},
+		{URL: "favicon.ico"},
+		{URL: "small.png", Size: 32},
+		{URL: "huge.png", Size: 1024},
+		{URL: "maskable.png", Size: 256, Purpose: "maskable"},
+		{URL: "any.png", Size: 256, Purpose: "any maskable"},
+		{URL: "best.ico", Type: "image/x-icon", Size: 256},
+		{URL: "touch.jpg", Size: 180},
+	}
+	RankIcons(icons)
+
+	var got []string
+	for _, icon := range icons {
+		got = append(got, icon.URL)
+	}
+	want := []string{"any.png", "maskable.png", "best.ico", "huge.png", "touch.jpg", "small.png", "favicon.ico", "mask.svg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ranked %v, want %v", got, want)
+	}
+
+	if url, err := bestIconURL([]IconCandidate{{URL: "only.svg"}}); err == nil {
+		t.Errorf("bestIconURL returned %q for an SVG only page", url)
+	}
+}
+
+func TestFetchStartPage(t *testing.T) {
+	var pageRequests, manifestRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/app/", http.StatusFound)
+	})
+	mux.HandleFunc("/app/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pageRequests, 1)
+		fmt.Fprint(w, `<html><head>
+			<link rel="icon" href="favicon.ico">
+			<link rel="manifest" href="/manifest.json">
+		</head></html>`)
+	})
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&manifestRequests, 1)
+		fmt.Fprint(w, `{"name": "Example", "start_url": "/app/", "icons": [
+			{"src": "icons/192.png", "sizes": "192x192", "type": "image/png"},
+			{"src": "icons/512.png", "sizes": "512x512", "type": "image/png"}
+		]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	page, err := FetchStartPage(server.URL)
+	if err != nil {
+		t.Fatalf("FetchStartPage: %v", err)
+	}
+	if pageRequests != 1 || manifestRequests != 1 {
+		t.Errorf("page fetched %d times and manifest %d times, want once each", pageRequests, manifestRequests)
+	}
+	if page.URL.Path != "/app/" {
+		t.Errorf("final URL is %s, want the redirect target", page.URL)
+	}
+	if page.Manifest == nil || page.Manifest.Name != "Example" || page.ManifestURL.String() != server.URL+"/manifest.json" {
+		t.Fatalf("manifest is %+v at %v", page.Manifest, page.ManifestURL)
+	}
+	if len(page.Icons) != 3 {
+		t.Fatalf("got %d icons, want 3", len(page.Icons))
+	}
+	if best, err := page.BestIcon(); err != nil || best != server.URL+"/icons/512.png" {
+		t.Errorf("best icon is %q (%v)", best, err)
+	}
+	if page.Icons[2].URL != server.URL+"/app/favicon.ico" {
+		t.Errorf("worst icon is %q, want the favicon", page.Icons[2].URL)
+	}
+}
+
+func TestParseStartPageManifestFailure(t *testing.T) {
+	base, _ := url.Parse("https://example.com/")
+	page := ParseStartPage(`<link rel="manifest" href="/m.json"><link rel="icon" href="/f.png">`, base, func(string) (*WebManifest, error) {
+		return nil, fmt.Errorf("not found")
+	})
+	if page.Manifest != nil || page.ManifestURL != nil {
+		t.Errorf("manifest is %+v at %v, want none", page.Manifest, page.ManifestURL)
+	}
+	if len(page.Icons) != 1 || page.Icons[0].URL != "https://example.com/f.png" {
+		t.Errorf("icons are %+v", page.Icons)
+	}
+}
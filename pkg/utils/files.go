@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Retries for replacing a file another process briefly holds open on Windows
+const (
+	renameRetries    = 10
+	renameRetryDelay = 10 * time.Millisecond
+)
+
+// CopyDir copies a directory tree, skipping entries for which skip returns true.
+// Files that can't be read, such as files locked by another process, are skipped.
+func CopyDir(src, dst string, skip func(name string) bool) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries but fail if the source itself is missing
+			if path == src {
+				return err
+			}
+			return nil
+		}
+
+		// Skip excluded entries
+		if path != src && skip != nil && skip(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Get target path
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		// Create directories and copy regular files
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if d.Type().IsRegular() {
+			copyFile(path, target)
+		}
+		return nil
+	})
+}
+
+// WriteFileAtomic writes data to a temporary file and renames it over the target,
+// so readers never see a partially written file
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	// Create temporary file in the same directory so rename stays on one volume
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(dir, filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+
+	// Write and flush data
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempPath, perm)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	// Replace target, retrying while another process briefly holds it open on Windows
+	for i := 0; ; i++ {
+		err = os.Rename(tempPath, filePath)
+		if err == nil || i >= renameRetries {
+			break
+		}
+		time.Sleep(renameRetryDelay)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+	}
+	return err
+}
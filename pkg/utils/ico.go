@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	// Register decoders for image formats used by favicons
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// IconSizes are the sizes generated when converting an image to ICO
+var IconSizes = []int{16, 24, 32, 48, 64, 128, 256}
+
+// ICO layout constants
+const (
+	icoHeaderSize    = 6
+	icoEntrySize     = 16
+	bitmapHeaderSize = 40
+	pngEntryMinSize  = 256 // Sizes from this size up are stored as PNG
+)
+
+// DecodeImage decodes a PNG, JPEG or GIF image
+func DecodeImage(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a supported image: %v", err)
+	}
+	return img, nil
+}
+
+// EncodeICO writes the image as an ICO file containing a resampled copy for each size.
+// Small sources such as 64 px favicons are scaled up, so Windows always finds the size it asks for.
+func EncodeICO(w io.Writer, img image.Image, sizes []int) error {
+	if len(sizes) == 0 {
+		return fmt.Errorf("no valid icon sizes")
+	}
+
+	images := make([]*image.NRGBA, len(sizes))
+	for i, size := range sizes {
+		if size <= 0 || size > 256 {
+			return fmt.Errorf("invalid icon size %d", size)
+		}
+		images[i] = ResizeImage(img, size)
+	}
+	return WriteICO(w, images)
+}
+
+// WriteICO writes square images of up to 256 pixels as the entries of an ICO file
+func WriteICO(w io.Writer, images []*image.NRGBA) error {
+	if len(images) == 0 {
+		return fmt.Errorf("no icon images")
+	}
+
+	// Encode an entry for each image
+	entries := make([][]byte, len(images))
+	sizes := make([]int, len(images))
+	for i, img := range images {
+		size := img.Bounds().Dx()
+		if size <= 0 || size > 256 || img.Bounds().Dy() != size {
+			return fmt.Errorf("invalid icon image size %v", img.Bounds().Size())
+		}
+		sizes[i] = size
+		if size >= pngEntryMinSize {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				return err
+			}
+			entries[i] = buf.Bytes()
+		} else {
+			entries[i] = encodeBitmapEntry(img)
+		}
+	}
+
+	// Write ICO header and directory
+	le := binary.LittleEndian
+	header := make([]byte, icoHeaderSize+len(entries)*icoEntrySize)
+	le.PutUint16(header[2:], 1) // Icon type
+	le.PutUint16(header[4:], uint16(len(entries)))
+	offset := uint32(len(header))
+	for i, size := range sizes {
+		entry := header[icoHeaderSize+i*icoEntrySize:]
+		entry[0] = uint8(size) // 256 wraps to 0 as required
+		entry[1] = uint8(size)
+		le.PutUint16(entry[4:], 1)  // Planes
+		le.PutUint16(entry[6:], 32) // Bits per pixel
+		le.PutUint32(entry[8:], uint32(len(entries[i])))
+		le.PutUint32(entry[12:], offset)
+		offset += uint32(len(entries[i]))
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := w.Write(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResizeImage scales the image to fit a square of the given size using area averaging.
+// Non-square images are centered on a transparent background.
+func ResizeImage(img image.Image, size int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return dst
+	}
+
+	// Fit the image into the square keeping its aspect ratio
+	scale := float64(size) / float64(bounds.Dx())
+	if s := float64(size) / float64(bounds.Dy()); s < scale {
+		scale = s
+	}
+	width := int(float64(bounds.Dx())*scale + 0.5)
+	height := int(float64(bounds.Dy())*scale + 0.5)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	offsetX := (size - width) / 2
+	offsetY := (size - height) / 2
+	ratioX := float64(bounds.Dx()) / float64(width)
+	ratioY := float64(bounds.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		y0 := float64(y) * ratioY
+		y1 := y0 + ratioY
+		for x := 0; x < width; x++ {
+			x0 := float64(x) * ratioX
+			x1 := x0 + ratioX
+
+			// Average the premultiplied colors of the covered source pixels
+			var r, g, b, a, total float64
+			for sy := int(y0); float64(sy) < y1 && sy < bounds.Dy(); sy++ {
+				wy := overlap(float64(sy), y0, y1)
+				for sx := int(x0); float64(sx) < x1 && sx < bounds.Dx(); sx++ {
+					weight := wy * overlap(float64(sx), x0, x1)
+					cr, cg, cb, ca := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r += float64(cr) * weight
+					g += float64(cg) * weight
+					b += float64(cb) * weight
+					a += float64(ca) * weight
+					total += weight
+				}
+			}
+			if total == 0 || a == 0 {
+				continue
+			}
+
+			// Convert back to non-premultiplied 8-bit color
+			dst.SetNRGBA(offsetX+x, offsetY+y, color.NRGBA{
+				R: uint8(r / a * 255),
+				G: uint8(g / a * 255),
+				B: uint8(b / a * 255),
+				A: uint8(a / total / 257),
+			})
+		}
+	}
+
+	return dst
+}
+
+// overlap returns how much of the unit interval starting at pos lies within [start, end)
+func overlap(pos, start, end float64) float64 {
+	lo, hi := pos, pos+1
+	if start > lo {
+		lo = start
+	}
+	if end < hi {
+		hi = end
+	}
+	if hi <= lo {
+		return 0
+	}
+	return hi - lo
+}
+
+// encodeBitmapEntry encodes an image as a 32-bit DIB with an AND mask for an ICO entry
+func encodeBitmapEntry(img *image.NRGBA) []byte {
+	le := binary.LittleEndian
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	maskStride := (width + 31) / 32 * 4
+	pixelSize := width * height * 4
+	out := make([]byte, bitmapHeaderSize+pixelSize+maskStride*height)
+
+	// Write BITMAPINFOHEADER, the height includes the AND mask
+	le.PutUint32(out[0:], bitmapHeaderSize)
+	le.PutUint32(out[4:], uint32(width))
+	le.PutUint32(out[8:], uint32(height*2))
+	le.PutUint16(out[12:], 1)  // Planes
+	le.PutUint16(out[14:], 32) // Bits per pixel
+	le.PutUint32(out[20:], uint32(pixelSize+maskStride*height))
+
+	// Write BGRA pixels and mask rows bottom-up
+	pixels := out[bitmapHeaderSize:]
+	mask := out[bitmapHeaderSize+pixelSize:]
+	for y := 0; y < height; y++ {
+		row := height - 1 - y
+		for x := 0; x < width; x++ {
+			c := img.NRGBAAt(x, y)
+			i := (row*width + x) * 4
+			pixels[i] = c.B
+			pixels[i+1] = c.G
+			pixels[i+2] = c.R
+			pixels[i+3] = c.A
+			if c.A == 0 {
+				mask[row*maskStride+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// pngSignature starts ICO entries stored as PNG
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// DecodeICO decodes the largest image of an ICO file.
+// Entries may be PNG or bitmaps with 1, 4, 8, 24 or 32 bits per pixel.
+func DecodeICO(data []byte) (image.Image, error) {
+	le := binary.LittleEndian
+	if len(data) < icoHeaderSize || le.Uint16(data[0:]) != 0 || le.Uint16(data[2:]) != 1 {
+		return nil, fmt.Errorf("not an ICO file")
+	}
+	count := int(le.Uint16(data[4:]))
+	if count == 0 || len(data) < icoHeaderSize+count*icoEntrySize {
+		return nil, fmt.Errorf("invalid ICO directory")
+	}
+
+	// Pick the largest entry, preferring more colors
+	best, bestSize, bestBits := -1, 0, 0
+	for i := 0; i < count; i++ {
+		entry := data[icoHeaderSize+i*icoEntrySize:]
+		size := int(entry[0])
+		if size == 0 {
+			size = 256
+		}
+		bits := int(le.Uint16(entry[6:]))
+		if size > bestSize || (size == bestSize && bits > bestBits) {
+			best, bestSize, bestBits = i, size, bits
+		}
+	}
+
+	// Get the entry data
+	entry := data[icoHeaderSize+best*icoEntrySize:]
+	length := int(le.Uint32(entry[8:]))
+	offset := int(le.Uint32(entry[12:]))
+	if offset < 0 || length <= 0 || offset+length > len(data) {
+		return nil, fmt.Errorf("invalid ICO entry")
+	}
+	entryData := data[offset : offset+length]
+
+	if bytes.HasPrefix(entryData, pngSignature) {
+		return png.Decode(bytes.NewReader(entryData))
+	}
+	return decodeBitmapEntry(entryData)
+}
+
+// decodeBitmapEntry decodes a DIB stored in an ICO entry, whose height includes the AND mask
+func decodeBitmapEntry(data []byte) (*image.NRGBA, error) {
+	le := binary.LittleEndian
+	if len(data) < bitmapHeaderSize {
+		return nil, fmt.Errorf("invalid ICO bitmap")
+	}
+	headerSize := int(le.Uint32(data[0:]))
+	width := int(int32(le.Uint32(data[4:])))
+	height := int(int32(le.Uint32(data[8:]))) / 2
+	bits := int(le.Uint16(data[14:]))
+	colorsUsed := int(le.Uint32(data[32:]))
+	if headerSize < bitmapHeaderSize || width <= 0 || height <= 0 || width > 256 || height > 256 {
+		return nil, fmt.Errorf("invalid ICO bitmap size")
+	}
+
+	// Read the palette of indexed bitmaps
+	var palette []color.NRGBA
+	offset := headerSize
+	if bits <= 8 {
+		if colorsUsed == 0 {
+			colorsUsed = 1 << bits
+		}
+		if offset+colorsUsed*4 > len(data) {
+			return nil, fmt.Errorf("invalid ICO palette")
+		}
+		for i := 0; i < colorsUsed; i++ {
+			c := data[offset+i*4:]
+			palette = append(palette, color.NRGBA{R: c[2], G: c[1], B: c[0], A: 255})
+		}
+		offset += colorsUsed * 4
+	}
+
+	// Check that pixels and mask fit, rows are padded to 4 bytes
+	switch bits {
+	case 1, 4, 8, 24, 32:
+	default:
+		return nil, fmt.Errorf("unsupported ICO bitmap with %d bits per pixel", bits)
+	}
+	stride := (width*bits + 31) / 32 * 4
+	maskStride := (width + 31) / 32 * 4
+	pixels := data[offset:]
+	if len(pixels) < stride*height {
+		return nil, fmt.Errorf("truncated ICO bitmap")
+	}
+	mask := pixels[stride*height:]
+	hasMask := len(mask) >= maskStride*height
+
+	// Decode rows bottom-up
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := pixels[(height-1-y)*stride:]
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+			switch bits {
+			case 32:
+				c = color.NRGBA{R: row[x*4+2], G: row[x*4+1], B: row[x*4], A: row[x*4+3]}
+				hasAlpha = hasAlpha || c.A != 0
+			case 24:
+				c = color.NRGBA{R: row[x*3+2], G: row[x*3+1], B: row[x*3], A: 255}
+			default:
+				perByte := 8 / bits
+				shift := uint(8 - bits - (x%perByte)*bits)
+				index := int(row[x/perByte]>>shift) & (1<<bits - 1)
+				if index < len(palette) {
+					c = palette[index]
+				}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	// Apply the AND mask unless 32-bit pixels have their own alpha
+	if hasMask && !hasAlpha {
+		for y := 0; y < height; y++ {
+			row := mask[(height-1-y)*maskStride:]
+			for x := 0; x < width; x++ {
+				transparent := row[x/8]&(0x80>>(x%8)) != 0
+				c := img.NRGBAAt(x, y)
+				c.A = 255
+				if transparent {
+					c.A = 0
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+	return img, nil
+}
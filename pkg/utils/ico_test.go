@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testImage returns an image with an opaque red left half and a transparent right half
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width/2; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	return img
+}
+
+// icoEntries decodes every entry of an ICO file, checking the directory against the entry data
+func icoEntries(t *testing.T, data []byte) map[int]image.Image {
+	t.Helper()
+	le := binary.LittleEndian
+	if len(data) < icoHeaderSize || le.Uint16(data[2:]) != 1 {
+		t.Fatal("not an ICO file")
+	}
+
+	entries := map[int]image.Image{}
+	for i := 0; i < int(le.Uint16(data[4:])); i++ {
+		entry := data[icoHeaderSize+i*icoEntrySize:]
+		size := int(entry[0])
+		if size == 0 {
+			size = 256
+		}
+		entryData := data[le.Uint32(entry[12:]) : le.Uint32(entry[12:])+le.Uint32(entry[8:])]
+
+		// Entries from pngEntryMinSize up are PNG, smaller ones are bitmaps
+		var img image.Image
+		var err error
+		if isPNG := bytes.HasPrefix(entryData, pngSignature); isPNG != (size >= pngEntryMinSize) {
+			t.Errorf("%d px entry stored as PNG: %v", size, isPNG)
+		} else if isPNG {
+			img, err = png.Decode(bytes.NewReader(entryData))
+		} else {
+			img, err = decodeBitmapEntry(entryData)
+		}
+		if err != nil {
+			t.Fatalf("failed to decode %d px entry: %v", size, err)
+		}
+		if img != nil && (img.Bounds().Dx() != size || img.Bounds().Dy() != size) {
+			t.Errorf("%d px entry decodes to %v", size, img.Bounds().Size())
+		}
+		entries[size] = img
+	}
+	return entries
+}
+
+func TestEncodeICO(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"large", testImage(512, 512)},
+		{"small favicon", testImage(64, 64)},
+		{"tiny", testImage(16, 16)},
+		{"wide", testImage(300, 150)},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := EncodeICO(&buf, tt.img, IconSizes); err != nil {
+			t.Fatalf("%s: EncodeICO: %v", tt.name, err)
+		}
+		entries := icoEntries(t, buf.Bytes())
+
+		// Every requested size is present, including ones larger than the source
+		for _, size := range IconSizes {
+			img, ok := entries[size]
+			if !ok {
+				t.Errorf("%s: no %d px entry", tt.name, size)
+				continue
+			}
+			if img == nil {
+				continue
+			}
+
+			// The left quarter is opaque red and the right quarter transparent
+			left := color.NRGBAModel.Convert(img.At(size/8, size/2)).(color.NRGBA)
+			right := color.NRGBAModel.Convert(img.At(size-1-size/8, size/2)).(color.NRGBA)
+			if left.R < 250 || left.A < 250 || right.A != 0 {
+				t.Errorf("%s: %d px entry has %v on the left and %v on the right", tt.name, size, left, right)
+			}
+		}
+		if len(entries) != len(IconSizes) {
+			t.Errorf("%s: got %d entries, want %d", tt.name, len(entries), len(IconSizes))
+		}
+
+		// DecodeICO reads back the largest entry
+		img, err := DecodeICO(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: DecodeICO: %v", tt.name, err)
+		}
+		if img.Bounds().Dx() != 256 {
+			t.Errorf("%s: DecodeICO returned a %v image", tt.name, img.Bounds().Size())
+		}
+	}
+}
+
+func TestEncodeICOInvalidSizes(t *testing.T) {
+	for _, sizes := range [][]int{nil, {0}, {16, 512}, {-1}} {
+		if err := EncodeICO(&bytes.Buffer{}, testImage(32, 32), sizes); err == nil {
+			t.Errorf("EncodeICO with sizes %v succeeded", sizes)
+		}
+	}
+}
+
+func TestConvertToICO(t *testing.T) {
+	dir := t.TempDir()
+	img := testImage(48, 48)
+
+	// Write the image in each supported format
+	var pngData, jpegData, gifData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		valid bool
+	}{
+		{"favicon.png", pngData.Bytes(), true},
+		{"favicon.jpg", jpegData.Bytes(), true},
+		{"favicon.gif", gifData.Bytes(), true},
+		{"page.html", []byte("<!doctype html><title>Not found</title>"), false},
+		{"program.exe", append([]byte("MZ"), make([]byte, 512)...), false},
+		{"empty.png", nil, false},
+		{"truncated.png", pngData.Bytes()[:len(pngData.Bytes())/2], false},
+	}
+	for _, tt := range tests {
+		input := filepath.Join(dir, tt.name)
+		output := input + ".ico"
+		if err := os.WriteFile(input, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := ConvertToICO(input, output)
+		if !tt.valid {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			if _, statErr := os.Stat(output); statErr == nil {
+				t.Errorf("%s: output written for a rejected image", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries := icoEntries(t, data); len(entries) != len(IconSizes) {
+			t.Errorf("%s: got %d entries, want %d", tt.name, len(entries), len(IconSizes))
+		}
+	}
+}
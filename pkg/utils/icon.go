@@ -1,88 +1,43 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"syscall"
-)
 
-// ConvertToICO converts an image file to ICO format using rcedit.exe
-// Since we don't have a direct way to convert images to ICO in Go,
-// we'll use rcedit.exe to set the icon on a temporary executable,
-// then extract the icon from that executable.
-func ConvertToICO(imagePath, rceditPath, outputPath string) error {
-	// Check if rcedit.exe exists
-	if _, err := os.Stat(rceditPath); os.IsNotExist(err) {
-		return fmt.Errorf("rcedit.exe not found at %s", rceditPath)
-	}
+	"github.com/kemalersin/hobaa/pkg/resedit"
+)
 
-	// Create a temporary directory
-	tempDir, err := os.MkdirTemp("", "hobaa_icon_convert")
+// ConvertToICO converts a PNG, JPEG or GIF image file to a multi-resolution ICO file
+func ConvertToICO(imagePath, outputPath string) error {
+	// Open image
+	file, err := os.Open(imagePath)
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tempDir)
+	defer file.Close()
 
-	// Create a temporary executable
-	tempExePath := filepath.Join(tempDir, "temp.exe")
-	
-	// Copy hobaa.exe to temp.exe
-	srcExe, err := os.Open(os.Args[0])
+	// Decode image, rejecting anything that isn't an image
+	img, err := DecodeImage(file)
 	if err != nil {
 		return err
 	}
-	defer srcExe.Close()
-	
-	dstExe, err := os.Create(tempExePath)
-	if err != nil {
-		return err
-	}
-	defer dstExe.Close()
-	
-	if _, err := dstExe.ReadFrom(srcExe); err != nil {
-		return err
-	}
-	
-	// Close files to ensure they're fully written
-	srcExe.Close()
-	dstExe.Close()
-
-	// Set the icon on the temporary executable with high quality settings
-	cmd := exec.Command(rceditPath, tempExePath, "--set-icon", imagePath)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set icon: %v", err)
-	}
 
-	// Extract the icon resource from the executable
-	// This is a more robust approach than just renaming the executable
-	if err := extractIconToICO(tempExePath, outputPath); err != nil {
-		// If extraction fails, fall back to the original method
-		if err := os.Rename(tempExePath, outputPath); err != nil {
-			return fmt.Errorf("failed to save icon: %v", err)
-		}
+	// Encode ICO to a buffer so a failed conversion doesn't leave a broken file
+	var buf bytes.Buffer
+	if err := EncodeICO(&buf, img, IconSizes); err != nil {
+		return fmt.Errorf("failed to encode icon: %v", err)
 	}
 
-	return nil
-}
-
-// extractIconToICO extracts the icon from an executable to an ICO file
-// This is a placeholder function - in a real implementation, you would use
-// Windows API to extract the icon resource properly
-func extractIconToICO(exePath, icoPath string) error {
-	// In a real implementation, this would use Windows API to extract the icon
-	// For now, we'll just copy the executable as the ICO file
-	return copyFile(exePath, icoPath)
+	return os.WriteFile(outputPath, buf.Bytes(), 0644)
 }
 
-// SetExecutableIcon sets the icon of an executable using rcedit.exe
-func SetExecutableIcon(exePath, iconPath, rceditPath string) error {
+// SetExecutableIcon sets the icon of an executable by editing its PE resources
+func SetExecutableIcon(exePath, iconPath string) error {
 	// Check if files exist
 	if _, err := os.Stat(exePath); os.IsNotExist(err) {
 		return fmt.Errorf("executable not found at %s", exePath)
@@ -90,9 +45,6 @@ func SetExecutableIcon(exePath, iconPath, rceditPath string) error {
 	if _, err := os.Stat(iconPath); os.IsNotExist(err) {
 		return fmt.Errorf("icon not found at %s", iconPath)
 	}
-	if _, err := os.Stat(rceditPath); os.IsNotExist(err) {
-		return fmt.Errorf("rcedit.exe not found at %s", rceditPath)
-	}
 
 	// Create a temporary directory
 	tempDir, err := os.MkdirTemp("", "hobaa_icon_change")
@@ -149,15 +101,9 @@ func SetExecutableIcon(exePath, iconPath, rceditPath string) error {
 	dstExe.Close()
 	bakExe.Close()
 
-	// Run rcedit.exe to set the icon on the temporary file
-	cmd := exec.Command(rceditPath, tempExePath, "--set-icon", iconPath)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		HideWindow: true,
-	}
-	
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to set icon: %v, output: %s", err, string(output))
+	// Replace the icon resources of the temporary file
+	if err := resedit.SetIconFromFile(tempExePath, iconPath); err != nil {
+		return fmt.Errorf("failed to set icon: %v", err)
 	}
 	
 	// Replace the original executable with the modified one
@@ -205,24 +151,17 @@ func copyFile(src, dst string) error {
 	return dstFile.Sync()
 }
 
-// RestartApplication restarts the application with the --force parameter
-func RestartApplication() error {
+// RestartApplication restarts the application with the --force parameter.
+// The command function creates the process, such as the Command method of the platform.
+func RestartApplication(command func(name string, args ...string) *exec.Cmd) error {
 	// Get the path of the current executable
 	exePath, err := os.Executable()
 	if err != nil {
 		return err
 	}
 
-	// Create the command to restart the application
-	cmd := exec.Command(exePath, "--force")
-	
-	// Set the command to run detached from the current process
-	if runtime.GOOS == "windows" {
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
-			HideWindow:    true,
-		}
-	}
+	// Create the command to restart the application detached from the current process
+	cmd := command(exePath, "--force")
 	
 	// Start the command
 	if err := cmd.Start(); err != nil {
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// WebManifest represents a W3C web app manifest
+type WebManifest struct {
+	Name            string         `json:"name"`
+	ShortName       string         `json:"short_name"`
+	StartURL        string         `json:"start_url"`
+	Scope           string         `json:"scope"`
+	Display         string         `json:"display"`
+	ThemeColor      string         `json:"theme_color"`
+	BackgroundColor string         `json:"background_color"`
+	Icons           []ManifestIcon `json:"icons"`
+}
+
+// ManifestIcon represents an entry of the icons array of a web app manifest
+type ManifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose"`
+}
+
+// ParseManifest parses a web app manifest
+func ParseManifest(data []byte) (*WebManifest, error) {
+	var manifest WebManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// DownloadManifest downloads and parses a web app manifest
+func DownloadManifest(manifestURL string) (*WebManifest, error) {
+	data, err := DownloadJSON(manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+// ResolveSameOrigin resolves a manifest URL member and returns it only if it has the same origin as the page.
+// URLs with another origin must be ignored according to the web app manifest specification.
+func ResolveSameOrigin(base *url.URL, ref, pageURL string) string {
+	resolved := resolveURL(base, ref)
+	if resolved == "" {
+		return ""
+	}
+
+	u, err := url.Parse(resolved)
+	if err != nil {
+		return ""
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil || !strings.EqualFold(u.Scheme, page.Scheme) || !strings.EqualFold(u.Host, page.Host) {
+		return ""
+	}
+
+	return resolved
+}
+
+// IconCandidates returns the manifest icons with URLs resolved against the manifest URL
+func (m *WebManifest) IconCandidates(base *url.URL) []IconCandidate {
+	var icons []IconCandidate
+	for _, icon := range m.Icons {
+		iconURL := resolveURL(base, icon.Src)
+		if iconURL == "" {
+			continue
+		}
+		icons = append(icons, IconCandidate{
+			URL:     iconURL,
+			Source:  "manifest",
+			Type:    icon.Type,
+			Size:    parseIconSizes(icon.Sizes),
+			Purpose: icon.Purpose,
+		})
+	}
+	return icons
+}
@@ -12,6 +12,9 @@ import (
 	"time"
 )
 
+// maxPageSize is the maximum number of bytes read from a web page
+const maxPageSize = 2 << 20
+
 // IsValidURL checks if a string is a valid URL
 func IsValidURL(str string) bool {
 	// Check for common TLDs to avoid treating random EXE names as URLs
@@ -107,8 +110,48 @@ func DownloadJSON(url string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-// GetFaviconURL returns the favicon URL for a website
+// DownloadPage downloads a web page and returns its contents with the final URL after redirects
+func DownloadPage(pageURL string) ([]byte, *url.URL, error) {
+	// Create HTTP client with timeout
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	// Send GET request
+	resp, err := client.Get(pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	// Read a limited amount of the response body
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, resp.Request.URL, nil
+}
+
+// GetFaviconURL returns the URL of the best icon for a website
 func GetFaviconURL(websiteURL string) (string, error) {
+	// Discover icons from the page and its web app manifest
+	if icons, err := DiscoverIcons(websiteURL); err == nil {
+		if iconURL, err := bestIconURL(icons); err == nil {
+			return iconURL, nil
+		}
+	}
+	return ProbeFaviconURL(websiteURL)
+}
+
+// ProbeFaviconURL returns the URL of the favicon at the conventional location of a website,
+// for sites whose page declares no usable icon
+func ProbeFaviconURL(websiteURL string) (string, error) {
 	// Parse URL
 	u, err := url.Parse(websiteURL)
 	if err != nil {
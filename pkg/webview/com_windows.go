@@ -0,0 +1,107 @@
+package webview
+
+import (
+	"fmt"
+	"reflect"
+	"syscall"
+	"unsafe"
+
+	"github.com/jchv/go-webview2/pkg/edge"
+)
+
+// Vtable indexes of IUnknown, which every COM object starts with
+const (
+	comQueryInterface = 0
+	comAddRef         = 1
+	comRelease        = 2
+)
+
+var (
+	ole32             = syscall.NewLazyDLL("ole32.dll")
+	procCoTaskMemFree = ole32.NewProc("CoTaskMemFree")
+)
+
+// comObject is a COM object created by WebView2
+type comObject struct {
+	vtbl *[128]uintptr
+}
+
+// call calls a method of the object by its vtable index.
+// Pointers converted to uintptr arguments are kept alive and in place during the call.
+//
+//go:uintptrescapes
+func (o *comObject) call(method int, args ...uintptr) uintptr {
+	r, _, _ := syscall.SyscallN(o.vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	return r
+}
+
+// string calls a getter returning a string allocated by COM and frees it
+func (o *comObject) string(method int) string {
+	var p *uint16
+	if o.call(method, uintptr(unsafe.Pointer(&p))) != 0 || p == nil {
+		return ""
+	}
+	defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(p)))
+
+	var chars []uint16
+	for c := p; *c != 0; c = (*uint16)(unsafe.Add(unsafe.Pointer(c), 2)) {
+		chars = append(chars, *c)
+	}
+	return syscall.UTF16ToString(chars)
+}
+
+// comHandler is a COM event handler implemented in Go.
+// WebView2 keeps raw pointers to handlers, so they must stay referenced while they are registered.
+type comHandler struct {
+	vtbl   *comHandlerVtbl
+	invoke func(sender, args *comObject)
+}
+
+// comHandlerVtbl is the vtable shared by all event handlers, which only differ in their Invoke arguments
+type comHandlerVtbl struct {
+	QueryInterface uintptr
+	AddRef         uintptr
+	Release        uintptr
+	Invoke         uintptr
+}
+
+// Handlers are referenced from Go, so reference counting is not needed
+var comHandlerFns = &comHandlerVtbl{
+	QueryInterface: syscall.NewCallback(func(this *comHandler, iid uintptr, object **comHandler) uintptr {
+		*object = this
+		return 0
+	}),
+	AddRef: syscall.NewCallback(func(this *comHandler) uintptr {
+		return 1
+	}),
+	Release: syscall.NewCallback(func(this *comHandler) uintptr {
+		return 1
+	}),
+	Invoke: syscall.NewCallback(func(this *comHandler, sender, args *comObject) uintptr {
+		this.invoke(sender, args)
+		return 0
+	}),
+}
+
+// newComHandler creates an event handler calling invoke
+func newComHandler(invoke func(sender, args *comObject)) *comHandler {
+	return &comHandler{vtbl: comHandlerFns, invoke: invoke}
+}
+
+// coreWebView2 returns the ICoreWebView2 of the window.
+// go-webview2 doesn't expose it, so it is read from the Chromium browser the window embeds.
+func (w *WebView) coreWebView2() (*comObject, error) {
+	v := reflect.ValueOf(w.window)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, fmt.Errorf("webview is not created")
+	}
+	browser := v.Elem().FieldByName("browser")
+	if !browser.IsValid() || browser.IsNil() || browser.Elem().Type() != reflect.TypeOf(&edge.Chromium{}) {
+		return nil, fmt.Errorf("webview doesn't use a Chromium browser")
+	}
+	core := browser.Elem().Elem().FieldByName("webview")
+	if !core.IsValid() || core.IsNil() {
+		return nil, fmt.Errorf("browser is not initialized")
+	}
+	return (*comObject)(core.UnsafePointer()), nil
+}
@@ -0,0 +1,38 @@
+package webview
+
+// DownloadState is the state of a download
+type DownloadState string
+
+// Download states
+const (
+	DownloadInProgress  DownloadState = "in_progress"
+	DownloadCompleted   DownloadState = "completed"
+	DownloadInterrupted DownloadState = "interrupted"
+)
+
+// DownloadRequest describes a download a page started
+type DownloadRequest struct {
+	ID            int // Identifies the download until the webview is destroyed
+	URL           string
+	MimeType      string
+	SuggestedPath string // Path the browser would save the file to
+}
+
+// DownloadUpdate reports the progress of a download
+type DownloadUpdate struct {
+	ID            int
+	State         DownloadState
+	BytesReceived int64
+	TotalBytes    int64 // 0 if the size is unknown
+	Path          string
+}
+
+// DownloadHandler decides where downloads are saved and follows their progress.
+// Its methods are called on the UI thread.
+type DownloadHandler interface {
+	// DownloadStarting returns the path to save a download to, or false to cancel it
+	DownloadStarting(req DownloadRequest) (path string, ok bool)
+
+	// DownloadChanged reports received bytes and state changes of a download
+	DownloadChanged(update DownloadUpdate)
+}
@@ -0,0 +1,183 @@
+package webview
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// IID of ICoreWebView2_4, which adds the DownloadStarting event
+var iidICoreWebView2_4 = syscall.GUID{
+	Data1: 0x20d02d59,
+	Data2: 0x6df2,
+	Data3: 0x42dc,
+	Data4: [8]byte{0xbd, 0x06, 0xf9, 0x8a, 0x69, 0x4b, 0x13, 0x02},
+}
+
+// Vtable indexes of the WebView2 methods used for downloads, counted from IUnknown
+const (
+	// ICoreWebView2_4
+	webViewAddDownloadStarting = 75
+
+	// ICoreWebView2DownloadStartingEventArgs
+	startingGetDownloadOperation = 3
+	startingPutCancel            = 5
+	startingGetResultFilePath    = 6
+	startingPutResultFilePath    = 7
+	startingPutHandled           = 9
+
+	// ICoreWebView2DownloadOperation
+	operationAddBytesReceivedChanged    = 3
+	operationRemoveBytesReceivedChanged = 4
+	operationAddStateChanged            = 7
+	operationRemoveStateChanged         = 8
+	operationGetURI                     = 9
+	operationGetMimeType                = 11
+	operationGetTotalBytesToReceive     = 12
+	operationGetBytesReceived           = 13
+	operationGetResultFilePath          = 15
+	operationGetState                   = 16
+	operationCancel                     = 18
+)
+
+// COREWEBVIEW2_DOWNLOAD_STATE values
+var downloadStates = map[int32]DownloadState{
+	0: DownloadInProgress,
+	1: DownloadInterrupted,
+	2: DownloadCompleted,
+}
+
+// downloadEvents follows the downloads of a webview
+type downloadEvents struct {
+	handler    DownloadHandler
+	starting   *comHandler
+	nextID     int
+	operations map[int]*download
+}
+
+// download is a download in progress
+type download struct {
+	operation     *comObject
+	changed       *comHandler
+	stateToken    int64
+	receivedToken int64
+}
+
+// SetDownloadHandler saves downloads where the handler decides instead of showing the browser's download UI.
+// It must be called on the UI thread, before pages start downloads.
+func (w *WebView) SetDownloadHandler(h DownloadHandler) error {
+	if w.downloads != nil {
+		w.downloads.handler = h
+		return nil
+	}
+
+	core, err := w.coreWebView2()
+	if err != nil {
+		return err
+	}
+
+	// Downloads need ICoreWebView2_4, available since WebView2 Runtime 92
+	var core4 *comObject
+	if core.call(comQueryInterface, uintptr(unsafe.Pointer(&iidICoreWebView2_4)), uintptr(unsafe.Pointer(&core4))) != 0 || core4 == nil {
+		return fmt.Errorf("the WebView2 Runtime doesn't support download events")
+	}
+	defer core4.call(comRelease)
+
+	events := &downloadEvents{handler: h, operations: map[int]*download{}}
+	events.starting = newComHandler(events.onStarting)
+	var token int64
+	if hr := core4.call(webViewAddDownloadStarting, uintptr(unsafe.Pointer(events.starting)), uintptr(unsafe.Pointer(&token))); hr != 0 {
+		return fmt.Errorf("failed to handle downloads: HRESULT 0x%x", hr)
+	}
+	w.downloads = events
+	return nil
+}
+
+// CancelDownload cancels a download in progress, it must be called on the UI thread
+func (w *WebView) CancelDownload(id int) error {
+	if w.downloads == nil {
+		return fmt.Errorf("downloads are not handled")
+	}
+	d, ok := w.downloads.operations[id]
+	if !ok {
+		return fmt.Errorf("download %d is not in progress", id)
+	}
+	d.operation.call(operationCancel)
+	return nil
+}
+
+// onStarting chooses the path of a new download and starts following it
+func (e *downloadEvents) onStarting(sender, args *comObject) {
+	var operation *comObject
+	if args.call(startingGetDownloadOperation, uintptr(unsafe.Pointer(&operation))) != 0 || operation == nil {
+		return
+	}
+	defer operation.call(comRelease)
+
+	e.nextID++
+	id := e.nextID
+	path, ok := e.handler.DownloadStarting(DownloadRequest{
+		ID:            id,
+		URL:           operation.string(operationGetURI),
+		MimeType:      operation.string(operationGetMimeType),
+		SuggestedPath: args.string(startingGetResultFilePath),
+	})
+	if !ok {
+		args.call(startingPutCancel, 1)
+		return
+	}
+
+	// Save to the chosen path without the browser's download bubble
+	pathW, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		args.call(startingPutCancel, 1)
+		return
+	}
+	args.call(startingPutResultFilePath, uintptr(unsafe.Pointer(pathW)))
+	args.call(startingPutHandled, 1)
+
+	// Report progress and state changes until the download ends
+	operation.call(comAddRef)
+	d := &download{operation: operation}
+	d.changed = newComHandler(func(*comObject, *comObject) {
+		e.onChanged(id, d)
+	})
+	operation.call(operationAddStateChanged, uintptr(unsafe.Pointer(d.changed)), uintptr(unsafe.Pointer(&d.stateToken)))
+	operation.call(operationAddBytesReceivedChanged, uintptr(unsafe.Pointer(d.changed)), uintptr(unsafe.Pointer(&d.receivedToken)))
+	e.operations[id] = d
+	e.onChanged(id, d)
+}
+
+// onChanged reports the progress of a download and stops following it once it ended
+func (e *downloadEvents) onChanged(id int, d *download) {
+	if _, ok := e.operations[id]; !ok {
+		return
+	}
+
+	var state int32
+	var received, total int64
+	d.operation.call(operationGetState, uintptr(unsafe.Pointer(&state)))
+	d.operation.call(operationGetBytesReceived, uintptr(unsafe.Pointer(&received)))
+	d.operation.call(operationGetTotalBytesToReceive, uintptr(unsafe.Pointer(&total)))
+	update := DownloadUpdate{
+		ID:            id,
+		State:         downloadStates[state],
+		BytesReceived: received,
+		TotalBytes:    total,
+		Path:          d.operation.string(operationGetResultFilePath),
+	}
+	if update.State == "" {
+		update.State = DownloadInterrupted
+	}
+	if update.TotalBytes < 0 {
+		update.TotalBytes = 0
+	}
+
+	if update.State != DownloadInProgress {
+		d.operation.call(operationRemoveStateChanged, uintptr(d.stateToken))
+		d.operation.call(operationRemoveBytesReceivedChanged, uintptr(d.receivedToken))
+		d.operation.call(comRelease)
+		delete(e.operations, id)
+	}
+	e.handler.DownloadChanged(update)
+}
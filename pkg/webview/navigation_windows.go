@@ -0,0 +1,55 @@
+package webview
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Vtable indexes of the WebView2 methods used to filter navigations and read the current URL, counted from IUnknown
+const (
+	// ICoreWebView2
+	webViewGetSource             = 4
+	webViewAddNavigationStarting = 7
+
+	// ICoreWebView2NavigationStartingEventArgs
+	navigationGetURI    = 3
+	navigationPutCancel = 8
+)
+
+// SetNavigationFilter cancels the navigations of the top-level document that the filter refuses,
+// whether they come from links, scripts, forms, meta refreshes or server redirects. Frames aren't filtered.
+// It must be called on the UI thread.
+func (w *WebView) SetNavigationFilter(filter func(url string) bool) error {
+	if w.navigation != nil {
+		w.navigationFilter = filter
+		return nil
+	}
+
+	core, err := w.coreWebView2()
+	if err != nil {
+		return err
+	}
+
+	w.navigationFilter = filter
+	handler := newComHandler(func(sender, args *comObject) {
+		if !w.navigationFilter(args.string(navigationGetURI)) {
+			args.call(navigationPutCancel, 1)
+		}
+	})
+	var token int64
+	if hr := core.call(webViewAddNavigationStarting, uintptr(unsafe.Pointer(handler)), uintptr(unsafe.Pointer(&token))); hr != 0 {
+		return fmt.Errorf("failed to filter navigations: HRESULT 0x%x", hr)
+	}
+	w.navigation = handler
+	return nil
+}
+
+// Source returns the URL of the top-level document, or "" if it can't be read.
+// It must be called on the UI thread, where bound functions also run.
+func (w *WebView) Source() string {
+	core, err := w.coreWebView2()
+	if err != nil {
+		return ""
+	}
+	return core.string(webViewGetSource)
+}
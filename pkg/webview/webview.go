@@ -1,117 +1,40 @@
+// Package webview provides the webview window used to display sites
 package webview
 
 import (
-	"github.com/jchv/go-webview2"
-	"github.com/kemalersin/hobaa/pkg/winapi"
 	"os"
 	"path/filepath"
-	"unsafe"
 )
 
-// WebView represents a webview window
-type WebView struct {
-	window webview2.WebView
-}
-
 // WindowOptions contains options for creating a webview window
 type WindowOptions struct {
-	Title    string
-	URL      string
-	Width    int
-	Height   int
-	Debug    bool
-	Icon     string // Path to icon file
-	DataDir  string // Path to WebView data directory
+	Title   string
+	URL     string
+	Width   int
+	Height  int
+	Debug   bool
+	Icon    string // Path to icon file
+	DataDir string // Path to WebView data directory
 }
 
-// New creates a new webview with the given options
-func New(options WindowOptions) *WebView {
-	// Set default values if not provided
+// Default window size used when no size is configured
+const (
+	DefaultWidth  = 1920
+	DefaultHeight = 1080
+)
+
+// setDefaults sets default values for options that are not provided
+func setDefaults(options *WindowOptions) {
 	if options.Width <= 0 {
-		options.Width = 1920
+		options.Width = DefaultWidth
 	}
 	if options.Height <= 0 {
-		options.Height = 1080
-	}
-
-	// Create webview
-	w := webview2.NewWithOptions(webview2.WebViewOptions{
-		Debug:     options.Debug,
-		AutoFocus: true,
-		DataPath:  options.DataDir, // Set WebView data directory
-		WindowOptions: webview2.WindowOptions{
-			Title:  options.Title,
-			Width:  uint(options.Width),
-			Height: uint(options.Height),
-			Center: true,
-		},
-	})
-
-	// Create WebView instance
-	webView := &WebView{
-		window: w,
+		options.Height = DefaultHeight
 	}
-
-	// Set icon if provided
-	if options.Icon != "" {
-		webView.SetIcon(options.Icon)
-	}
-	
-	// Inject back button script
-	injectBackButtonScript(w)
-
-	// Navigate to URL if provided
-	if options.URL != "" {
-		webView.Navigate(options.URL)
-	}
-
-	return webView
-}
-
-// Navigate navigates to the specified URL
-func (w *WebView) Navigate(url string) {
-	w.window.Navigate(url)
-}
-
-// Run starts the webview main loop
-func (w *WebView) Run() {
-	w.window.Run()
-}
-
-// Destroy destroys the webview
-func (w *WebView) Destroy() {
-	w.window.Destroy()
-}
-
-// Window returns the native window handle
-func (w *WebView) Window() unsafe.Pointer {
-	return w.window.Window()
-}
-
-// SetIcon sets the window icon
-func (w *WebView) SetIcon(iconPath string) {
-	// Check if icon exists
-	if _, err := os.Stat(iconPath); os.IsNotExist(err) {
-		return
-	}
-	
-	// Set window icon using Windows API
-	hwnd := w.Window()
-	if hwnd != nil {
-		// Convert to uintptr
-		handle := uintptr(hwnd)
-		// Set icon
-		winapi.SetWindowIcon(handle, iconPath)
-	}
-}
-
-// Init initializes the webview with JavaScript
-func (w *WebView) Init(js string) {
-	w.window.Init(js)
 }
 
 // injectBackButtonScript injects JavaScript to add a back button to the WebView
-func injectBackButtonScript(w webview2.WebView) {
+func injectBackButtonScript(w *WebView) {
 	// Wait for the DOM to be loaded
 	w.Init(`
 		// Create and inject CSS for back button
@@ -251,7 +174,7 @@ func injectBackButtonScript(w webview2.WebView) {
 			originalReplaceState.apply(this, arguments);
 			setTimeout(updateBackButtonVisibility, 100);
 		};
-	`);
+	`)
 }
 
 // GetExecutablePath returns the path of the current executable
@@ -272,4 +195,4 @@ func GetExecutableDir() string {
 		return ""
 	}
 	return filepath.Dir(exePath)
-} 
\ No newline at end of file
+}
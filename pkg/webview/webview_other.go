@@ -0,0 +1,162 @@
+//go:build !windows
+
+package webview
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// WebView represents a headless webview used on platforms without WebView2
+type WebView struct {
+	options  WindowOptions
+	url      string
+	scripts  []string
+	evals    []string
+	bindings map[string]interface{}
+
+	downloads      DownloadHandler
+	nextDownloadID int
+
+	navigationFilter func(url string) bool
+}
+
+// New creates a new headless webview with the given options
+func New(options WindowOptions) *WebView {
+	// Set default values if not provided
+	setDefaults(&options)
+
+	// Create WebView instance
+	webView := &WebView{
+		options:  options,
+		bindings: map[string]interface{}{},
+	}
+
+	// Inject back button script
+	injectBackButtonScript(webView)
+
+	// Navigate to URL if provided
+	if options.URL != "" {
+		webView.Navigate(options.URL)
+	}
+
+	return webView
+}
+
+// Navigate records the specified URL as the current URL
+func (w *WebView) Navigate(url string) {
+	w.url = url
+}
+
+// SetTitle records the window title
+func (w *WebView) SetTitle(title string) {
+	w.options.Title = title
+}
+
+// Run returns immediately since there is no main loop
+func (w *WebView) Run() {}
+
+// Destroy does nothing for a headless webview
+func (w *WebView) Destroy() {}
+
+// Window returns nil since there is no native window
+func (w *WebView) Window() unsafe.Pointer {
+	return nil
+}
+
+// SetIcon does nothing for a headless webview
+func (w *WebView) SetIcon(iconPath string) {}
+
+// Init records the JavaScript to run on page load
+func (w *WebView) Init(js string) {
+	w.scripts = append(w.scripts, js)
+}
+
+// Bind records a Go function exposed to JavaScript
+func (w *WebView) Bind(name string, f interface{}) error {
+	w.bindings[name] = f
+	return nil
+}
+
+// Eval records the JavaScript run in the current page
+func (w *WebView) Eval(js string) {
+	w.evals = append(w.evals, js)
+}
+
+// Dispatch runs the function right away since there is no UI thread
+func (w *WebView) Dispatch(f func()) {
+	f()
+}
+
+// Options returns the options the webview was created with
+func (w *WebView) Options() WindowOptions {
+	return w.options
+}
+
+// URL returns the last navigated URL
+func (w *WebView) URL() string {
+	return w.url
+}
+
+// Source returns the URL of the top-level document, the last URL navigated to
+func (w *WebView) Source() string {
+	return w.url
+}
+
+// Scripts returns the JavaScript registered with Init
+func (w *WebView) Scripts() []string {
+	return w.scripts
+}
+
+// Evals returns the JavaScript run with Eval
+func (w *WebView) Evals() []string {
+	return w.evals
+}
+
+// Bindings returns the Go functions registered with Bind
+func (w *WebView) Bindings() map[string]interface{} {
+	return w.bindings
+}
+
+// SetNavigationFilter records the filter called by PageNavigate
+func (w *WebView) SetNavigationFilter(filter func(url string) bool) error {
+	w.navigationFilter = filter
+	return nil
+}
+
+// PageNavigate simulates the page navigating its top-level document, as links, scripts, forms and redirects do.
+// It returns false if the navigation filter cancelled the navigation.
+func (w *WebView) PageNavigate(url string) bool {
+	if w.navigationFilter != nil && !w.navigationFilter(url) {
+		return false
+	}
+	w.url = url
+	return true
+}
+
+// SetDownloadHandler records the handler called by Download
+func (w *WebView) SetDownloadHandler(h DownloadHandler) error {
+	w.downloads = h
+	return nil
+}
+
+// CancelDownload always fails since downloads finish right away
+func (w *WebView) CancelDownload(id int) error {
+	return fmt.Errorf("download %d is not in progress", id)
+}
+
+// Download simulates a page downloading size bytes, reporting it to the download handler as completed.
+// It returns the path chosen by the handler, or false if the handler cancelled the download.
+func (w *WebView) Download(url, mimeType, suggestedPath string, size int64) (string, bool) {
+	if w.downloads == nil {
+		return "", false
+	}
+	w.nextDownloadID++
+	id := w.nextDownloadID
+	path, ok := w.downloads.DownloadStarting(DownloadRequest{ID: id, URL: url, MimeType: mimeType, SuggestedPath: suggestedPath})
+	if !ok {
+		return "", false
+	}
+	w.downloads.DownloadChanged(DownloadUpdate{ID: id, State: DownloadCompleted, BytesReceived: size, TotalBytes: size, Path: path})
+	return path, true
+}
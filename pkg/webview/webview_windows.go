@@ -0,0 +1,126 @@
+package webview
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/jchv/go-webview2"
+	"github.com/kemalersin/hobaa/pkg/winapi"
+)
+
+// WebView represents a webview window
+type WebView struct {
+	window    webview2.WebView
+	downloads *downloadEvents // Set by SetDownloadHandler
+
+	// Set by SetNavigationFilter
+	navigation       *comHandler
+	navigationFilter func(url string) bool
+}
+
+// New creates a new webview with the given options
+func New(options WindowOptions) *WebView {
+	// Set default values if not provided
+	setDefaults(&options)
+
+	// Create webview
+	w := webview2.NewWithOptions(webview2.WebViewOptions{
+		Debug:     options.Debug,
+		AutoFocus: true,
+		DataPath:  options.DataDir, // Set WebView data directory
+		WindowOptions: webview2.WindowOptions{
+			Title:  options.Title,
+			Width:  uint(options.Width),
+			Height: uint(options.Height),
+			Center: true,
+		},
+	})
+
+	// Create WebView instance
+	webView := &WebView{
+		window: w,
+	}
+
+	// Keep the logical window size when it moves to a monitor with a different DPI
+	if hwnd := w.Window(); hwnd != nil {
+		winapi.HandleDpiChanges(syscall.Handle(uintptr(hwnd)))
+	}
+
+	// Set icon if provided
+	if options.Icon != "" {
+		webView.SetIcon(options.Icon)
+	}
+
+	// Inject back button script
+	injectBackButtonScript(webView)
+
+	// Navigate to URL if provided
+	if options.URL != "" {
+		webView.Navigate(options.URL)
+	}
+
+	return webView
+}
+
+// Navigate navigates to the specified URL
+func (w *WebView) Navigate(url string) {
+	w.window.Navigate(url)
+}
+
+// SetTitle sets the window title
+func (w *WebView) SetTitle(title string) {
+	w.window.SetTitle(title)
+}
+
+// Run starts the webview main loop
+func (w *WebView) Run() {
+	w.window.Run()
+}
+
+// Destroy destroys the webview
+func (w *WebView) Destroy() {
+	w.window.Destroy()
+}
+
+// Window returns the native window handle
+func (w *WebView) Window() unsafe.Pointer {
+	return w.window.Window()
+}
+
+// SetIcon sets the window icon
+func (w *WebView) SetIcon(iconPath string) {
+	// Check if icon exists
+	if _, err := os.Stat(iconPath); os.IsNotExist(err) {
+		return
+	}
+
+	// Set window icon using Windows API
+	hwnd := w.Window()
+	if hwnd != nil {
+		// Convert to uintptr
+		handle := uintptr(hwnd)
+		// Set icon
+		winapi.SetWindowIcon(handle, iconPath)
+	}
+}
+
+// Init initializes the webview with JavaScript
+func (w *WebView) Init(js string) {
+	w.window.Init(js)
+}
+
+// Bind exposes a Go function to JavaScript as a global function returning a promise
+func (w *WebView) Bind(name string, f interface{}) error {
+	return w.window.Bind(name, f)
+}
+
+// Eval runs JavaScript in the current page, it must be called on the UI thread
+func (w *WebView) Eval(js string) {
+	w.window.Eval(js)
+}
+
+// Dispatch runs a function on the UI thread
+func (w *WebView) Dispatch(f func()) {
+	w.window.Dispatch(f)
+}
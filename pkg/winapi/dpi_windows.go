@@ -0,0 +1,72 @@
+package winapi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// DPI constants
+const (
+	WM_DPICHANGED = 0x02E0
+
+	MDT_EFFECTIVE_DPI = 0
+
+	SWP_NOZORDER   = 0x0004
+	SWP_NOACTIVATE = 0x0010
+)
+
+var (
+	procGetDpiForWindow = user32.NewProc("GetDpiForWindow")
+
+	shcore               = syscall.NewLazyDLL("shcore.dll")
+	procGetDpiForMonitor = shcore.NewProc("GetDpiForMonitor")
+)
+
+// GetDpiForWindow returns the DPI of the monitor a window is on, or 0 if it is unknown
+func GetDpiForWindow(hwnd syscall.Handle) int {
+	if procGetDpiForWindow.Find() != nil {
+		return 0
+	}
+	dpi, _, _ := procGetDpiForWindow.Call(uintptr(hwnd))
+	return int(dpi)
+}
+
+// HandleDpiChanges resizes the window to the size Windows suggests when it moves to a monitor with a different DPI.
+// It must be called on the thread that owns the window.
+func HandleDpiChanges(hwnd syscall.Handle) error {
+	return SubclassWindow(hwnd, func(hwnd syscall.Handle, msg uint32, wParam, lParam uintptr) {
+		if msg != WM_DPICHANGED || lParam == 0 {
+			return
+		}
+
+		// The suggested rectangle keeps the logical size of the window on the new monitor
+		rect := (*RECT)(unsafe.Add(nil, lParam))
+		procSetWindowPos.Call(
+			uintptr(hwnd),
+			0,
+			uintptr(rect.Left),
+			uintptr(rect.Top),
+			uintptr(rect.Right-rect.Left),
+			uintptr(rect.Bottom-rect.Top),
+			SWP_NOZORDER|SWP_NOACTIVATE,
+		)
+	})
+}
+
+// getDpiForMonitor returns the effective DPI of a monitor, or 0 if it is unknown
+func getDpiForMonitor(handle uintptr) int {
+	if procGetDpiForMonitor.Find() != nil {
+		return 0
+	}
+	var dpiX, dpiY uint32
+	ret, _, _ := procGetDpiForMonitor.Call(
+		handle,
+		MDT_EFFECTIVE_DPI,
+		uintptr(unsafe.Pointer(&dpiX)),
+		uintptr(unsafe.Pointer(&dpiY)),
+	)
+	if ret != 0 { // S_OK
+		return 0
+	}
+	return int(dpiX)
+}
@@ -0,0 +1,95 @@
+package winapi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Named pipe constants
+const (
+	PIPE_ACCESS_DUPLEX            = 0x00000003
+	FILE_FLAG_FIRST_PIPE_INSTANCE = 0x00080000
+	PIPE_TYPE_BYTE                = 0x00000000
+	PIPE_READMODE_BYTE            = 0x00000000
+	PIPE_WAIT                     = 0x00000000
+	PIPE_REJECT_REMOTE_CLIENTS    = 0x00000008
+	PIPE_UNLIMITED_INSTANCES      = 255
+
+	ERROR_ACCESS_DENIED  syscall.Errno = 5
+	ERROR_PIPE_BUSY      syscall.Errno = 231
+	ERROR_PIPE_CONNECTED syscall.Errno = 535
+
+	pipeBufferSize = 4096
+)
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procCreateNamedPipeW = kernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe = kernel32.NewProc("ConnectNamedPipe")
+	procWaitNamedPipeW   = kernel32.NewProc("WaitNamedPipeW")
+)
+
+// CreateNamedPipe creates an instance of a local byte mode named pipe.
+// With first set it fails with ERROR_ACCESS_DENIED if the pipe already exists.
+func CreateNamedPipe(name string, first bool) (syscall.Handle, error) {
+	nameW, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return syscall.InvalidHandle, err
+	}
+
+	openMode := uint32(PIPE_ACCESS_DUPLEX)
+	if first {
+		openMode |= FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	handle, _, err := procCreateNamedPipeW.Call(
+		uintptr(unsafe.Pointer(nameW)),
+		uintptr(openMode),
+		PIPE_TYPE_BYTE|PIPE_READMODE_BYTE|PIPE_WAIT|PIPE_REJECT_REMOTE_CLIENTS,
+		PIPE_UNLIMITED_INSTANCES,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		0,
+	)
+	if syscall.Handle(handle) == syscall.InvalidHandle {
+		return syscall.InvalidHandle, err
+	}
+	return syscall.Handle(handle), nil
+}
+
+// ConnectNamedPipe waits for a client to connect to a pipe instance
+func ConnectNamedPipe(pipe syscall.Handle) error {
+	ret, _, err := procConnectNamedPipe.Call(uintptr(pipe), 0)
+	if ret == 0 && err != ERROR_PIPE_CONNECTED {
+		return err
+	}
+	return nil
+}
+
+// OpenNamedPipe connects to a named pipe, waiting up to timeout milliseconds for a free instance
+func OpenNamedPipe(name string, timeout uint32) (syscall.Handle, error) {
+	nameW, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return syscall.InvalidHandle, err
+	}
+
+	for {
+		handle, err := syscall.CreateFile(
+			nameW,
+			syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+			0,
+			nil,
+			syscall.OPEN_EXISTING,
+			0,
+			0,
+		)
+		if err != ERROR_PIPE_BUSY {
+			return handle, err
+		}
+
+		// All instances are busy, wait for one to become free
+		if ret, _, err := procWaitNamedPipeW.Call(uintptr(unsafe.Pointer(nameW)), uintptr(timeout)); ret == 0 {
+			return syscall.InvalidHandle, err
+		}
+	}
+}
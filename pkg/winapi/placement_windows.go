@@ -0,0 +1,181 @@
+package winapi
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/kemalersin/hobaa/pkg/geometry"
+)
+
+// Window placement constants
+const (
+	SW_SHOWNORMAL    = 1
+	SW_SHOWMINIMIZED = 2
+	SW_SHOWMAXIMIZED = 3
+
+	WPF_RESTORETOMAXIMIZED = 0x0002
+
+	MONITOR_DEFAULTTONEAREST = 0x00000002
+	MONITORINFOF_PRIMARY     = 0x00000001
+)
+
+// POINT represents a Windows POINT structure
+type POINT struct {
+	X int32
+	Y int32
+}
+
+// WINDOWPLACEMENT represents a Windows WINDOWPLACEMENT structure
+type WINDOWPLACEMENT struct {
+	Length           uint32
+	Flags            uint32
+	ShowCmd          uint32
+	PtMinPosition    POINT
+	PtMaxPosition    POINT
+	RcNormalPosition RECT
+}
+
+// MONITORINFOEX represents a Windows MONITORINFOEXW structure
+type MONITORINFOEX struct {
+	CbSize    uint32
+	RcMonitor RECT
+	RcWork    RECT
+	DwFlags   uint32
+	SzDevice  [32]uint16
+}
+
+var (
+	procGetWindowPlacement  = user32.NewProc("GetWindowPlacement")
+	procSetWindowPlacement  = user32.NewProc("SetWindowPlacement")
+	procMonitorFromWindow   = user32.NewProc("MonitorFromWindow")
+	procMonitorFromRect     = user32.NewProc("MonitorFromRect")
+	procGetMonitorInfoW     = user32.NewProc("GetMonitorInfoW")
+	procEnumDisplayMonitors = user32.NewProc("EnumDisplayMonitors")
+
+	// Monitor enumeration collects handles through a single shared callback
+	enumMonitorsMu      sync.Mutex
+	enumMonitorsHandles []uintptr
+	enumMonitorsProc    = syscall.NewCallback(func(monitor, hdc, rect, data uintptr) uintptr {
+		enumMonitorsHandles = append(enumMonitorsHandles, monitor)
+		return 1 // Continue enumeration
+	})
+)
+
+// GetWindowPlacement returns the restored bounds and state of a window in screen coordinates
+func GetWindowPlacement(hwnd syscall.Handle) (geometry.Placement, error) {
+	wp := WINDOWPLACEMENT{Length: uint32(unsafe.Sizeof(WINDOWPLACEMENT{}))}
+	ret, _, err := procGetWindowPlacement.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&wp)))
+	if ret == 0 {
+		return geometry.Placement{}, err
+	}
+
+	monitorHandle, _, _ := procMonitorFromWindow.Call(uintptr(hwnd), MONITOR_DEFAULTTONEAREST)
+	monitor, err := getMonitorInfo(monitorHandle)
+	if err != nil {
+		return geometry.Placement{}, err
+	}
+
+	// The normal position is in workspace coordinates, which start at the work area
+	bounds := rectToGeometry(wp.RcNormalPosition)
+	bounds.X += monitor.WorkArea.X - monitor.Bounds.X
+	bounds.Y += monitor.WorkArea.Y - monitor.Bounds.Y
+
+	minimized := wp.ShowCmd == SW_SHOWMINIMIZED
+	return geometry.Placement{
+		Bounds:    bounds,
+		Monitor:   monitor.ID,
+		DPI:       GetDpiForWindow(hwnd),
+		Maximized: wp.ShowCmd == SW_SHOWMAXIMIZED || (minimized && wp.Flags&WPF_RESTORETOMAXIMIZED != 0),
+		Minimized: minimized,
+	}, nil
+}
+
+// SetWindowPlacement moves a window to the restored bounds and shows it in the saved state
+func SetWindowPlacement(hwnd syscall.Handle, p geometry.Placement) error {
+	rect := RECT{
+		Left:   int32(p.Bounds.X),
+		Top:    int32(p.Bounds.Y),
+		Right:  int32(p.Bounds.Right()),
+		Bottom: int32(p.Bounds.Bottom()),
+	}
+
+	// Convert screen coordinates to workspace coordinates of the target monitor
+	monitorHandle, _, _ := procMonitorFromRect.Call(uintptr(unsafe.Pointer(&rect)), MONITOR_DEFAULTTONEAREST)
+	if monitor, err := getMonitorInfo(monitorHandle); err == nil {
+		dx := int32(monitor.WorkArea.X - monitor.Bounds.X)
+		dy := int32(monitor.WorkArea.Y - monitor.Bounds.Y)
+		rect.Left, rect.Right = rect.Left-dx, rect.Right-dx
+		rect.Top, rect.Bottom = rect.Top-dy, rect.Bottom-dy
+	}
+
+	wp := WINDOWPLACEMENT{
+		Length:           uint32(unsafe.Sizeof(WINDOWPLACEMENT{})),
+		ShowCmd:          SW_SHOWNORMAL,
+		RcNormalPosition: rect,
+	}
+	switch {
+	case p.Minimized:
+		wp.ShowCmd = SW_SHOWMINIMIZED
+		if p.Maximized {
+			wp.Flags = WPF_RESTORETOMAXIMIZED
+		}
+	case p.Maximized:
+		wp.ShowCmd = SW_SHOWMAXIMIZED
+	}
+
+	ret, _, err := procSetWindowPlacement.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&wp)))
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// GetMonitors returns the monitors attached to the system
+func GetMonitors() ([]geometry.Monitor, error) {
+	enumMonitorsMu.Lock()
+	enumMonitorsHandles = nil
+	ret, _, err := procEnumDisplayMonitors.Call(0, 0, enumMonitorsProc, 0)
+	handles := enumMonitorsHandles
+	enumMonitorsMu.Unlock()
+	if ret == 0 {
+		return nil, err
+	}
+
+	monitors := make([]geometry.Monitor, 0, len(handles))
+	for _, handle := range handles {
+		monitor, err := getMonitorInfo(handle)
+		if err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, monitor)
+	}
+	return monitors, nil
+}
+
+// getMonitorInfo returns the bounds, DPI and device name of a monitor
+func getMonitorInfo(handle uintptr) (geometry.Monitor, error) {
+	info := MONITORINFOEX{CbSize: uint32(unsafe.Sizeof(MONITORINFOEX{}))}
+	ret, _, err := procGetMonitorInfoW.Call(handle, uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return geometry.Monitor{}, err
+	}
+
+	return geometry.Monitor{
+		ID:       syscall.UTF16ToString(info.SzDevice[:]),
+		Bounds:   rectToGeometry(info.RcMonitor),
+		WorkArea: rectToGeometry(info.RcWork),
+		DPI:      getDpiForMonitor(handle),
+		Primary:  info.DwFlags&MONITORINFOF_PRIMARY != 0,
+	}, nil
+}
+
+// rectToGeometry converts a Windows RECT to a geometry rectangle
+func rectToGeometry(r RECT) geometry.Rect {
+	return geometry.Rect{
+		X:      int(r.Left),
+		Y:      int(r.Top),
+		Width:  int(r.Right - r.Left),
+		Height: int(r.Bottom - r.Top),
+	}
+}
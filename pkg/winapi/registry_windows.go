@@ -0,0 +1,107 @@
+package winapi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Registry constants
+const (
+	HKEY_CURRENT_USER       = 0x80000001
+	KEY_WRITE               = 0x20006
+	REG_SZ                  = 1
+	REG_OPTION_NON_VOLATILE = 0
+
+	ERROR_FILE_NOT_FOUND syscall.Errno = 2
+)
+
+var (
+	advapi32            = syscall.NewLazyDLL("advapi32.dll")
+	procRegCreateKeyExW = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValueExW  = advapi32.NewProc("RegSetValueExW")
+	procRegDeleteTreeW  = advapi32.NewProc("RegDeleteTreeW")
+	procRegCloseKey     = advapi32.NewProc("RegCloseKey")
+)
+
+// RegisterURLProtocol registers a URL scheme for the current user, so links like scheme://... start command.
+// The command receives the link as %1 and icon is shown for the links, e.g. "C:\hobaa.exe,0".
+func RegisterURLProtocol(scheme, command, icon string) error {
+	root := `Software\Classes\` + scheme
+	values := []struct {
+		key, name, data string
+	}{
+		{root, "", "URL:" + scheme + " Protocol"},
+		{root, "URL Protocol", ""},
+		{root + `\DefaultIcon`, "", icon},
+		{root + `\shell\open\command`, "", command},
+	}
+	for _, v := range values {
+		if err := setRegistryString(HKEY_CURRENT_USER, v.key, v.name, v.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnregisterURLProtocol removes a URL scheme registered for the current user.
+// Removing a scheme that is not registered is not an error.
+func UnregisterURLProtocol(scheme string) error {
+	keyW, err := syscall.UTF16PtrFromString(`Software\Classes\` + scheme)
+	if err != nil {
+		return err
+	}
+
+	ret, _, _ := procRegDeleteTreeW.Call(HKEY_CURRENT_USER, uintptr(unsafe.Pointer(keyW)))
+	if ret != 0 && syscall.Errno(ret) != ERROR_FILE_NOT_FOUND {
+		return syscall.Errno(ret)
+	}
+	return nil
+}
+
+// setRegistryString creates a registry key if needed and sets a string value, or its default value if name is empty
+func setRegistryString(root uintptr, key, name, data string) error {
+	keyW, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return err
+	}
+	nameW, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	dataW, err := syscall.UTF16FromString(data)
+	if err != nil {
+		return err
+	}
+
+	// Create or open the key
+	var handle syscall.Handle
+	ret, _, _ := procRegCreateKeyExW.Call(
+		root,
+		uintptr(unsafe.Pointer(keyW)),
+		0,
+		0,
+		REG_OPTION_NON_VOLATILE,
+		KEY_WRITE,
+		0,
+		uintptr(unsafe.Pointer(&handle)),
+		0,
+	)
+	if ret != 0 {
+		return syscall.Errno(ret)
+	}
+	defer procRegCloseKey.Call(uintptr(handle))
+
+	// Set the value including the terminating null
+	ret, _, _ = procRegSetValueExW.Call(
+		uintptr(handle),
+		uintptr(unsafe.Pointer(nameW)),
+		0,
+		REG_SZ,
+		uintptr(unsafe.Pointer(&dataW[0])),
+		uintptr(len(dataW)*2),
+	)
+	if ret != 0 {
+		return syscall.Errno(ret)
+	}
+	return nil
+}
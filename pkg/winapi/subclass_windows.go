@@ -0,0 +1,104 @@
+package winapi
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Window messages observed by subclassed windows
+const (
+	WM_DESTROY       = 0x0002
+	WM_MOVE          = 0x0003
+	WM_SIZE          = 0x0005
+	WM_NCDESTROY     = 0x0082
+	WM_ENTERSIZEMOVE = 0x0231
+	WM_EXITSIZEMOVE  = 0x0232
+
+	GWLP_WNDPROC = -4
+)
+
+// MessageHandler is called with the messages of a subclassed window after the original window procedure
+type MessageHandler func(hwnd syscall.Handle, msg uint32, wParam, lParam uintptr)
+
+// subclass holds the original window procedure and handlers of a subclassed window
+type subclass struct {
+	original uintptr
+	handlers []MessageHandler
+}
+
+var (
+	procCallWindowProcW   = user32.NewProc("CallWindowProcW")
+	procSetWindowLongPtrW *syscall.LazyProc
+
+	// A single callback is shared by all windows since callbacks are never freed
+	subclassProc     = syscall.NewCallback(subclassWndProc)
+	subclassMu       sync.Mutex
+	subclassedWindow = map[syscall.Handle]*subclass{}
+)
+
+func init() {
+	// SetWindowLongPtrW is only exported by 64-bit user32.dll
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		procSetWindowLongPtrW = user32.NewProc("SetWindowLongPtrW")
+	} else {
+		procSetWindowLongPtrW = procSetWindowLongW
+	}
+}
+
+// SubclassWindow calls the handler with every message the window receives until it is destroyed.
+// It must be called on the thread that owns the window.
+func SubclassWindow(hwnd syscall.Handle, handler MessageHandler) error {
+	subclassMu.Lock()
+	defer subclassMu.Unlock()
+
+	// Add the handler if the window is already subclassed
+	if s, ok := subclassedWindow[hwnd]; ok {
+		s.handlers = append(s.handlers, handler)
+		return nil
+	}
+
+	// Replace the window procedure
+	index := GWLP_WNDPROC
+	original, _, err := procSetWindowLongPtrW.Call(uintptr(hwnd), uintptr(index), subclassProc)
+	if original == 0 {
+		return err
+	}
+	subclassedWindow[hwnd] = &subclass{
+		original: original,
+		handlers: []MessageHandler{handler},
+	}
+	return nil
+}
+
+// subclassWndProc forwards messages to the original window procedure and then to the handlers
+func subclassWndProc(hwnd, msg, wParam, lParam uintptr) uintptr {
+	subclassMu.Lock()
+	s, ok := subclassedWindow[syscall.Handle(hwnd)]
+	var handlers []MessageHandler
+	if ok {
+		handlers = append(handlers, s.handlers...)
+	}
+	subclassMu.Unlock()
+	if !ok {
+		return 0
+	}
+
+	// Let the original window procedure handle the message first
+	result, _, _ := procCallWindowProcW.Call(s.original, hwnd, msg, wParam, lParam)
+	for _, handler := range handlers {
+		handler(syscall.Handle(hwnd), uint32(msg), wParam, lParam)
+	}
+
+	// Restore the original window procedure once the window is gone
+	if msg == WM_NCDESTROY {
+		index := GWLP_WNDPROC
+		procSetWindowLongPtrW.Call(hwnd, uintptr(index), s.original)
+
+		subclassMu.Lock()
+		delete(subclassedWindow, syscall.Handle(hwnd))
+		subclassMu.Unlock()
+	}
+
+	return result
+}
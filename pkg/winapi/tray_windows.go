@@ -0,0 +1,153 @@
+package winapi
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Notification area constants
+const (
+	NIM_ADD        = 0x00000000
+	NIM_MODIFY     = 0x00000001
+	NIM_DELETE     = 0x00000002
+	NIM_SETVERSION = 0x00000004
+
+	NIF_MESSAGE = 0x00000001
+	NIF_ICON    = 0x00000002
+	NIF_TIP     = 0x00000004
+	NIF_INFO    = 0x00000010
+	NIF_SHOWTIP = 0x00000080
+	NIIF_USER   = 0x00000004
+
+	NOTIFYICON_VERSION_4 = 4
+
+	WM_USER              = 0x0400
+	WM_APP               = 0x8000
+	WM_LBUTTONUP         = 0x0202
+	NIN_BALLOONUSERCLICK = WM_USER + 5
+
+	// Message box constants
+	MB_YESNO        = 0x00000004
+	MB_ICONQUESTION = 0x00000020
+	IDYES           = 6
+)
+
+// NOTIFYICONDATA represents a Windows NOTIFYICONDATAW structure
+type NOTIFYICONDATA struct {
+	CbSize           uint32
+	HWnd             syscall.Handle
+	UID              uint32
+	UFlags           uint32
+	UCallbackMessage uint32
+	HIcon            uintptr
+	SzTip            [128]uint16
+	DwState          uint32
+	DwStateMask      uint32
+	SzInfo           [256]uint16
+	UVersion         uint32
+	SzInfoTitle      [64]uint16
+	DwInfoFlags      uint32
+	GuidItem         [16]byte
+	HBalloonIcon     uintptr
+}
+
+var (
+	procShell_NotifyIconW = shell32.NewProc("Shell_NotifyIconW")
+	procMessageBoxW       = user32.NewProc("MessageBoxW")
+)
+
+// AddTrayIcon adds an icon for the window to the notification area.
+// Clicks on the icon and its balloons are sent to the window as callbackMessage.
+func AddTrayIcon(hwnd syscall.Handle, id uint32, callbackMessage uint32, icon uintptr, tip string) error {
+	data := newNotifyIconData(hwnd, id)
+	data.UFlags = NIF_MESSAGE | NIF_ICON | NIF_TIP | NIF_SHOWTIP
+	data.UCallbackMessage = callbackMessage
+	data.HIcon = icon
+	copyUTF16(data.SzTip[:], tip)
+	if err := shellNotifyIcon(NIM_ADD, data); err != nil {
+		return err
+	}
+
+	// Use the current behavior for callback messages
+	data.UVersion = NOTIFYICON_VERSION_4
+	return shellNotifyIcon(NIM_SETVERSION, data)
+}
+
+// ShowTrayBalloon shows a notification from a notification area icon, which Windows 10 and later show as a toast
+func ShowTrayBalloon(hwnd syscall.Handle, id uint32, title, text string, icon uintptr) error {
+	data := newNotifyIconData(hwnd, id)
+	data.UFlags = NIF_INFO
+	data.DwInfoFlags = NIIF_USER
+	data.HBalloonIcon = icon
+	copyUTF16(data.SzInfoTitle[:], title)
+	copyUTF16(data.SzInfo[:], text)
+	return shellNotifyIcon(NIM_MODIFY, data)
+}
+
+// HideTrayBalloon removes the notification shown from a notification area icon
+func HideTrayBalloon(hwnd syscall.Handle, id uint32) error {
+	data := newNotifyIconData(hwnd, id)
+	data.UFlags = NIF_INFO
+	return shellNotifyIcon(NIM_MODIFY, data)
+}
+
+// DeleteTrayIcon removes an icon from the notification area
+func DeleteTrayIcon(hwnd syscall.Handle, id uint32) error {
+	return shellNotifyIcon(NIM_DELETE, newNotifyIconData(hwnd, id))
+}
+
+// LoadIcon loads an icon of the given size from an ICO file
+func LoadIcon(iconPath string, size int) uintptr {
+	iconPathW, err := syscall.UTF16PtrFromString(iconPath)
+	if err != nil {
+		return 0
+	}
+	icon, _, _ := procLoadImageW.Call(
+		0,
+		uintptr(unsafe.Pointer(iconPathW)),
+		IMAGE_ICON,
+		uintptr(size),
+		uintptr(size),
+		LR_LOADFROMFILE,
+	)
+	return icon
+}
+
+// AskYesNo shows a modal question owned by the window and reports whether the user answered yes
+func AskYesNo(hwnd syscall.Handle, caption, text string) bool {
+	captionW, _ := syscall.UTF16PtrFromString(caption)
+	textW, _ := syscall.UTF16PtrFromString(text)
+	ret, _, _ := procMessageBoxW.Call(
+		uintptr(hwnd),
+		uintptr(unsafe.Pointer(textW)),
+		uintptr(unsafe.Pointer(captionW)),
+		MB_YESNO|MB_ICONQUESTION,
+	)
+	return ret == IDYES
+}
+
+// newNotifyIconData returns the structure identifying a notification area icon
+func newNotifyIconData(hwnd syscall.Handle, id uint32) *NOTIFYICONDATA {
+	data := &NOTIFYICONDATA{HWnd: hwnd, UID: id}
+	data.CbSize = uint32(unsafe.Sizeof(*data))
+	return data
+}
+
+// shellNotifyIcon sends a message to the notification area
+func shellNotifyIcon(message uint32, data *NOTIFYICONDATA) error {
+	ret, _, err := procShell_NotifyIconW.Call(uintptr(message), uintptr(unsafe.Pointer(data)))
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// copyUTF16 copies a string into a fixed size buffer, truncating it to leave room for the terminating null
+func copyUTF16(dst []uint16, s string) {
+	src, _ := syscall.UTF16FromString(s)
+	if len(src) > len(dst) {
+		src = src[:len(dst)]
+		src[len(src)-1] = 0
+	}
+	copy(dst, src)
+}
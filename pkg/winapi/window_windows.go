@@ -2,6 +2,7 @@
 package winapi
 
 import (
+	"fmt"
 	"syscall"
 	"time"
 	"unsafe"
@@ -28,6 +29,14 @@ const (
 	// Window messages
 	WM_SETICON = 0x0080
 
+	// Window activation constants
+	SW_RESTORE = 9
+	ASFW_ANY   = -1
+
+	// Window flashing constants
+	FLASHW_ALL       = 0x00000003
+	FLASHW_TIMERNOFG = 0x0000000C
+
 	// Image loading constants
 	LR_LOADFROMFILE = 0x0010
 	IMAGE_ICON      = 1
@@ -45,8 +54,15 @@ var (
 	procLoadImageW     = user32.NewProc("LoadImageW")
 	procSendMessageW   = user32.NewProc("SendMessageW")
 
+	procIsIconic                 = user32.NewProc("IsIconic")
+	procShowWindow               = user32.NewProc("ShowWindow")
+	procSetForegroundWindow      = user32.NewProc("SetForegroundWindow")
+	procAllowSetForegroundWindow = user32.NewProc("AllowSetForegroundWindow")
+	procFlashWindowEx            = user32.NewProc("FlashWindowEx")
+
 	shell32        = syscall.NewLazyDLL("shell32.dll")
 	shChangeNotify = shell32.NewProc("SHChangeNotify")
+	shellExecuteW  = shell32.NewProc("ShellExecuteW")
 )
 
 // GetWindowSize gets the window size using Windows API
@@ -67,33 +83,38 @@ func GetWindowSize(hwnd syscall.Handle) (int, int, error) {
 	return width, height, nil
 }
 
-// MonitorWindowSize periodically checks the window size and calls the callback when changed
-func MonitorWindowSize(hwnd syscall.Handle, callback func(width, height int)) {
-	// Wait a bit for the window to fully initialize
-	time.Sleep(1 * time.Second)
-
-	lastWidth, lastHeight := 0, 0
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		// Check if the window still exists
-		if hwnd == 0 {
-			return
-		}
-
-		// Get the current window size
-		width, height, err := GetWindowSize(hwnd)
-		if err != nil {
-			continue
-		}
-
-		// If the size has changed, call the callback
-		if width != lastWidth || height != lastHeight {
-			lastWidth, lastHeight = width, height
-			callback(width, height)
-		}
+// FocusWindow restores a minimized window and brings it to the front
+func FocusWindow(hwnd syscall.Handle) {
+	if iconic, _, _ := procIsIconic.Call(uintptr(hwnd)); iconic != 0 {
+		procShowWindow.Call(uintptr(hwnd), SW_RESTORE)
+	}
+	procSetForegroundWindow.Call(uintptr(hwnd))
+}
+
+// AllowSetForegroundWindow lets another process bring its window to the front,
+// which Windows only allows for the process the user last interacted with
+func AllowSetForegroundWindow() {
+	asfwAny := ASFW_ANY
+	procAllowSetForegroundWindow.Call(uintptr(asfwAny))
+}
+
+// FLASHWINFO represents a Windows FLASHWINFO structure
+type FLASHWINFO struct {
+	CbSize    uint32
+	Hwnd      syscall.Handle
+	DwFlags   uint32
+	UCount    uint32
+	DwTimeout uint32
+}
+
+// FlashWindow flashes the taskbar button of a window until it comes to the front
+func FlashWindow(hwnd syscall.Handle) {
+	info := FLASHWINFO{
+		Hwnd:    hwnd,
+		DwFlags: FLASHW_ALL | FLASHW_TIMERNOFG,
 	}
+	info.CbSize = uint32(unsafe.Sizeof(info))
+	procFlashWindowEx.Call(uintptr(unsafe.Pointer(&info)))
 }
 
 // ClearIconCache clears the Windows icon cache using Shell API
@@ -106,6 +127,53 @@ func ClearIconCache() {
 	)
 }
 
+// ShellOpen opens a file or URL with its associated application
+func ShellOpen(target string) error {
+	verb, _ := syscall.UTF16PtrFromString("open")
+	file, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+
+	ret, _, _ := shellExecuteW.Call(
+		0,
+		uintptr(unsafe.Pointer(verb)),
+		uintptr(unsafe.Pointer(file)),
+		0,
+		0,
+		SW_SHOWNORMAL,
+	)
+
+	// Values up to 32 are error codes
+	if ret <= 32 {
+		return fmt.Errorf("ShellExecute failed with code %d", ret)
+	}
+	return nil
+}
+
+// ShowInFolder opens an Explorer window with the file selected
+func ShowInFolder(path string) error {
+	verb, _ := syscall.UTF16PtrFromString("open")
+	file, _ := syscall.UTF16PtrFromString("explorer.exe")
+	params, err := syscall.UTF16PtrFromString(`/select,"` + path + `"`)
+	if err != nil {
+		return err
+	}
+
+	ret, _, _ := shellExecuteW.Call(
+		0,
+		uintptr(unsafe.Pointer(verb)),
+		uintptr(unsafe.Pointer(file)),
+		uintptr(unsafe.Pointer(params)),
+		0,
+		SW_SHOWNORMAL,
+	)
+	if ret <= 32 {
+		return fmt.Errorf("ShellExecute failed with code %d", ret)
+	}
+	return nil
+}
+
 // Sleep waits for the specified milliseconds
 func Sleep(milliseconds int) {
 	time.Sleep(time.Duration(milliseconds) * time.Millisecond)